@@ -7,8 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-
-	"github.com/unweave/unweave/api/types"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -16,6 +14,10 @@ import (
 type Config struct {
 	ApiURL string `json:"apiURL"`
 	Token  string `json:"token"`
+
+	// Retry configures how requests failing with transient errors are retried. Uses
+	// DefaultRetryPolicy if nil.
+	Retry *RetryPolicy `json:"-"`
 }
 
 type Client struct {
@@ -35,10 +37,15 @@ type Client struct {
 }
 
 func NewClient(cfg Config) *Client {
+	retry := DefaultRetryPolicy
+	if cfg.Retry != nil {
+		retry = *cfg.Retry
+	}
+
 	c := &Client{
 		cfg: &cfg,
 		client: &http.Client{
-			Transport: newLoggedTransport(http.DefaultTransport),
+			Transport: newRetryTransport(newLoggedTransport(http.DefaultTransport), retry),
 		},
 	}
 	c.Build = &BuildService{client: c}
@@ -132,16 +139,7 @@ func (c *Client) ExecuteRest(ctx context.Context, req *RestRequest, resp interfa
 		return fmt.Errorf("status %s, fail to read response body", res.Status)
 	}
 	if res.StatusCode < 200 || res.StatusCode >= 400 {
-		var errResp types.Error
-		if err = json.NewDecoder(&buf).Decode(&errResp); err != nil {
-			return fmt.Errorf("status %s, fail to decode response body", res.Status)
-		}
-		return &types.Error{
-			Code:       errResp.Code,
-			Message:    errResp.Message,
-			Suggestion: errResp.Suggestion,
-			Provider:   errResp.Provider,
-		}
+		return decodeError(res, &buf)
 	}
 
 	if err = json.NewDecoder(&buf).Decode(&resp); err == io.EOF {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
@@ -63,22 +62,19 @@ func (s *EndpointService) EvalAttach(ctx context.Context, userID, projectID, end
 	return nil
 }
 
-func (s *EndpointService) RunEvalCheck(ctx context.Context, userID, projectID, endpointID string) error {
+func (s *EndpointService) RunEvalCheck(ctx context.Context, userID, projectID, endpointID string) (types.EndpointCheckRun, error) {
 	uri := fmt.Sprintf("projects/%s/%s/endpoints/%s/check", userID, projectID, endpointID)
 	req, err := s.client.NewAuthorizedRestRequest(Post, uri, nil, nil)
 	if err != nil {
-		return err
+		return types.EndpointCheckRun{}, err
 	}
 
 	response := types.EndpointCheckRun{}
 	if err = s.client.ExecuteRest(ctx, req, &response); err != nil {
-		return err
+		return types.EndpointCheckRun{}, err
 	}
 
-	ui.JSON(response)
-	ui.Infof("check id: %s", response.CheckID)
-
-	return nil
+	return response, nil
 }
 
 func (s *EndpointService) EndpointCheckStatus(ctx context.Context, userID, projectID, checkID string) (types.EndpointCheck, error) {
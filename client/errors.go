@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+var (
+	// ErrUnauthorized matches API errors for requests without valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound matches API errors for resources that don't exist.
+	ErrNotFound = errors.New("not found")
+)
+
+// apiError wraps the error returned by the API so that it can be matched against the
+// sentinel errors with errors.Is. Use errors.As with a *types.Error to get the details.
+type apiError struct {
+	status int
+	err    *types.Error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+func (e *apiError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.status == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.status == http.StatusNotFound
+	}
+	return false
+}
+
+// decodeError decodes the body of a failed response into an API error.
+func decodeError(res *http.Response, body io.Reader) error {
+	var errResp types.Error
+	if err := json.NewDecoder(body).Decode(&errResp); err != nil {
+		return fmt.Errorf("status %s, fail to decode response body", res.Status)
+	}
+	if errResp.Code == 0 {
+		errResp.Code = res.StatusCode
+	}
+	return &apiError{status: res.StatusCode, err: &types.Error{
+		Code:       errResp.Code,
+		Message:    errResp.Message,
+		Suggestion: errResp.Suggestion,
+		Provider:   errResp.Provider,
+	}}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestAPIErrors(t *testing.T) {
+	ctx := context.Background()
+
+	errorServer := func(status int) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code": 0, "message": "nope", "suggestion": "try again"}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	t.Run("should match unauthorized errors", func(t *testing.T) {
+		srv := errorServer(http.StatusUnauthorized)
+
+		_, err := newTestClient(srv.URL).Exec.Get(ctx, "owner", "project", "id")
+		assert.ErrorIs(t, err, ErrUnauthorized)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("should match not found errors", func(t *testing.T) {
+		srv := errorServer(http.StatusNotFound)
+
+		_, err := newTestClient(srv.URL).Exec.Get(ctx, "owner", "project", "id")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("should keep the API error details", func(t *testing.T) {
+		srv := errorServer(http.StatusBadRequest)
+
+		_, err := newTestClient(srv.URL).Exec.Get(ctx, "owner", "project", "id")
+
+		var e *types.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, "nope", e.Message)
+		assert.Equal(t, "try again", e.Suggestion)
+	})
+}
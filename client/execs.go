@@ -3,7 +3,6 @@ package client
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -27,52 +26,40 @@ type ExecService struct {
 }
 
 func (s *ExecService) Create(ctx context.Context, owner, project string, params types.ExecCreateParams) (*types.Exec, error) {
-	buf := &bytes.Buffer{}
-	w := multipart.NewWriter(buf)
-
+	// Sessions are created with an idempotency key so that the request can be retried,
+	// unless a context is streamed with it.
+	var body io.Reader
+	var contentType string
 	if params.Source != nil && params.Source.Context != nil {
-		fw, err := w.CreateFormFile("context", "context.zip")
-		if err != nil {
-			return nil, err
-		}
-		if _, err = io.Copy(fw, params.Source.Context); err != nil {
+		body, contentType = streamMultipartForm(params.Source.Context, params)
+	} else {
+		buf := &bytes.Buffer{}
+		w := multipart.NewWriter(buf)
+		if err := writeMultipartForm(w, nil, params); err != nil {
 			return nil, err
 		}
-	}
-
-	paramsJSON, err := json.Marshal(params)
-	if err != nil {
-		return nil, err
-	}
-	if err = w.WriteField("params", string(paramsJSON)); err != nil {
-		return nil, err
-	}
-	if err = w.Close(); err != nil {
-		return nil, err
+		body, contentType = buf, w.FormDataContentType()
 	}
 
 	uri := fmt.Sprintf("projects/%s/%s/sessions", owner, project)
 
-	req, err := http.NewRequest("POST", uri, buf)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", w.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+s.client.cfg.Token)
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set("Authorization", "Bearer "+s.client.cfg.Token)
+	// Retried requests must not provision a second session.
+	header.Set(IdempotencyKeyHeader, newIdempotencyKey())
 
 	// TODO: hack for now: add box query if PersistentFS is set
 	query := ""
 	r := &RestRequest{
 		Url:    fmt.Sprintf("%s/%s?%s", s.client.cfg.ApiURL, uri, query),
-		Header: req.Header,
-		Body:   buf,
+		Header: header,
+		Body:   body,
 		Type:   Post,
 	}
-	req.Header.Set("Content-Type", w.FormDataContentType())
 
 	exec := &types.Exec{}
-	if err = s.client.ExecuteRest(ctx, r, exec); err != nil {
+	if err := s.client.ExecuteRest(ctx, r, exec); err != nil {
 		return nil, err
 	}
 	return exec, nil
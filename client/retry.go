@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/unweave/cli/ui"
+)
+
+// IdempotencyKeyHeader is sent with non-idempotent requests that are safe to retry. The
+// API uses it to deduplicate retried requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy configures how requests that fail with a transient error are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Set it to
+	// 1 to disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles on every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff and any Retry-After duration sent by the API.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when the client Config doesn't set one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+type retryRoundTripper struct {
+	rt     http.RoundTripper
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// newRetryTransport takes an http.RoundTripper and returns a new one that retries
+// idempotent requests on network errors and transient server errors
+func newRetryTransport(rt http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	return &retryRoundTripper{rt: rt, policy: policy, sleep: sleepContext}
+}
+
+func (r *retryRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if r.policy.MaxAttempts <= 1 || !isRetryable(request) {
+		return r.rt.RoundTrip(request)
+	}
+
+	ctx := request.Context()
+	for attempt := 1; ; attempt++ {
+		req := request
+		if attempt > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = request.Clone(ctx)
+			req.Body = body
+		}
+
+		response, err := r.rt.RoundTrip(req)
+		if attempt >= r.policy.MaxAttempts || !shouldRetry(response, err) || ctx.Err() != nil {
+			return response, err
+		}
+
+		delay := r.backoff(attempt, response)
+		if response != nil {
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		ui.Debugf("Retrying %s %s in %s (attempt %d of %d)", request.Method, request.URL.String(), delay, attempt+1, r.policy.MaxAttempts)
+		if err = r.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isRetryable returns whether a request can be safely sent more than once. Only
+// idempotent methods or requests carrying an idempotency key are retried, and only if
+// their body can be replayed.
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// shouldRetry returns whether the outcome of a request is a transient failure. A 503 is
+// only retried if the API sends a Retry-After header since it's also used to signal
+// that a provider is out of capacity.
+func shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	case http.StatusServiceUnavailable:
+		return res.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+// backoff returns how long to wait before the next attempt. It honors the Retry-After
+// header if present and otherwise uses exponential backoff with full jitter.
+func (r *retryRoundTripper) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			if d > r.policy.MaxDelay {
+				return r.policy.MaxDelay
+			}
+			return d
+		}
+	}
+
+	ceiling := r.policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.policy.MaxDelay {
+		ceiling = r.policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(mathrand.Int63n(int64(ceiling)))
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newIdempotencyKey returns a random key to deduplicate retried requests.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+type recordedRequest struct {
+	method         string
+	body           string
+	idempotencyKey string
+}
+
+// flakyServer fails the first `failures` requests with status and then succeeds.
+func flakyServer(t *testing.T, failures int, status int, header http.Header, body string) (*httptest.Server, func() []recordedRequest) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, recordedRequest{
+			method:         r.Method,
+			body:           string(buf),
+			idempotencyKey: r.Header.Get(IdempotencyKeyHeader),
+		})
+		n := len(requests)
+		mu.Unlock()
+
+		if n <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code": 500, "message": "transient"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest{}, requests...)
+	}
+}
+
+func newTestClient(url string) *Client {
+	return NewClient(Config{
+		ApiURL: url,
+		Retry:  &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should retry idempotent requests on server errors", func(t *testing.T) {
+		srv, requests := flakyServer(t, 2, http.StatusBadGateway, nil, `{"sessions": []}`)
+
+		_, err := newTestClient(srv.URL).Exec.List(ctx, "owner", "project", false)
+		require.NoError(t, err)
+		assert.Len(t, requests(), 3)
+	})
+
+	t.Run("should give up after the max attempts", func(t *testing.T) {
+		srv, requests := flakyServer(t, 5, http.StatusInternalServerError, nil, `{}`)
+
+		_, err := newTestClient(srv.URL).Exec.List(ctx, "owner", "project", false)
+
+		var e *types.Error
+		require.ErrorAs(t, err, &e)
+		assert.Len(t, requests(), 3)
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		srv, requests := flakyServer(t, 1, http.StatusNotFound, nil, `{}`)
+
+		_, err := newTestClient(srv.URL).Exec.List(ctx, "owner", "project", false)
+		assert.Error(t, err)
+		assert.Len(t, requests(), 1)
+	})
+
+	t.Run("should not retry a 503 without Retry-After", func(t *testing.T) {
+		srv, requests := flakyServer(t, 1, http.StatusServiceUnavailable, nil, `{}`)
+
+		_, err := newTestClient(srv.URL).Exec.List(ctx, "owner", "project", false)
+		assert.Error(t, err)
+		assert.Len(t, requests(), 1)
+	})
+
+	t.Run("should retry a 503 with Retry-After", func(t *testing.T) {
+		header := http.Header{"Retry-After": []string{"0"}}
+		srv, requests := flakyServer(t, 1, http.StatusServiceUnavailable, header, `{"sessions": []}`)
+
+		_, err := newTestClient(srv.URL).Exec.List(ctx, "owner", "project", false)
+		assert.NoError(t, err)
+		assert.Len(t, requests(), 2)
+	})
+
+	t.Run("should not retry a POST without an idempotency key", func(t *testing.T) {
+		srv, requests := flakyServer(t, 1, http.StatusBadGateway, nil, `{}`)
+
+		_, err := newTestClient(srv.URL).Evals.Create(ctx, "owner", "project", "exec-id")
+		assert.Error(t, err)
+		assert.Len(t, requests(), 1)
+	})
+
+	t.Run("should replay session creation with the same idempotency key", func(t *testing.T) {
+		srv, requests := flakyServer(t, 1, http.StatusBadGateway, nil, `{"id": "exec-id"}`)
+
+		exec, err := newTestClient(srv.URL).Exec.Create(ctx, "owner", "project", types.ExecCreateParams{Provider: "unweave"})
+		require.NoError(t, err)
+		assert.Equal(t, "exec-id", exec.ID)
+
+		reqs := requests()
+		require.Len(t, reqs, 2)
+		assert.NotEmpty(t, reqs[0].idempotencyKey)
+		assert.Equal(t, reqs[0].idempotencyKey, reqs[1].idempotencyKey)
+		assert.Equal(t, reqs[0].body, reqs[1].body)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	rt := &retryRoundTripper{policy: RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}}
+
+	t.Run("should honor Retry-After capped at the max delay", func(t *testing.T) {
+		res := &http.Response{Header: http.Header{"Retry-After": []string{"1"}}}
+		assert.Equal(t, time.Second, rt.backoff(1, res))
+
+		res.Header.Set("Retry-After", "60")
+		assert.Equal(t, time.Second, rt.backoff(1, res))
+	})
+
+	t.Run("should keep jittered backoff under the exponential ceiling", func(t *testing.T) {
+		for attempt := 1; attempt <= 6; attempt++ {
+			ceiling := rt.policy.BaseDelay << (attempt - 1)
+			if ceiling > rt.policy.MaxDelay {
+				ceiling = rt.policy.MaxDelay
+			}
+			d := rt.backoff(attempt, nil)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.Less(t, d, ceiling)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+)
+
+// writeMultipartForm writes the form of the requests uploading a context: the archive
+// read from context, if it isn't nil, in the context field and params as JSON in the
+// params field.
+func writeMultipartForm(w *multipart.Writer, context io.Reader, params any) error {
+	if context != nil {
+		fw, err := w.CreateFormFile("context", "context.zip")
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(fw, context); err != nil {
+			return err
+		}
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if err = w.WriteField("params", string(paramsJSON)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// streamMultipartForm returns a request body that writes the form of writeMultipartForm
+// as it's sent, and its content type. The context is never held in memory, so requests
+// sending the body can't be retried. The context is closed once it's been read or the
+// request fails, and errors reading it fail the request.
+func streamMultipartForm(context io.ReadCloser, params any) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		err := writeMultipartForm(w, context, params)
+		context.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr, w.FormDataContentType()
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+// trackedContext is a build context that records whether it was closed.
+type trackedContext struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (c *trackedContext) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestBuildCreateStreamsContext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should stream the context and params", func(t *testing.T) {
+		archive := bytes.Repeat([]byte("0123456789abcdef"), 1<<18) // 4 MiB
+
+		var contentLength int64
+		var gotContext []byte
+		var gotParams types.BuildsCreateParams
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentLength = r.ContentLength
+			mr, err := r.MultipartReader()
+			require.NoError(t, err)
+			for {
+				part, err := mr.NextPart()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				buf, _ := io.ReadAll(part)
+				switch part.FormName() {
+				case "context":
+					assert.Equal(t, "context.zip", part.FileName())
+					gotContext = buf
+				case "params":
+					require.NoError(t, json.Unmarshal(buf, &gotParams))
+				}
+			}
+			w.Write([]byte(`{"buildID": "bld-id"}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		buildCtx := &trackedContext{Reader: bytes.NewReader(archive)}
+		id, err := newTestClient(srv.URL).Build.Create(ctx, "owner", "project", types.BuildsCreateParams{
+			Builder:      "docker",
+			BuildContext: buildCtx,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "bld-id", id)
+		assert.Equal(t, int64(-1), contentLength, "the body should be streamed, not buffered")
+		assert.Equal(t, archive, gotContext)
+		assert.Equal(t, "docker", gotParams.Builder)
+		assert.True(t, buildCtx.closed.Load())
+	})
+
+	t.Run("should fail if the context can't be read", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte(`{"buildID": "bld-id"}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		buildCtx := &trackedContext{Reader: io.MultiReader(bytes.NewReader([]byte("partial")), failingReader{errors.New("disk on fire")})}
+		_, err := newTestClient(srv.URL).Build.Create(ctx, "owner", "project", types.BuildsCreateParams{BuildContext: buildCtx})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "disk on fire")
+		assert.True(t, buildCtx.closed.Load())
+		assert.LessOrEqual(t, requests.Load(), int32(1), "streamed requests can't be retried")
+	})
+
+	t.Run("should stop reading the context if the API rejects the request", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(`{"code": 413, "message": "context too large"}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		pr, pw := io.Pipe()
+		writerDone := make(chan error, 1)
+		go func() {
+			// A context bigger than any buffer on the way to the server.
+			_, err := pw.Write(make([]byte, 64<<20))
+			writerDone <- err
+		}()
+
+		_, err := newTestClient(srv.URL).Build.Create(ctx, "owner", "project", types.BuildsCreateParams{BuildContext: pr})
+		require.Error(t, err)
+
+		select {
+		case err := <-writerDone:
+			assert.ErrorIs(t, err, io.ErrClosedPipe)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the context is still being read after the request failed")
+		}
+	})
+}
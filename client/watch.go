@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// ErrWatchUnsupported is returned by Watch if the API doesn't offer an event stream for
+// sessions. Callers should fall back to polling.
+var ErrWatchUnsupported = errors.New("session watch is not supported by the API")
+
+// Watch subscribes to the server-sent event stream of a session. Every status event
+// carries the session as JSON. The returned channel is closed once the stream ends or
+// ctx is done.
+func (s *ExecService) Watch(ctx context.Context, owner, project, sessionID string) (<-chan types.Exec, error) {
+	url := fmt.Sprintf("%s/projects/%s/%s/sessions/%s/watch", s.client.cfg.ApiURL, owner, project, sessionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.client.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.client.cfg.Token)
+	}
+
+	res, err := s.client.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound,
+		res.StatusCode == http.StatusMethodNotAllowed,
+		res.StatusCode == http.StatusNotAcceptable,
+		res.StatusCode == http.StatusNotImplemented:
+		res.Body.Close()
+		return nil, ErrWatchUnsupported
+	case res.StatusCode < 200 || res.StatusCode >= 400:
+		defer res.Body.Close()
+		return nil, decodeError(res, res.Body)
+	case !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream"):
+		res.Body.Close()
+		return nil, ErrWatchUnsupported
+	}
+
+	execs := make(chan types.Exec)
+	go func() {
+		defer close(execs)
+		defer res.Body.Close()
+
+		err := readEvents(res.Body, func(event string, data []byte) bool {
+			if event != "" && event != "status" {
+				return true
+			}
+			var exec types.Exec
+			if err := json.Unmarshal(data, &exec); err != nil {
+				ui.Debugf("Failed to decode session event: %v", err)
+				return true
+			}
+			select {
+			case execs <- exec:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			ui.Debugf("Session event stream ended: %v", err)
+		}
+	}()
+
+	return execs, nil
+}
+
+// readEvents parses a text/event-stream body and calls fn for every event until fn
+// returns false or the stream ends.
+func readEvents(r io.Reader, fn func(event string, data []byte) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 && !fn(event, []byte(strings.Join(data, "\n"))) {
+				return nil
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by servers as a keep-alive.
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/ui"
+)
+
+// Whoami handles the Cobra command for showing which identity and scope the CLI
+// authenticates with
+func Whoami(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	token, source := config.Token()
+	if source == config.TokenSourceNone {
+		ui.Errorf("Not authenticated. Run `unweave login`, or set UNWEAVE_PROJECT_TOKEN or --token to use a token.")
+		os.Exit(1)
+	}
+
+	owner, project, projectErr := config.GetProjectOwnerAndName()
+	uwc := config.InitUnweaveClient()
+
+	var (
+		identity = "-"
+		scope    string
+		err      error
+	)
+	switch source {
+	case config.TokenSourceProject:
+		scope = "project " + config.Config.Project.URI
+		identity = "project token"
+	case config.TokenSourceUser:
+		scope = "account"
+		if email := config.Config.Unweave.User.Email; email != "" {
+			identity = email
+		}
+	case config.TokenSourceFlag:
+		scope = "unknown"
+	}
+
+	// Verify the token with the narrowest call its scope allows. Tokens saved with
+	// `unweave login --with-token` don't know their user, so they're checked like flags.
+	if source == config.TokenSourceUser && config.Config.Unweave.User.ID != "" {
+		account, aerr := uwc.Account.AccountGet(ctx, config.Config.Unweave.User.ID)
+		if aerr == nil {
+			identity = account.Email
+		}
+		err = aerr
+	} else if projectErr == nil {
+		if _, err = uwc.Account.ProjectGet(ctx, owner, project); err == nil && source == config.TokenSourceFlag {
+			scope = "project " + config.Config.Project.URI
+		}
+	} else {
+		err = projectErr
+	}
+
+	status := "valid"
+	if err != nil {
+		status = "invalid"
+		if !errors.Is(err, client.ErrUnauthorized) {
+			status = "unverified"
+		}
+		ui.Debugf("Failed to verify token: %s", err)
+	}
+
+	if ui.OutputJSON {
+		ui.JSON(map[string]any{
+			"context":  config.ActiveContext.Name,
+			"api_url":  config.Config.Unweave.ApiURL,
+			"source":   source,
+			"identity": identity,
+			"scope":    scope,
+			"status":   status,
+		})
+	} else {
+		results := []ui.ResultEntry{
+			{Key: "Context", Value: config.ActiveContext.Name},
+			{Key: "API", Value: config.Config.Unweave.ApiURL},
+			{Key: "Token", Value: tokenSourceDescription(source) + " (" + maskToken(token) + ")"},
+			{Key: "Identity", Value: identity},
+			{Key: "Scope", Value: scope},
+			{Key: "Status", Value: status},
+		}
+		ui.ResultTitle("Authenticated as:")
+		ui.Result(results, ui.IndentWidth)
+	}
+
+	if status == "invalid" {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func tokenSourceDescription(source config.TokenSource) string {
+	switch source {
+	case config.TokenSourceFlag:
+		return "--token flag"
+	case config.TokenSourceProject:
+		return "project token"
+	default:
+		return "user login"
+	}
+}
+
+// maskToken hides all but the last characters of a token.
+func maskToken(token string) string {
+	const visible = 4
+	if len(token) <= visible*2 {
+		return "****"
+	}
+	return "****" + token[len(token)-visible:]
+}
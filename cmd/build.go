@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	ignore "github.com/sabhiram/go-gitignore"
 	"github.com/spf13/cobra"
@@ -27,6 +28,17 @@ type gatherContextFunc func(w io.Writer) error
 // gatherContext zips up the user's code and environment and write it to a buffer to be
 // uploaded to the server.
 func gatherContext(rootDir string, w io.Writer, archiveType string) error {
+	gi := compileIgnore(rootDir)
+
+	if archiveType == "zip" {
+		return tools.Zip(rootDir, w, gi)
+	}
+	return tools.Tar(rootDir, w, gi)
+}
+
+// compileIgnore returns the ignore patterns for the project at rootDir. It uses the
+// project's .gitignore if there is one along with the default patterns.
+func compileIgnore(rootDir string) *ignore.GitIgnore {
 	giPath := filepath.Join(rootDir, ".gitignore")
 	lines := strings.Split(defaultGitIgnore, "\n")
 
@@ -39,13 +51,10 @@ func gatherContext(rootDir string, w io.Writer, archiveType string) error {
 		if err != nil {
 			ui.Errorf("Error compiling .gitignore file %s:", err)
 			ui.Errorf("Ignoring .gitignore file")
+			gi = ignore.CompileIgnoreLines(lines...)
 		}
 	}
-
-	if archiveType == "zip" {
-		return tools.Zip(rootDir, w, gi)
-	}
-	return tools.Tar(rootDir, w, gi)
+	return gi
 }
 
 func Build(cmd *cobra.Command, args []string) error {
@@ -69,19 +78,39 @@ func Build(cmd *cobra.Command, args []string) error {
 		os.Exit(1)
 	}
 
-	uwc := config.InitUnweaveClient()
-	buf := &bytes.Buffer{}
+	limit, err := config.ContextSizeLimit()
+	if err != nil {
+		ui.Errorf("Invalid max context size: %s", err)
+		os.Exit(1)
+	}
+	size, err := checkContextSize(dir, limit)
+	if err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	uwc := config.InitUnweaveClient()
 
-	if err := gatherContext(dir, buf, "zip"); err != nil {
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
 		return err
 	}
+
 	ui.Infof("Starting build for project '%s/%s'", owner, projectName)
 
+	// The context is zipped as it's uploaded instead of in memory.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(gatherContext(dir, pw, "zip"))
+	}()
+
+	var buildContext io.ReadCloser = pr
+	if !ui.OutputJSON {
+		buildContext = newUploadProgress(pr, size)
+	}
 	params := types.BuildsCreateParams{
 		Builder:      "docker",
-		BuildContext: io.NopCloser(buf),
+		BuildContext: buildContext,
 	}
 	buildID, err := uwc.Build.Create(cmd.Context(), owner, projectName, params)
 	if err != nil {
@@ -91,7 +120,71 @@ func Build(cmd *cobra.Command, args []string) error {
 			fmt.Println(uie.Verbose())
 			os.Exit(1)
 		}
+		ui.Errorf("Failed to upload the build context: %s", err)
+		os.Exit(1)
 	}
 	ui.Successf("Build %q is under way!", buildID)
 	return nil
 }
+
+// maxContextSizeBreakdown is how many of the largest files are listed when a build
+// context is too large.
+const maxContextSizeBreakdown = 10
+
+// checkContextSize returns the size of the files of the build context of dir, or an
+// error listing the largest ones if it's more than limit. A zero limit means there's no
+// limit.
+func checkContextSize(dir string, limit int64) (int64, error) {
+	files, err := tools.ArchiveFiles(dir, compileIgnore(dir))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the build context: %w", err)
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	if limit <= 0 || total <= limit {
+		return total, nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	if len(files) > maxContextSizeBreakdown {
+		files = files[:maxContextSizeBreakdown]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "The build context is %s, over the %s limit. Its largest files are:\n", formatBytes(total), formatBytes(limit))
+	for _, f := range files {
+		fmt.Fprintf(&b, "  %10s  %s\n", formatBytes(f.Size), f.Path)
+	}
+	b.WriteString("Add the files the build doesn't need to .gitignore, or raise the limit with " +
+		"--max-context-size or build.max_context_size in .unweave/config.toml")
+	return total, errors.New(b.String())
+}
+
+// uploadProgress keeps a line up to date with how much of a build context was uploaded.
+type uploadProgress struct {
+	io.ReadCloser
+	// total is the size of the files in the context. The archive is compressed so it's
+	// only an indication.
+	total    int64
+	done     int64
+	rendered time.Time
+}
+
+func newUploadProgress(r io.ReadCloser, total int64) *uploadProgress {
+	return &uploadProgress{ReadCloser: r, total: total}
+}
+
+func (p *uploadProgress) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	p.done += int64(n)
+	if err != nil || time.Since(p.rendered) > 100*time.Millisecond {
+		p.rendered = time.Now()
+		line := fmt.Sprintf("\r⬆️  Uploading build context: %s sent (%s of files)\033[K", formatBytes(p.done), formatBytes(p.total))
+		if err == io.EOF {
+			line += "\n"
+		}
+		fmt.Fprint(ui.Output, line)
+	}
+	return n, err
+}
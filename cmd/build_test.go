@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContextSize(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0600))
+	}
+	write("data/ignored.bin", 1<<20)
+	write("model/weights.bin", 4096)
+	for i := 0; i < 12; i++ {
+		write(fmt.Sprintf("src/file%02d.py", i), 100+i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("data/\n"), 0600))
+
+	// The ignored file isn't part of the context.
+	const total = 6 + 4096 + 12*100 + 66
+
+	t.Run("should return the size of the context", func(t *testing.T) {
+		size, err := checkContextSize(dir, 1<<20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(total), size)
+
+		size, err = checkContextSize(dir, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(total), size)
+	})
+
+	t.Run("should list the largest files if the context is too large", func(t *testing.T) {
+		_, err := checkContextSize(dir, 4096)
+		require.Error(t, err)
+
+		lines := strings.Split(err.Error(), "\n")
+		assert.Equal(t, "The build context is 5.2 KiB, over the 4.0 KiB limit. Its largest files are:", lines[0])
+		assert.Equal(t, "     4.0 KiB  "+filepath.Join("model", "weights.bin"), lines[1])
+		assert.Equal(t, "       111 B  "+filepath.Join("src", "file11.py"), lines[2])
+		assert.Len(t, lines, 1+maxContextSizeBreakdown+1)
+		assert.Contains(t, lines[len(lines)-1], "--max-context-size")
+	})
+}
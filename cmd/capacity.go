@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/notify"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// waitForCapacity waits until one of the placements of fb has capacity and creates the
+// session there. If the capacity is gone by the time the session is created, it waits
+// again, for at most config.MaxWait overall.
+func waitForCapacity(ctx context.Context, sc *session.Client, params types.ExecCreateParams, fb session.Fallback) (*types.Exec, session.Placement, error) {
+	placements := fb.Allowed()
+	waiter := session.CapacityWaiter{Provider: config.InitUnweaveClient().Provider}
+	var deadline time.Time
+	if config.MaxWait > 0 {
+		deadline = time.Now().Add(config.MaxWait)
+	}
+
+	ui.Infof("⏳ Waiting for capacity for %s", describePlacements(placements))
+	for {
+		if !deadline.IsZero() {
+			if waiter.MaxWait = time.Until(deadline); waiter.MaxWait <= 0 {
+				return nil, session.Placement{}, fmt.Errorf("%w after %s", session.ErrCapacityTimeout, config.MaxWait)
+			}
+		}
+
+		_, err := waiter.Until(ctx, placements, renderCapacityPoll)
+		clearStatusLine()
+		if err != nil {
+			return nil, session.Placement{}, err
+		}
+
+		exec, placement, err := sc.CreateWithFallback(ctx, params, session.Fallback{Placements: placements})
+		if !session.IsOutOfCapacity(err) {
+			return exec, placement, err
+		}
+		ui.Debugf("Capacity was gone before the session was created, waiting again")
+	}
+}
+
+// renderCapacityPoll keeps a single status line up to date while waiting for capacity.
+func renderCapacityPoll(p session.CapacityPoll) {
+	if ui.OutputJSON {
+		return
+	}
+	if p.Err != nil {
+		ui.Debugf("Failed to check for capacity: %s", p.Err)
+	}
+	checks := "once"
+	if p.Polls > 1 {
+		checks = fmt.Sprintf("%d times", p.Polls)
+	}
+	fmt.Fprintf(ui.Output, "\r⏳ No capacity yet, checked %s in %s\033[K", checks, formatAge(p.Elapsed))
+}
+
+func clearStatusLine() {
+	if !ui.OutputJSON {
+		fmt.Fprint(ui.Output, "\r\033[K")
+	}
+}
+
+func describePlacements(placements []session.Placement) string {
+	const shown = 3
+	names := make([]string, 0, shown)
+	for i, p := range placements {
+		if i == shown {
+			break
+		}
+		names = append(names, p.String())
+	}
+	s := strings.Join(names, ", ")
+	if len(placements) > shown {
+		s += fmt.Sprintf(" or %d more", len(placements)-shown)
+	}
+	return s
+}
+
+// validateNotifyTargets returns an error if any of the --notify targets is invalid, so
+// that mistakes show before waiting for capacity rather than after.
+func validateNotifyTargets() error {
+	for _, target := range config.Notify {
+		if err := notify.Validate(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifySessionCreated sends a notification to every --notify target that a session
+// was created after waiting for capacity.
+func notifySessionCreated(ctx context.Context, exec types.Exec, placement session.Placement) {
+	n := notify.Notification{
+		Title: "Unweave session created",
+		Text:  fmt.Sprintf("Session %s is starting with %s", exec.Name, placement),
+		Data: map[string]string{
+			"id":       exec.ID,
+			"name":     exec.Name,
+			"provider": string(placement.Provider),
+			"region":   placement.Region,
+			"gpu_type": placement.GPUType,
+		},
+	}
+	for _, target := range config.Notify {
+		if err := notify.Send(ctx, target, n); err != nil {
+			ui.Attentionf("Failed to send a notification to %s: %s", target, err)
+		}
+	}
+}
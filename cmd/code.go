@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
@@ -19,55 +19,66 @@ func Code(cmd *cobra.Command, args []string) error {
 	}
 
 	prvKey := config.SSHPrivateKeyPath
-	execCh, isNew, errCh := getOrCreateExec(cmd, execRef)
+	events, isNew, err := getOrCreateExec(cmd, execRef)
+	if err != nil {
+		handleWaitError(err)
+		return nil
+	}
 	ctx := cmd.Context()
 
-	for {
-		select {
-		case e := <-execCh:
-			if e.Status == types.StatusRunning {
-				prvKey, err := getDefaultKey(ctx, e, prvKey)
-				if prvKey == "" {
-					ui.Errorf("Expected private key to be none empty string")
-					os.Exit(1)
-				}
-				if err != nil {
-					ui.Errorf("Failed to get private key: %s", err)
-					os.Exit(1)
-				}
-
-				ensureHosts(e, prvKey)
+	for event := range events {
+		if event.Err != nil {
+			handleWaitError(event.Err)
+			return nil
+		}
+		renderWaitEvent(event)
 
-				if err := handleCopySourceDir(!config.NoCopySource, isNew, e, prvKey, ""); err != nil {
-					ui.HandleError(err)
-					os.Exit(1)
-				}
+		if e := event.Exec; e.Status == types.StatusRunning {
+			prvKey, err := getDefaultKey(ctx, e, prvKey)
+			if prvKey == "" {
+				ui.Errorf("Expected private key to be none empty string")
+				os.Exit(1)
+			}
+			if err != nil {
+				ui.Errorf("Failed to get private key: %s", err)
+				os.Exit(1)
+			}
 
-				ui.Infof("🔧 Setting up VS Code ...")
-				arg := fmt.Sprintf("vscode-remote://ssh-remote+%s@%s%s", e.Network.User, e.Network.Host, config.ProjectHostDir())
+			ensureHosts(e, prvKey)
 
-				codeCmd := exec.Command("code", "--folder-uri="+arg)
-				codeCmd.Stdout = os.Stdout
-				codeCmd.Stderr = os.Stderr
-				if e := codeCmd.Run(); e != nil {
-					ui.Errorf("Failed to start VS Code: %v", e)
-					os.Exit(1)
-				}
-				ui.Successf("✅ VS Code is ready!")
-				return nil
+			if err := handleCopySourceDir(ctx, !config.NoCopySource, isNew, e, prvKey, ""); err != nil {
+				ui.HandleError(err)
+				os.Exit(1)
+			}
+			if isNew {
+				setupWatchdog(ctx, e, prvKey)
 			}
+			setupEnv(ctx, e, prvKey, isNew)
 
-		case err := <-errCh:
-			var e *types.Error
-			if errors.As(err, &e) {
-				uie := &ui.Error{Error: e}
-				fmt.Println(uie.Verbose())
+			ui.Infof("🔧 Setting up VS Code ...")
+			arg := fmt.Sprintf("vscode-remote://ssh-remote+%s@%s%s", e.Network.User, e.Network.Host, config.ProjectHostDir())
+
+			codeCmd := exec.Command("code", "--folder-uri="+arg)
+			codeCmd.Stdout = os.Stdout
+			codeCmd.Stderr = os.Stderr
+			if e := codeCmd.Run(); e != nil {
+				ui.Errorf("Failed to start VS Code: %v", e)
 				os.Exit(1)
 			}
-			return err
+			ui.Successf("✅ VS Code is ready!")
+
+			if config.Config.Project.Sessions.Sync {
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+				defer stop()
 
-		case <-ctx.Done():
+				ui.Infof("🔄 Syncing to %q. Press Ctrl+C to stop.", config.ProjectHostDir())
+				if err := syncProject(ctx, e, prvKey, false, renderSyncEvent); err != nil {
+					ui.Errorf("Sync stopped: %s", err)
+					os.Exit(1)
+				}
+			}
 			return nil
 		}
 	}
+	return nil
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/ui"
+)
+
+// ConfigValidate handles the Cobra command for checking the project and user config
+func ConfigValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var files []*config.File
+	if f, err := config.OpenProjectConfig(); err == nil {
+		files = append(files, f)
+	}
+	f, err := config.OpenUserConfig()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	files = append(files, f)
+
+	issues := []config.Issue{}
+	for _, f := range files {
+		issues = append(issues, f.Validate()...)
+	}
+
+	if ui.OutputJSON {
+		ui.JSON(issues)
+	} else if len(issues) == 0 {
+		paths := make([]string, len(files))
+		for i, f := range files {
+			paths[i] = f.Path
+		}
+		ui.Successf("No issues found in %s", strings.Join(paths, " and "))
+	} else {
+		for _, issue := range issues {
+			ui.Errorf("%s", issue)
+		}
+	}
+
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// ConfigGet handles the Cobra command for printing a value of the config
+func ConfigGet(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	f := openConfigFile()
+	value, err := f.Get(args[0])
+	if err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
+
+	if ui.OutputJSON {
+		ui.JSON(map[string]string{"key": args[0], "value": value})
+		return nil
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// ConfigSet handles the Cobra command for setting a value of the config
+func ConfigSet(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	f := openConfigFile()
+	if err := f.Set(args[0], args[1]); err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
+	if err := f.Save(); err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	ui.Successf("Set %s in %s", args[0], f.Path)
+	return nil
+}
+
+// WarnConfigIssues points out the issues found in the config files when they were
+// loaded, without getting in the way of the command.
+func WarnConfigIssues(issues []config.Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	msg := issues[0].String()
+	if len(issues) > 1 {
+		msg += fmt.Sprintf(" and %d more", len(issues)-1)
+	}
+	ui.Attentionf("Invalid config: %s. Run `unweave config validate` for details.", msg)
+}
+
+func openConfigFile() *config.File {
+	open := config.OpenProjectConfig
+	if config.GlobalConfig {
+		open = config.OpenUserConfig
+	}
+	f, err := open()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	return f
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/ui"
+)
+
+// ContextList handles the Cobra command for listing contexts
+func ContextList(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	contexts, current, err := config.ListContexts()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	if ui.OutputJSON {
+		ui.JSON(map[string]any{"current": current, "active": config.ActiveContext.Name, "contexts": contexts})
+		return nil
+	}
+
+	cols := []ui.Column{
+		{Title: "", Width: 1},
+		{Title: "Name", Width: -1},
+		{Title: "API URL", Width: -1},
+		{Title: "Project", Width: -1},
+	}
+	rows := make([]ui.Row, len(contexts))
+	for idx, c := range contexts {
+		marker := ""
+		if c.Name == config.ActiveContext.Name {
+			marker = "*"
+		}
+		project := c.Project
+		if project == "" {
+			project = "-"
+		}
+		rows[idx] = ui.Row{marker, c.Name, c.ApiURL, project}
+	}
+	ui.Table("Contexts", cols, rows)
+
+	if config.ActiveContext.Name != current {
+		ui.Infof("The default context is %q, %q is selected for this command.", current, config.ActiveContext.Name)
+	}
+	return nil
+}
+
+// ContextUse handles the Cobra command for changing the default context
+func ContextUse(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	if err := config.UseContext(args[0]); err != nil {
+		handleContextError(err)
+	}
+	ui.Successf("Switched to context %q", args[0])
+	return nil
+}
+
+// ContextAdd handles the Cobra command for adding a context
+func ContextAdd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	appURL, _ := cmd.Flags().GetString("app-url")
+
+	// The global --token and --project flags set the token and default project
+	ctx := config.Context{
+		Name:    args[0],
+		ApiURL:  apiURL,
+		AppURL:  appURL,
+		Token:   config.AuthToken,
+		Project: config.ProjectURI,
+	}
+	if err := config.AddContext(ctx); err != nil {
+		handleContextError(err)
+	}
+
+	ui.Successf("Context %q added", ctx.Name)
+	ui.Infof("Run `unweave context use %s` to switch to it, or pass `--context %s` to a command.", ctx.Name, ctx.Name)
+	return nil
+}
+
+// ContextRemove handles the Cobra command for removing a context
+func ContextRemove(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	if err := config.RemoveContext(args[0]); err != nil {
+		handleContextError(err)
+	}
+	ui.Successf("Context %q removed", args[0])
+	return nil
+}
+
+func handleContextError(err error) {
+	ui.Errorf("%s", err)
+	if errors.Is(err, config.ErrContextNotFound) {
+		ui.Infof("Run `unweave context ls` to see the available contexts.")
+	}
+	os.Exit(1)
+}
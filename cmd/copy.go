@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,13 +10,13 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/ssh"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
 var (
 	getSessionIDRegex = regexp.MustCompile(`^sess:([^/]+)`)
-	regExValidDirpath = regexp.MustCompile(`^\/(?:[^\/]+\/)*[^\/]+$`)
 )
 
 func Copy(cmd *cobra.Command, args []string) error {
@@ -46,26 +47,78 @@ func Copy(cmd *cobra.Command, args []string) error {
 		ui.Infof("❌ Unsuccessful copy, failed to get private key")
 	}
 
-	switch {
-	case shouldCopyLocalDirToRemote(args[0]):
-		// Eventually simplify this to talk in terms of scpArgs, too many dependants for now
-		err = copyDirFromLocalAndUnzip(exec.ID, scpArgs[0], splitSessFromDirpath(args[1]), exec.Network, privateKey)
-	case shouldCopyRemoteDirToLocal(args[0]):
-		err = copyDirFromRemoteAndUnzip(scpArgs[0], scpArgs[1], privateKey)
-	default:
-		err = copySourceSCP(scpArgs[0], scpArgs[1], privateKey)
-	}
+	ctx := cmd.Context()
 
-	if err != nil {
-		fmt.Println(err.Error())
+	if err = copyPaths(ctx, exec.Network, args, scpArgs, privateKey); err != nil {
+		ui.HandleError(err)
 		ui.Infof("❌ Unsuccessful copy %s => %s", scpArgs[0], scpArgs[1])
-		return nil
+		ui.Infof("Run the same command again to resume the copy.")
+		os.Exit(1)
 	}
 
 	ui.Infof("✅  Copied %s => %s", scpArgs[0], scpArgs[1])
 	return nil
 }
 
+// copyPaths copies a file or directory between the local machine and the session. Files
+// are copied in chunks, verified with SHA-256 and resumed if an earlier copy was
+// interrupted. Local directories are copied without the paths their .gitignore ignores.
+func copyPaths(ctx context.Context, network types.ExecNetwork, args, scpArgs []string, privateKey string) error {
+	t, err := ssh.Dial(ctx, ssh.Options{Network: network, PrivateKeyPath: privateKey})
+	if err != nil {
+		return fmt.Errorf("failed to connect to session: %w", err)
+	}
+	defer t.Close()
+
+	opts := ssh.TransferOptions{}
+	if !ui.OutputJSON {
+		opts.OnProgress = newProgressRenderer()
+	}
+
+	if strings.HasPrefix(args[0], "sess:") {
+		return ssh.CopyFromRemote(ctx, t, splitSessFromDirpath(args[0]), scpArgs[1], opts)
+	}
+	if shouldCopyLocalDirToRemote(args[0]) {
+		opts.Ignore = compileIgnore(scpArgs[0])
+	}
+	return ssh.CopyToRemote(ctx, t, scpArgs[0], splitSessFromDirpath(args[1]), opts)
+}
+
+// newProgressRenderer returns a progress callback that keeps a single line per file up
+// to date.
+func newProgressRenderer() func(ssh.Progress) {
+	return func(p ssh.Progress) {
+		if p.Skipped {
+			fmt.Fprintf(ui.Output, "%s already copied\n", filepath.Base(p.Path))
+			return
+		}
+		line := fmt.Sprintf("\r%s %s / %s", filepath.Base(p.Path), formatBytes(p.Done), formatBytes(p.Total))
+		if p.Total > 0 {
+			line += fmt.Sprintf(" (%.0f%%)", float64(p.Done)/float64(p.Total)*100)
+		}
+		if p.Resumed > 0 {
+			line += fmt.Sprintf(", resumed at %s", formatBytes(p.Resumed))
+		}
+		if p.Done == p.Total {
+			line += "\n"
+		}
+		fmt.Fprint(ui.Output, line)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func shouldCopyLocalDirToRemote(from string) bool {
 	if strings.Contains(from, "sess:") {
 		return false
@@ -79,15 +132,6 @@ func shouldCopyLocalDirToRemote(from string) bool {
 	return pathInfo.IsDir()
 }
 
-func shouldCopyRemoteDirToLocal(from string) bool {
-	if !strings.Contains(from, "sess:") {
-		return false
-	}
-
-	sessFromDirpath := splitSessFromDirpath(from)
-	return regExValidDirpath.MatchString(sessFromDirpath)
-}
-
 func getTargetExec(cmd *cobra.Command, args []string) (*types.Exec, error) {
 	var targetExec *types.Exec
 	for _, arg := range args {
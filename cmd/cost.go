@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/cost"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// sessionCost is an active session along with what it costs.
+type sessionCost struct {
+	sessionInfo
+	Rate *cost.Rate `json:"rate,omitempty"`
+	// Spent is what the session has cost since it was created.
+	Spent float64 `json:"spent"`
+}
+
+type costReport struct {
+	Sessions []sessionCost `json:"sessions"`
+	// Hourly is what the active sessions cost per hour together.
+	Hourly float64 `json:"hourly"`
+	// Spent is what the active sessions have cost since they were created.
+	Spent float64 `json:"spent"`
+	// EndedSpent is what the sessions that ended this month cost this month.
+	EndedSpent float64 `json:"ended_spent"`
+	// MonthProjected is what the sessions of the project will have cost by the end of
+	// the month if the active ones run until their TTL or the end of the month.
+	MonthProjected float64 `json:"month_projected"`
+	MonthlyBudget  float64 `json:"monthly_budget,omitempty"`
+}
+
+// Cost handles the Cobra command for reporting what the active sessions cost
+func Cost(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	sessions, err := activeSessionCosts(ctx)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	ended, err := endedSessionCosts(sessions, now)
+	if err != nil {
+		ui.Attentionf("Sessions that ended this month are left out: %s", err)
+	}
+	report := costReport{Sessions: sessions, MonthlyBudget: projectBudget().Monthly}
+	report.EndedSpent, _ = cost.Forecast(ended, now)
+	month := ended
+	for _, s := range sessions {
+		report.Spent += s.Spent
+		if s.Rate != nil {
+			report.Hourly += s.Rate.Hourly
+			month = append(month, costSession(s.sessionInfo, *s.Rate))
+		}
+	}
+	_, report.MonthProjected = cost.Forecast(month, now)
+
+	if ui.OutputJSON {
+		ui.JSON(report)
+		return nil
+	}
+	if len(sessions) == 0 {
+		ui.Infof("No active sessions")
+		if report.EndedSpent > 0 {
+			ui.Infof("The sessions that ended this month cost %s", cost.FormatUSD(report.EndedSpent))
+		}
+		return nil
+	}
+
+	cols := []ui.Column{
+		{Title: "Name", Width: -1},
+		{Title: "Instance Type", Width: -1},
+		{Title: "Per Hour", Width: -1},
+		{Title: "Running", Width: -1},
+		{Title: "Spent", Width: -1},
+	}
+	rows := make([]ui.Row, len(sessions))
+	for idx, s := range sessions {
+		hourly := "-"
+		if s.Rate != nil {
+			hourly = cost.FormatUSD(s.Rate.Hourly)
+		}
+		rows[idx] = ui.Row{
+			s.Name,
+			sessionColumns["instance-type"].value(s.sessionInfo, now),
+			hourly,
+			formatAge(now.Sub(s.CreatedAt)),
+			cost.FormatUSD(s.Spent),
+		}
+	}
+	ui.Table("Active Sessions", cols, rows)
+
+	results := []ui.ResultEntry{
+		{Key: "Per Hour", Value: cost.FormatUSD(report.Hourly)},
+		{Key: "Spent", Value: cost.FormatUSD(report.Spent)},
+		{Key: "Ended This Month", Value: cost.FormatUSD(report.EndedSpent)},
+		{Key: "This Month", Value: cost.FormatUSD(report.MonthProjected) + " if the sessions keep running"},
+	}
+	if report.MonthlyBudget > 0 {
+		results = append(results, ui.ResultEntry{Key: "Monthly Budget", Value: cost.FormatUSD(report.MonthlyBudget)})
+	}
+	ui.ResultTitle("Total:")
+	ui.Result(results, ui.IndentWidth)
+	return nil
+}
+
+// sessionBudget checks new sessions against the budget of the project.
+type sessionBudget struct {
+	budget cost.Budget
+	// month are the other sessions of the project this month, running or ended.
+	month []cost.Session
+	ttl   time.Duration
+	// confirmed is the hourly rate the user agreed to go over the budget with, if any.
+	confirmed float64
+}
+
+// check returns an error wrapping cost.ErrOverBudget if a session that costs hourly would
+// go over the budget, unless the user already agreed to a session that costs as much.
+func (b sessionBudget) check(hourly float64, now time.Time) error {
+	if b.confirmed > 0 && hourly <= b.confirmed {
+		return nil
+	}
+	return b.budget.Check(cost.Session{CreatedAt: now, Hourly: hourly, TTL: b.ttl}, b.month, now)
+}
+
+// checkSessionCost shows what a new session will cost per hour and checks it against
+// the budget of the project. It returns an error if the session shouldn't be created,
+// and otherwise the budget to check the placements the session falls back to against.
+func checkSessionCost(ctx context.Context, provider types.Provider, spec types.HardwareSpec, lifetime session.Lifetime) (sessionBudget, error) {
+	b := sessionBudget{budget: projectBudget(), ttl: lifetime.TTL}
+	now := time.Now()
+	if !b.budget.IsZero() {
+		var err error
+		if b.month, err = monthSessionCosts(ctx, now); err != nil {
+			return b, fmt.Errorf("failed to check the budget: %w", err)
+		}
+	}
+
+	nodeTypes, err := config.InitUnweaveClient().Provider.ListNodeTypes(ctx, provider, false)
+	if err != nil {
+		ui.Debugf("Failed to get the prices of %s: %s", provider, err)
+	}
+	rate, err := cost.Estimate(spec, nodeTypes)
+	if err != nil {
+		if b.budget.IsZero() {
+			ui.Debugf("Can't estimate the cost of the session: %s", err)
+		} else {
+			ui.Attentionf("Can't check the session against the budget: %s", err)
+		}
+		return b, nil
+	}
+	ui.Infof("💰 Estimated cost: %s/hour (%s)", cost.FormatUSD(rate.Hourly), rate.NodeType)
+
+	err = b.check(rate.Hourly, now)
+	if err == nil {
+		return b, nil
+	}
+	if strings.EqualFold(config.Config.Project.Budget.OnExceed, "block") {
+		return b, err
+	}
+	ui.Attentionf("%s", err)
+	if !ui.Confirm("Create the session anyway", "n") {
+		return b, errors.New("session not created")
+	}
+	b.confirmed = rate.Hourly
+	return b, nil
+}
+
+// monthSessionCosts returns the sessions of the project this month, running or ended.
+func monthSessionCosts(ctx context.Context, now time.Time) ([]cost.Session, error) {
+	running, err := activeSessionCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := endedSessionCosts(running, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range running {
+		if s.Rate != nil {
+			sessions = append(sessions, costSession(s.sessionInfo, *s.Rate))
+		}
+	}
+	return sessions, nil
+}
+
+// activeSessionCosts returns the active sessions of the project with their rates. The
+// rate of a session is left out if its provider has no price for it.
+func activeSessionCosts(ctx context.Context) ([]sessionCost, error) {
+	sc, err := session.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := listSessions(ctx, sc, sessionListOptions{sortBy: sessionColumns["name"]})
+	if err != nil {
+		return nil, err
+	}
+
+	uwc := config.InitUnweaveClient()
+	prices := map[types.Provider][]types.NodeType{}
+	now := time.Now()
+
+	costs := make([]sessionCost, len(sessions))
+	for i, s := range sessions {
+		costs[i] = sessionCost{sessionInfo: s}
+
+		nodeTypes, ok := prices[s.Provider]
+		if !ok {
+			if nodeTypes, err = uwc.Provider.ListNodeTypes(ctx, s.Provider, false); err != nil {
+				ui.Debugf("Failed to get the prices of %s: %s", s.Provider, err)
+			}
+			prices[s.Provider] = nodeTypes
+		}
+		rate, err := cost.Estimate(s.Spec, nodeTypes)
+		if err != nil {
+			ui.Debugf("Can't estimate the cost of %s: %s", s.Name, err)
+			continue
+		}
+		costs[i].Rate = &rate
+		costs[i].Spent = costSession(s, rate).Spent(now)
+	}
+	return costs, nil
+}
+
+// endedSessionCosts records that the active sessions run, and returns the sessions of the
+// project that ended this month. The API doesn't return when sessions ended, so they're
+// the ones this machine saw running, until it last saw them or terminated them.
+func endedSessionCosts(active []sessionCost, now time.Time) ([]cost.Session, error) {
+	store, err := sessionUsageStore()
+	if err != nil {
+		return nil, err
+	}
+	running := map[string]session.Usage{}
+	for _, s := range active {
+		if s.Rate != nil {
+			running[s.ID] = session.Usage{CreatedAt: s.CreatedAt, Hourly: s.Rate.Hourly}
+		}
+	}
+	usage, err := store.Observe(config.Config.Project.URI, running, now)
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ := cost.Month(now)
+	var ended []cost.Session
+	for _, u := range usage {
+		if u.Ended() && u.EndedAt.After(start) {
+			ended = append(ended, cost.Session{CreatedAt: u.CreatedAt, Hourly: u.Hourly, EndedAt: u.EndedAt})
+		}
+	}
+	return ended, nil
+}
+
+// recordSessionStart records what a new session costs, so that it counts toward the
+// monthly budget even if it's terminated before the CLI sees it running again.
+func recordSessionStart(ctx context.Context, e types.Exec, provider types.Provider, spec types.HardwareSpec) {
+	nodeTypes, err := config.InitUnweaveClient().Provider.ListNodeTypes(ctx, provider, false)
+	if err != nil {
+		ui.Debugf("Failed to get the prices of %s: %s", provider, err)
+	}
+	rate, err := cost.Estimate(spec, nodeTypes)
+	if err != nil {
+		ui.Debugf("Can't record the cost of the session: %s", err)
+		return
+	}
+	store, err := sessionUsageStore()
+	if err != nil {
+		ui.Debugf("Failed to record the cost of the session: %s", err)
+		return
+	}
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	u := session.Usage{Project: config.Config.Project.URI, CreatedAt: createdAt, Hourly: rate.Hourly, LastSeen: createdAt}
+	if err = store.Start(e.ID, u); err != nil {
+		ui.Debugf("Failed to record the cost of the session: %s", err)
+	}
+}
+
+// recordSessionsEnded records that sessions were terminated, so that they stop counting
+// toward the monthly budget.
+func recordSessionsEnded(sessionIDs ...string) {
+	store, err := sessionUsageStore()
+	if err == nil {
+		err = store.End(sessionIDs, time.Now())
+	}
+	if err != nil {
+		ui.Debugf("Failed to record that the sessions ended: %s", err)
+	}
+}
+
+func costSession(s sessionInfo, rate cost.Rate) cost.Session {
+	cs := cost.Session{CreatedAt: s.CreatedAt, Hourly: rate.Hourly}
+	if s.Lifetime != nil {
+		cs.TTL = s.Lifetime.TTL
+	}
+	return cs
+}
+
+func projectBudget() cost.Budget {
+	b := config.Config.Project.Budget
+	return cost.Budget{Monthly: b.Monthly, MaxHourlyPrice: b.MaxHourlyPrice}
+}
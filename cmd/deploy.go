@@ -10,6 +10,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/jobs"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ssh"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
@@ -17,12 +20,27 @@ import (
 func Deploy(cmd *cobra.Command, args []string) error {
 	name := strings.ReplaceAll(config.EndpointName, "_", "-")
 
-	return runSSHConnectionCommand(cmd, args, &deployCommandFlow{endpointName: name})
+	flow := &deployCommandFlow{endpointName: name}
+	if err := runSSHConnectionCommand(cmd, args, flow); err != nil || flow.endpoint.ID == "" {
+		return err
+	}
+
+	ui.JSON(map[string]any{
+		"endpoint": flow.endpoint,
+		"version":  flow.version,
+	})
+
+	return nil
 }
 
 type deployCommandFlow struct {
 	endpointName string
 	execCommandFlow
+
+	// endpoint and version are the endpoint deployed to and the version created once the
+	// command is running.
+	endpoint types.EndpointListItem
+	version  types.EndpointVersion
 }
 
 func (d *deployCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmdArgs {
@@ -77,29 +95,60 @@ func (d *deployCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmd
 
 	execArgs.userCommand = strings.Split(config.Command, " ")
 
-	execArgs.execCommand = execArgs.userCommand
-	execArgs.execCommand = wrapCommandNoHupLogging(execArgs.userCommand)
+	job := jobs.New(config.Config.Project.URI, execArgs.userCommand)
+	d.job = &job
+	// Like exec, the command loads the environment variables of the session first
+	execArgs.execCommand = []string{session.LoadEnvCommand + ";", job.StartCommand()}
 
 	return execArgs
 }
 
-func (d *deployCommandFlow) onSshCommandFinish(ctx context.Context, execID string) error {
+// getExec creates the session the endpoint runs on. Endpoints serve requests without
+// anyone logged in or any GPU load, which the watchdog takes for idleness, so their
+// sessions get no lifetime: they run until the endpoint is taken down.
+func (d *deployCommandFlow) getExec(cmd *cobra.Command, execCmd execCmdArgs) (<-chan session.Event, bool, error) {
+	ui.Infof("Initializing session...")
+
+	return execCreateAndWatch(cmd.Context(), types.ExecConfig{Command: execCmd.userCommand}, types.GitConfig{}, session.Lifetime{}), true, nil
+}
+
+func (d *deployCommandFlow) onSshCommandFinish(ctx context.Context, e types.Exec, prvKey string) error {
+	if d.job != nil {
+		if t, err := ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey}); err != nil {
+			ui.Attentionf("Failed to save job %s: %s", d.job.ID, err)
+		} else {
+			saveDeployJob(ctx, t, *d.job, e)
+			t.Close()
+		}
+	}
+
+	var err error
+	d.endpoint, d.version, err = createEndpointVersion(ctx, d.endpointName, e)
+	return err
+}
+
+// createEndpointVersion points a new version of the endpoint called name at session e,
+// creating the endpoint if it doesn't exist.
+func createEndpointVersion(ctx context.Context, name string, e types.Exec) (types.EndpointListItem, types.EndpointVersion, error) {
 	uwc := config.InitUnweaveClient()
-	owner, project := config.GetProjectOwnerAndName()
+	owner, project, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return types.EndpointListItem{}, types.EndpointVersion{}, err
+	}
 
 	endpoints, err := uwc.Endpoints.List(ctx, owner, project)
 	if err != nil {
-		return fmt.Errorf("list endpoints: %w", err)
+		return types.EndpointListItem{}, types.EndpointVersion{}, fmt.Errorf("list endpoints: %w", err)
 	}
 
-	end, ok := findEndpoint(d.endpointName, endpoints)
+	end, ok := findEndpoint(name, endpoints)
 
 	if !ok {
-		ui.Debugf("endpoint not found, creating new, name: %q", d.endpointName)
+		ui.Debugf("endpoint not found, creating new, name: %q", name)
 
-		endpoint, err := uwc.Endpoints.Create(ctx, owner, project, execID, d.endpointName)
+		endpoint, err := uwc.Endpoints.Create(ctx, owner, project, e.ID, name)
 		if err != nil {
-			return fmt.Errorf("deploy: %w", err)
+			return types.EndpointListItem{}, types.EndpointVersion{}, fmt.Errorf("deploy: %w", err)
 		}
 
 		ui.Infof("✅ Endpoint created: %q", endpoint.ID)
@@ -108,22 +157,118 @@ func (d *deployCommandFlow) onSshCommandFinish(ctx context.Context, execID strin
 		end.ID = endpoint.ID
 	}
 
-	ui.Debugf("creating version, name: %q, id: %q", d.endpointName, end.ID)
+	ui.Debugf("creating version, name: %q, id: %q", name, end.ID)
 
-	version, err := uwc.Endpoints.CreateVersion(ctx, owner, project, end.ID, execID)
+	version, err := uwc.Endpoints.CreateVersion(ctx, owner, project, end.ID, e.ID)
 	if err != nil {
-		return fmt.Errorf("create version: %w", err)
+		return end, types.EndpointVersion{}, fmt.Errorf("create version: %w", err)
 	}
 
 	ui.Infof("✅ Version created %q", version.ID)
 	ui.Infof("https://%s", version.HTTPAddress)
 
-	ui.JSON(map[string]any{
-		"endpoint": end,
-		"version":  version,
+	return end, version, nil
+}
+
+// saveDeployJob saves the job of a deployed command in the registry. Failing to is only
+// reported, since the endpoint works regardless.
+func saveDeployJob(ctx context.Context, t ssh.Transport, job jobs.Job, e types.Exec) {
+	registry, err := jobRegistry()
+	if err != nil {
+		ui.Attentionf("Failed to save job %s: %s", job.ID, err)
+		return
+	}
+	registerJob(ctx, t, registry, job, e)
+}
+
+// endpointDeployment is an endpoint for deployEndpoint to deploy.
+type endpointDeployment struct {
+	name    string
+	command string
+	spec    string
+	port    int32
+}
+
+// deployEndpoint deploys an endpoint the way unweave deploy does, by running its command
+// as a job on a new session of the default provider and pointing a new version of the
+// endpoint at it. Unlike unweave deploy it doesn't read the command line flags, and
+// returns errors instead of exiting, so that it can deploy many endpoints in a row.
+func deployEndpoint(ctx context.Context, d endpointDeployment) (types.EndpointListItem, types.EndpointVersion, error) {
+	var (
+		end     types.EndpointListItem
+		version types.EndpointVersion
+	)
+
+	provider := types.Provider(config.Config.Project.DefaultProvider)
+	if provider == "" {
+		return end, version, errors.New("no default provider set in the project config")
+	}
+	specConfig, err := resolveSpec(d.spec)
+	if err != nil {
+		return end, version, err
+	}
+	spec, err := specConfig.HardwareSpec()
+	if err != nil {
+		return end, version, err
+	}
+	if _, err = checkSessionCost(ctx, provider, spec, session.Lifetime{}); err != nil {
+		return end, version, err
+	}
+	keyName, pub, err := setupSSHKey(ctx)
+	if err != nil {
+		return end, version, err
+	}
+
+	sc, err := session.FromConfig()
+	if err != nil {
+		return end, version, err
+	}
+	command := strings.Split(d.command, " ")
+	e, err := sc.Create(ctx, types.ExecCreateParams{
+		Provider:     provider,
+		Spec:         spec,
+		SSHKeyName:   keyName,
+		SSHPublicKey: string(pub),
+		Command:      command,
+		InternalPort: d.port,
 	})
+	if err != nil {
+		return end, version, fmt.Errorf("failed to create session: %w", err)
+	}
+	ui.Infof("Session %q created for endpoint %s", e.ID, d.name)
+	recordSessionStart(ctx, *e, provider, spec)
 
-	return nil
+	// The session keeps running if anything fails from here on, so that it can be looked at
+	fail := func(err error) (types.EndpointListItem, types.EndpointVersion, error) {
+		return end, version, fmt.Errorf("%w (session %s is still running, `unweave terminate %s` stops it)", err, e.ID, e.ID)
+	}
+
+	waiter := sc.Waiter()
+	waiter.Timeout = config.WaitTimeout
+	running, err := waiter.Until(ctx, e.ID, renderWaitEvent)
+	if err != nil {
+		return end, version, fmt.Errorf("session %s didn't start: %w", e.ID, err)
+	}
+	prvKey, err := getDefaultKey(ctx, *running, config.SSHPrivateKeyPath)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get private key: %w", err))
+	}
+	t, err := ssh.Dial(ctx, ssh.Options{Network: running.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		return fail(fmt.Errorf("failed to connect to session: %w", err))
+	}
+	defer t.Close()
+
+	job := jobs.New(config.Config.Project.URI, command)
+	if _, err = t.Run(ctx, job.StartCommand()); err != nil {
+		return fail(fmt.Errorf("failed to start the command: %w", err))
+	}
+	saveDeployJob(ctx, t, job, *running)
+
+	if end, version, err = createEndpointVersion(ctx, d.name, *running); err != nil {
+		return fail(err)
+	}
+	return end, version, nil
 }
 
 func findEndpoint(name string, ends []types.EndpointListItem) (types.EndpointListItem, bool) {
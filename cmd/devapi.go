@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/devapi"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// DevAPI serves the in-memory fake of the Unweave API until the process is interrupted.
+func DevAPI(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	network := types.ExecNetwork{User: "root"}
+	if config.DevAPISSHAddr != "" {
+		host, port, err := net.SplitHostPort(config.DevAPISSHAddr)
+		if err != nil {
+			return err
+		}
+		network.Host = host
+		if network.Port, err = strconv.Atoi(port); err != nil {
+			return err
+		}
+	}
+
+	srv := devapi.NewServer(devapi.Config{SessionNetwork: network})
+
+	ui.Infof("Serving the fake Unweave API on http://%s", config.DevAPIAddr)
+	ui.Infof("Run the CLI against it with UNWEAVE_ENV=dev")
+
+	return http.ListenAndServe(config.DevAPIAddr, srv)
+}
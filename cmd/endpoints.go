@@ -12,7 +12,10 @@ func EndpointCreate(cmd *cobra.Command, args []string) error {
 	execID := args[0]
 	ctx := cmd.Context()
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
@@ -35,7 +38,10 @@ func EndpointCreate(cmd *cobra.Command, args []string) error {
 func EndpointList(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
@@ -62,11 +68,14 @@ func EndpointEvalAttach(cmd *cobra.Command, args []string) error {
 	endpointID := args[0]
 	evalID := args[1]
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
-	err := uwc.Endpoints.EvalAttach(ctx, owner, projectName, endpointID, evalID)
+	err = uwc.Endpoints.EvalAttach(ctx, owner, projectName, endpointID, evalID)
 	if err != nil {
 		return err
 	}
@@ -78,15 +87,21 @@ func EndpointEvalCheck(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	endpointID := args[0]
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
-	err := uwc.Endpoints.RunEvalCheck(ctx, owner, projectName, endpointID)
+	check, err := uwc.Endpoints.RunEvalCheck(ctx, owner, projectName, endpointID)
 	if err != nil {
 		return err
 	}
 
+	ui.JSON(check)
+	ui.Infof("check id: %s", check.CheckID)
+
 	return nil
 }
 
@@ -94,7 +109,10 @@ func EndpointCheckStatus(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	checkID := args[0]
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
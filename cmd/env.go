@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// resolveSessionEnv returns the environment variables to inject into a session. The
+// [env] table of the config is overridden by the --env-file flags in order, and those by
+// the --env flags.
+func resolveSessionEnv() (session.Env, error) {
+	env := session.Env{}
+	for key, value := range config.Config.Project.SessionEnv {
+		expanded, err := config.ExpandEnvValue(value, os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("[env] %s: %w", key, err)
+		}
+		env[key] = expanded
+	}
+
+	for _, path := range config.EnvFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		vars, err := config.ParseDotEnv(f, os.LookupEnv)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for key, value := range vars {
+			env[key] = value
+		}
+	}
+
+	for _, v := range config.EnvVars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			if value, ok = os.LookupEnv(key); !ok {
+				return nil, fmt.Errorf("--env %s: %s isn't set in your environment, use --env %s=<value>", key, key, key)
+			}
+		}
+		env[key] = value
+	}
+	return env, env.Validate()
+}
+
+// setupEnv injects the environment variables into a new session, or into an existing
+// one if they're set with flags.
+func setupEnv(ctx context.Context, e types.Exec, prvKey string, isNew bool) {
+	if !isNew && len(config.EnvVars) == 0 && len(config.EnvFiles) == 0 {
+		return
+	}
+	env, err := resolveSessionEnv()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	if len(env) == 0 {
+		return
+	}
+
+	t, err := ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		ui.HandleError(fmt.Errorf("failed to connect to session: %w", err))
+		os.Exit(1)
+	}
+	defer t.Close()
+
+	if err = env.Install(ctx, t); err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	ui.Infof("🔑 Set %s on the session", strings.Join(env.Names(), ", "))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/session"
+)
+
+func TestResolveSessionEnv(t *testing.T) {
+	t.Setenv("LOCAL_TOKEN", "from-local")
+	envFile := filepath.Join(t.TempDir(), "secrets.env")
+	require.NoError(t, os.WriteFile(envFile, []byte("export FILE=1\nOVERRIDDEN=file\nURL=\"https://example.com/?a=b\"\n"), 0600))
+
+	config.Config.Project = &config.Project{SessionEnv: map[string]string{
+		"WANDB_PROJECT": "sweeps",
+		"HF_TOKEN":      "${LOCAL_TOKEN}",
+		"OVERRIDDEN":    "config",
+		"DB_PASSWORD":   "pa$word$$1",
+	}}
+	t.Cleanup(func() { config.EnvVars, config.EnvFiles = nil, nil })
+
+	t.Run("should merge the config, env files and flags", func(t *testing.T) {
+		config.EnvFiles = []string{envFile}
+		config.EnvVars = []string{"FLAG=a=b", "LOCAL_TOKEN"}
+		env, err := resolveSessionEnv()
+		require.NoError(t, err)
+		assert.Equal(t, session.Env{
+			"WANDB_PROJECT": "sweeps",
+			"HF_TOKEN":      "from-local",
+			"OVERRIDDEN":    "file",
+			"DB_PASSWORD":   "pa$word$1",
+			"FILE":          "1",
+			"URL":           "https://example.com/?a=b",
+			"FLAG":          "a=b",
+			"LOCAL_TOKEN":   "from-local",
+		}, env)
+	})
+
+	t.Run("should fail for variables missing from the local environment", func(t *testing.T) {
+		config.EnvFiles, config.EnvVars = nil, []string{"UW_TEST_MISSING"}
+		_, err := resolveSessionEnv()
+		assert.EqualError(t, err, "--env UW_TEST_MISSING: UW_TEST_MISSING isn't set in your environment, use --env UW_TEST_MISSING=<value>")
+	})
+
+	t.Run("should fail for invalid names", func(t *testing.T) {
+		config.EnvFiles, config.EnvVars = nil, []string{"MY-VAR=1"}
+		_, err := resolveSessionEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail for missing env files", func(t *testing.T) {
+		config.EnvFiles, config.EnvVars = []string{filepath.Join(t.TempDir(), "missing.env")}, nil
+		_, err := resolveSessionEnv()
+		assert.Error(t, err)
+	})
+}
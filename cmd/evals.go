@@ -10,7 +10,10 @@ func EvalCreate(cmd *cobra.Command, args []string) error {
 	execID := args[0]
 	ctx := cmd.Context()
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
@@ -30,7 +33,10 @@ func EvalCreate(cmd *cobra.Command, args []string) error {
 func EvalList(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	uwc := config.InitUnweaveClient()
 
@@ -4,19 +4,34 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/jobs"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ssh"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
 func Exec(cmd *cobra.Command, args []string) error {
-	return runSSHConnectionCommand(cmd, args, &execCommandFlow{})
+	flow := &execCommandFlow{}
+	if err := runSSHConnectionCommand(cmd, args, flow); err != nil {
+		return err
+	}
+	// The CLI only exits with the exit code of the job once the connection is cleaned up
+	if flow.exitCode != 0 {
+		os.Exit(flow.exitCode)
+	}
+	return nil
 }
 
-type execCommandFlow struct{}
+type execCommandFlow struct {
+	// job is the job the command runs as if it runs detached.
+	job *jobs.Job
+	// exitCode is the exit code of the job if the CLI waited for it.
+	exitCode int
+}
 
 func (e *execCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmdArgs {
 	execArgs := execCmdArgs{
@@ -46,6 +61,14 @@ func (e *execCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmdAr
 		os.Exit(1)
 	}
 
+	if config.ExecWait && config.ExecAttach {
+		const errMsg = "❌ Invalid arguments. --wait can't be used with --interactive, " +
+			"interactive commands always run until they finish. " +
+			"See `unweave exec --help` for more information"
+		ui.Errorf(errMsg)
+		os.Exit(1)
+	}
+
 	if len(argsBeforeDoubleDash) > 0 {
 		const errMsg = "❌ Invalid arguments. You may not pass argment before the -- flag. " +
 			"See `unweave exec --help` for more information"
@@ -56,38 +79,57 @@ func (e *execCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmdAr
 	execArgs.execCommand = execArgs.userCommand
 
 	if !config.ExecAttach {
-		execArgs.execCommand = wrapCommandNoHupLogging(execArgs.userCommand)
+		job := jobs.New(config.Config.Project.URI, execArgs.userCommand)
+		e.job = &job
+		execArgs.execCommand = []string{job.StartCommand()}
 	}
+	// Commands don't run in a login shell, so they load the environment variables of the
+	// session themselves
+	execArgs.execCommand = append([]string{session.LoadEnvCommand + ";"}, execArgs.execCommand...)
 
 	return execArgs
 }
 
-func (e *execCommandFlow) getExec(cmd *cobra.Command, execCmd execCmdArgs) (chan types.Exec, bool, chan error) {
-	ctx := cmd.Context()
-
-	sendError := func(err error) chan error {
-		errCh := make(chan error, 1)
-		errCh <- err
-		return errCh
-	}
-
+func (e *execCommandFlow) getExec(cmd *cobra.Command, execCmd execCmdArgs) (<-chan session.Event, bool, error) {
 	ui.Infof("Initializing session...")
 
-	execCh, errCh, err := execCreateAndWatch(ctx, types.ExecConfig{Command: execCmd.userCommand}, types.GitConfig{})
-	if err != nil {
-		return nil, false, sendError(err)
-	}
-
 	const alwaysNewExec = true
 
-	return execCh, alwaysNewExec, errCh
+	return execCreateAndWatch(cmd.Context(), types.ExecConfig{Command: execCmd.userCommand}, types.GitConfig{}, mustResolveLifetime()), alwaysNewExec, nil
 }
 
-func (e *execCommandFlow) onSshCommandFinish(ctx context.Context, execID string) error {
-	ui.Infof("Session %q exited. Use 'unweave terminate' to stop the session.", execID)
+func (e *execCommandFlow) onSshCommandFinish(ctx context.Context, exec types.Exec, prvKey string) error {
+	if e.job == nil {
+		ui.Infof("Session %q exited. Use 'unweave terminate' to stop the session.", exec.ID)
+		ui.JSON(map[string]any{"id": exec.ID})
+		return nil
+	}
 
-	ui.JSON(map[string]any{"id": execID})
+	t, err := ssh.Dial(ctx, ssh.Options{Network: exec.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	defer t.Close()
+
+	registry, err := jobRegistry()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	job := registerJob(ctx, t, registry, *e.job, exec)
+	ui.Infof("🏃 Job %s started on session %q", job.ID, exec.ID)
+	ui.JSON(map[string]any{"id": exec.ID, "job": job})
+
+	if !config.ExecWait {
+		ui.Infof("Use 'unweave jobs status %s' to check on it and 'unweave terminate' to stop the session.", job.ID)
+		return nil
+	}
 
+	redial := func(ctx context.Context) (ssh.Transport, error) {
+		return ssh.Dial(ctx, ssh.Options{Network: exec.Network, PrivateKeyPath: prvKey})
+	}
+	e.exitCode = waitForJob(ctx, t, redial, registry, job)
 	return nil
 }
 
@@ -95,7 +137,10 @@ func (e *execCommandFlow) onSshCommandFinish(ctx context.Context, execID string)
 func getExecs(ctx context.Context) ([]types.Exec, error) {
 	uwc := config.InitUnweaveClient()
 	listTerminated := config.All
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return nil, err
+	}
 	return uwc.Exec.List(ctx, owner, projectName, listTerminated)
 }
 
@@ -118,12 +163,3 @@ func getExecByNameOrID(ctx context.Context, ref string) (*types.Exec, error) {
 
 	return nil, fmt.Errorf("session %s does not exist", ref)
 }
-
-func wrapCommandNoHupLogging(userCommand []string) []string {
-	escaped := strings.ReplaceAll(strings.Join(userCommand, " "), "\"", "\\\"")
-
-	out := []string{"nohup", "bash", "-c", "\"", escaped, "\""}
-	out = append(out, ">", execLogFile, "2>&1", "&", "echo", "$!", ">", "./pid.nohup", "&&", "sleep", "1")
-
-	return out
-}
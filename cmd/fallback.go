@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/cost"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// sessionFallback returns where a new session may be created: first, followed by the
+// fallbacks of the spec if the provider is out of capacity. Fallbacks that the provider
+// doesn't offer, or that cost more than the price ceiling or would go over the budget,
+// are skipped. Only first was checked against the budget up front.
+func sessionFallback(ctx context.Context, first session.Placement, spec types.HardwareSpec, budget sessionBudget) session.Fallback {
+	specConfig, _ := selectedSpec()
+	fb := specConfig.Fallback
+	if fb == nil {
+		return session.Fallback{Placements: []session.Placement{first}}
+	}
+
+	providers := make([]types.Provider, len(fb.Providers))
+	for i, p := range fb.Providers {
+		providers[i] = types.Provider(p)
+	}
+	ceiling := fb.MaxHourlyPrice
+	if ceiling <= 0 {
+		ceiling = config.Config.Project.Budget.MaxHourlyPrice
+	}
+
+	uwc := config.InitUnweaveClient()
+	offers := map[types.Provider][]types.NodeType{}
+
+	return session.Fallback{
+		Placements: session.Placements(first, providers, fb.GPUTypes, fb.Regions),
+		Check: func(p session.Placement) error {
+			nodeTypes, ok := offers[p.Provider]
+			if !ok {
+				var err error
+				if nodeTypes, err = uwc.Provider.ListNodeTypes(ctx, p.Provider, false); err != nil {
+					ui.Debugf("Failed to get the node types of %s: %s", p.Provider, err)
+				}
+				offers[p.Provider] = nodeTypes
+			}
+			return checkPlacement(p, spec, nodeTypes, ceiling, budget)
+		},
+		OnFailure: func(p session.Placement, err error) {
+			if session.IsOutOfCapacity(err) {
+				ui.Attentionf("No capacity for %s", p)
+				return
+			}
+			ui.Infof("Skipping %s: %s", p, err)
+		},
+	}
+}
+
+// checkPlacement returns an error if none of nodeTypes fits a session with spec at p, or
+// if the session would cost more than ceiling per hour or go over budget. Nothing is
+// checked against nodeTypes if it's empty, since the provider's node types may not be
+// known.
+func checkPlacement(p session.Placement, spec types.HardwareSpec, nodeTypes []types.NodeType, ceiling float64, budget sessionBudget) error {
+	spec.GPU.Type = p.GPUType
+	instanceType := spec.GPU.Type
+	if instanceType == "" {
+		instanceType = spec.CPU.Type
+	}
+
+	if len(nodeTypes) > 0 {
+		var offered []types.NodeType
+		for _, nt := range nodeTypes {
+			if nt.ID != instanceType && nt.Specs.GPU.Type != instanceType {
+				continue
+			}
+			if p.Region != "" && len(nt.Regions) > 0 && !hasRegion(nt, p.Region) {
+				continue
+			}
+			offered = append(offered, nt)
+		}
+		if len(offered) == 0 {
+			return errors.New("not offered by the provider")
+		}
+		nodeTypes = offered
+	}
+
+	if ceiling <= 0 && budget.budget.IsZero() {
+		return nil
+	}
+	rate, err := cost.Estimate(spec, nodeTypes)
+	if err != nil {
+		if ceiling > 0 {
+			return fmt.Errorf("can't check against the price ceiling of %s/hour: %w", cost.FormatUSD(ceiling), err)
+		}
+		return fmt.Errorf("can't check against the budget: %w", err)
+	}
+	if ceiling > 0 && rate.Hourly > ceiling {
+		return fmt.Errorf("costs %s/hour, more than the price ceiling of %s/hour",
+			cost.FormatUSD(rate.Hourly), cost.FormatUSD(ceiling))
+	}
+	return budget.check(rate.Hourly, time.Now())
+}
+
+func hasRegion(nt types.NodeType, region string) bool {
+	for _, r := range nt.Regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
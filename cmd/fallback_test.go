@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unweave/cli/cost"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestCheckPlacement(t *testing.T) {
+	price := func(cents int) *int { return &cents }
+	nodeTypes := []types.NodeType{
+		{ID: "rtx_4000", Price: price(56), Regions: []string{"us_east_1", "us_west_2"}},
+		{ID: "a100", Price: price(220), Regions: []string{"us_west_2"}},
+		{ID: "h100", Regions: []string{"us_west_2"}},
+	}
+	spec := types.HardwareSpec{GPU: types.GPU{Type: "rtx_4000"}, CPU: types.CPU{Type: "x86_64"}}
+
+	cases := []struct {
+		name      string
+		placement session.Placement
+		nodeTypes []types.NodeType
+		ceiling   float64
+		budget    sessionBudget
+		wantErr   string
+	}{
+		{
+			name:      "offered without a ceiling",
+			placement: session.Placement{Provider: "unweave", GPUType: "h100"},
+			nodeTypes: nodeTypes,
+		},
+		{
+			name:      "offered in the region",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100", Region: "us_west_2"},
+			nodeTypes: nodeTypes,
+			ceiling:   2.5,
+		},
+		{
+			name:      "not offered in the region",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100", Region: "us_east_1"},
+			nodeTypes: nodeTypes,
+			wantErr:   "not offered",
+		},
+		{
+			name:      "not offered at all",
+			placement: session.Placement{Provider: "unweave", GPUType: "v100"},
+			nodeTypes: nodeTypes,
+			wantErr:   "not offered",
+		},
+		{
+			name:      "over the price ceiling",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100"},
+			nodeTypes: nodeTypes,
+			ceiling:   2,
+			wantErr:   "costs $2.20/hour, more than the price ceiling of $2.00/hour",
+		},
+		{
+			name:      "no price with a ceiling",
+			placement: session.Placement{Provider: "unweave", GPUType: "h100"},
+			nodeTypes: nodeTypes,
+			ceiling:   10,
+			wantErr:   "can't check against the price ceiling",
+		},
+		{
+			name:      "over the monthly budget",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100"},
+			nodeTypes: nodeTypes,
+			budget:    sessionBudget{budget: cost.Budget{Monthly: 1}},
+			wantErr:   "over budget",
+		},
+		{
+			name:      "over the budget for a session the user agreed to",
+			placement: session.Placement{Provider: "unweave", GPUType: "rtx_4000"},
+			nodeTypes: nodeTypes,
+			budget:    sessionBudget{budget: cost.Budget{Monthly: 1}, confirmed: 0.56},
+		},
+		{
+			name:      "over the budget for a pricier session than the user agreed to",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100"},
+			nodeTypes: nodeTypes,
+			budget:    sessionBudget{budget: cost.Budget{Monthly: 1}, confirmed: 0.56},
+			wantErr:   "over budget",
+		},
+		{
+			name:      "over the hourly budget",
+			placement: session.Placement{Provider: "unweave", GPUType: "a100"},
+			nodeTypes: nodeTypes,
+			ceiling:   2.5,
+			budget:    sessionBudget{budget: cost.Budget{MaxHourlyPrice: 1}},
+			wantErr:   "more than the maximum of $1.00/hour",
+		},
+		{
+			name:      "no price with a budget",
+			placement: session.Placement{Provider: "unweave", GPUType: "h100"},
+			nodeTypes: nodeTypes,
+			budget:    sessionBudget{budget: cost.Budget{Monthly: 100}},
+			wantErr:   "can't check against the budget",
+		},
+		{
+			name:      "unknown node types",
+			placement: session.Placement{Provider: "lambdalabs", GPUType: "a100"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkPlacement(c.placement, spec, c.nodeTypes, c.ceiling, c.budget)
+			if c.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, c.wantErr)
+		})
+	}
+}
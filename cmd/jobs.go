@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/jobs"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// jobsRefreshTimeout bounds how long listing jobs waits for their sessions.
+const jobsRefreshTimeout = 30 * time.Second
+
+// JobsList handles the Cobra command for listing the jobs of the project
+func JobsList(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	registry, err := jobRegistry()
+	if err != nil {
+		return err
+	}
+	list, err := registry.List(config.Config.Project.URI)
+	if err != nil {
+		return err
+	}
+
+	refreshJobs(ctx, registry, list)
+
+	if ui.OutputJSON {
+		ui.JSON(list)
+		return nil
+	}
+
+	cols := []ui.Column{
+		{Title: "ID", Width: -1},
+		{Title: "Session", Width: -1},
+		{Title: "Status", Width: -1},
+		{Title: "Exit Code", Width: 9},
+		{Title: "Started", Width: 19},
+		{Title: "Command", Width: -1},
+	}
+	rows := make([]ui.Row, len(list))
+	for idx, j := range list {
+		rows[idx] = ui.Row{
+			j.ID,
+			j.SessionID,
+			string(j.State.Status),
+			formatExitCode(j.State),
+			j.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			strings.Join(j.Command, " "),
+		}
+	}
+	ui.Table("Jobs", cols, rows)
+	return nil
+}
+
+// JobStatus handles the Cobra command for showing the status of a job
+func JobStatus(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	registry, job := getJob(args[0])
+	job, err := refreshJob(ctx, registry, job)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	renderJob(job)
+	return nil
+}
+
+// JobWait handles the Cobra command for waiting for a job to finish. It exits with the
+// exit code of the job.
+func JobWait(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	registry, job := getJob(args[0])
+	t, err := dialJobSession(ctx, job)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	redial := func(ctx context.Context) (ssh.Transport, error) { return dialJobSession(ctx, job) }
+	code := waitForJob(ctx, t, redial, registry, job)
+	t.Close()
+	os.Exit(code)
+	return nil
+}
+
+// JobKill handles the Cobra command for stopping a running job
+func JobKill(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	registry, job := getJob(args[0])
+	t, err := dialJobSession(ctx, job)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	defer t.Close()
+
+	if err = jobs.Kill(ctx, t, job); err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	if job.State, err = jobs.Inspect(ctx, t, job); err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	if err = registry.Save(job); err != nil {
+		ui.Debugf("Failed to save job %s: %s", job.ID, err)
+	}
+
+	ui.Successf("Job %s killed", job.ID)
+	ui.JSON(job)
+	return nil
+}
+
+func jobRegistry() (*jobs.Registry, error) {
+	dir, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return jobs.NewRegistry(filepath.Join(dir, "jobs.json")), nil
+}
+
+// getJob looks up a job of the active project by its ID or a unique prefix of it.
+func getJob(ref string) (*jobs.Registry, jobs.Job) {
+	registry, err := jobRegistry()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	job, err := registry.Get(config.Config.Project.URI, ref)
+	if err != nil {
+		ui.Errorf("%s", err)
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			ui.Infof("Run `unweave jobs ls` to see the jobs of this project.")
+		}
+		os.Exit(1)
+	}
+	return registry, job
+}
+
+// dialJobSession connects to the session a job runs on.
+func dialJobSession(ctx context.Context, job jobs.Job) (ssh.Transport, error) {
+	e, err := getExecByNameOrID(ctx, job.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	prvKey, err := getDefaultKey(ctx, *e, config.SSHPrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get private key: %w", err)
+	}
+	return ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey})
+}
+
+// refreshJob fetches the current state of a job from its session and saves it. If the
+// session can't be reached anymore, e.g. because it was terminated, the job is saved as
+// unknown.
+func refreshJob(ctx context.Context, registry *jobs.Registry, job jobs.Job) (jobs.Job, error) {
+	list := []jobs.Job{job}
+	err := inspectJobs(ctx, list)[0]
+	if serr := registry.Save(list[0]); err == nil {
+		err = serr
+	}
+	return list[0], err
+}
+
+// refreshJobs refreshes the jobs in list that were running the last time we looked,
+// since only those can have changed, and saves them. Every session is dialed once, and
+// the sessions concurrently, so that listing jobs doesn't take a dial timeout for each
+// job of a session that can't be reached.
+func refreshJobs(ctx context.Context, registry *jobs.Registry, list []jobs.Job) {
+	var sessionIDs []string
+	bySession := map[string][]jobs.Job{}
+	for _, j := range list {
+		if j.State.Done() {
+			continue
+		}
+		if _, ok := bySession[j.SessionID]; !ok {
+			sessionIDs = append(sessionIDs, j.SessionID)
+		}
+		bySession[j.SessionID] = append(bySession[j.SessionID], j)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jobsRefreshTimeout)
+	defer cancel()
+
+	errs := make([][]error, len(sessionIDs))
+	session.ForEach(len(sessionIDs), session.DefaultParallelism, func(i int) {
+		errs[i] = inspectJobs(ctx, bySession[sessionIDs[i]])
+	})
+
+	refreshed := map[string]jobs.Job{}
+	for i, id := range sessionIDs {
+		for k, j := range bySession[id] {
+			if errs[i][k] != nil {
+				ui.Debugf("Failed to refresh job %s: %s", j.ID, errs[i][k])
+			}
+			if err := registry.Save(j); err != nil {
+				ui.Debugf("Failed to save job %s: %s", j.ID, err)
+			}
+			refreshed[j.ID] = j
+		}
+	}
+	for i, j := range list {
+		if r, ok := refreshed[j.ID]; ok {
+			list[i] = r
+		}
+	}
+}
+
+// inspectJobs fetches the current state of jobs, which all run on the same session, over
+// one connection. If the session can't be reached anymore, e.g. because it was
+// terminated, the jobs are unknown, unless ctx is done. It returns the error of each job.
+func inspectJobs(ctx context.Context, list []jobs.Job) []error {
+	errs := make([]error, len(list))
+	t, err := dialJobSession(ctx, list[0])
+	if err != nil {
+		for i, j := range list {
+			// Running out of time says nothing about the session
+			if ctx.Err() == nil {
+				list[i].State = jobs.State{Status: jobs.StatusUnknown, PID: j.PID}
+			}
+			errs[i] = err
+		}
+		return errs
+	}
+	defer t.Close()
+
+	for i, j := range list {
+		state, err := jobs.Inspect(ctx, t, j)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		list[i].State = state
+	}
+	return errs
+}
+
+// registerJob saves a job started on session e in the registry, along with its PID.
+func registerJob(ctx context.Context, t ssh.Transport, registry *jobs.Registry, job jobs.Job, e types.Exec) jobs.Job {
+	job.SessionID = e.ID
+
+	var err error
+	if job.State, err = jobs.Inspect(ctx, t, job); err != nil {
+		ui.Debugf("Failed to get status of job %s: %s", job.ID, err)
+	}
+	job.PID = job.State.PID
+
+	if err = registry.Save(job); err != nil {
+		ui.Attentionf("Failed to save job %s: %s", job.ID, err)
+	}
+	return job
+}
+
+// waitForJob waits for a job to finish and returns the exit code the CLI should exit
+// with. The session is reconnected to with redial if the connection drops.
+func waitForJob(ctx context.Context, t ssh.Transport, redial jobs.Dialer, registry *jobs.Registry, job jobs.Job) int {
+	ui.Infof("⏳ Waiting for job %s to finish", job.ID)
+
+	var err error
+	job.State, err = jobs.Wait(ctx, t, redial, job, jobs.DefaultPollInterval)
+	if err != nil {
+		ui.HandleError(err)
+		return 1
+	}
+	if err = registry.Save(job); err != nil {
+		ui.Debugf("Failed to save job %s: %s", job.ID, err)
+	}
+
+	renderJob(job)
+	if job.State.ExitCode == nil {
+		return 1
+	}
+	return *job.State.ExitCode
+}
+
+func renderJob(job jobs.Job) {
+	if ui.OutputJSON {
+		ui.JSON(job)
+		return
+	}
+
+	results := []ui.ResultEntry{
+		{Key: "ID", Value: job.ID},
+		{Key: "Session", Value: job.SessionID},
+		{Key: "Status", Value: string(job.State.Status)},
+		{Key: "Exit Code", Value: formatExitCode(job.State)},
+		{Key: "Started", Value: job.StartedAt.Local().Format(time.RFC1123)},
+		{Key: "Command", Value: strings.Join(job.Command, " ")},
+		{Key: "Logs", Value: job.LogFile()},
+	}
+	ui.ResultTitle("Job:")
+	ui.Result(results, ui.IndentWidth)
+}
+
+func formatExitCode(s jobs.State) string {
+	if s.ExitCode == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *s.ExitCode)
+}
@@ -23,9 +23,10 @@ func SSHKeyAdd(cmd *cobra.Command, args []string) error {
 	if len(args) == 2 {
 		name = args[1]
 	}
-	keyname, err := ssh.Add(cmd.Context(), publicKeyPath, config.Config.Unweave.User.ID, &name)
+	keyname, err := ssh.Add(cmd.Context(), publicKeyPath, config.AccountID(), &name)
 	if err != nil {
-		return err
+		ui.HandleError(err)
+		os.Exit(1)
 	}
 
 	ui.Successf("SSH key added as %q", keyname)
@@ -35,21 +36,18 @@ func SSHKeyAdd(cmd *cobra.Command, args []string) error {
 func sshKeyAddIDRSA(ctx context.Context, path string, name *string) (keyName string, pub []byte, err error) {
 	filename := filepath.Base(path)
 	if filename != "id_rsa" {
-		ui.Errorf("Invalid RSA private key filename: %s. Only ida_rsa is supported", filename)
 		return "", nil, fmt.Errorf("invalid RSA private key filename: %s", filename)
 	}
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		ui.Errorf("Private key not found: %s", path)
 		return "", nil, fmt.Errorf("private key not found: %s", path)
 	}
 
 	if _, err := os.Stat(path + ".pub"); os.IsNotExist(err) {
-		ui.Errorf("Public key not found: %s", path+".pub")
 		return "", nil, fmt.Errorf("public key not found: %s", path+".pub")
 	}
 
-	keyname, err := ssh.Add(ctx, path+".pub", config.Config.Unweave.User.ID, name)
+	keyname, err := ssh.Add(ctx, path+".pub", config.AccountID(), name)
 	if err != nil {
 		return "", nil, err
 	}
@@ -63,9 +61,10 @@ func SSHKeyGenerate(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
 		name = tools.Stringy(args[0])
 	}
-	keyname, keypath, _, err := ssh.Generate(cmd.Context(), config.Config.Unweave.User.ID, name)
+	keyname, keypath, _, err := ssh.Generate(cmd.Context(), config.AccountID(), name)
 	if err != nil {
-		return err
+		ui.HandleError(err)
+		os.Exit(1)
 	}
 
 	ui.Successf("Created new SSH key pair:\n"+
@@ -82,7 +81,7 @@ func SSHKeyList(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	uwc := config.InitUnweaveClient()
 
-	entries, err := uwc.SSHKey.List(ctx, config.Config.Unweave.User.ID)
+	entries, err := uwc.SSHKey.List(ctx, config.AccountID())
 	if err != nil {
 		var e *types.Error
 		if errors.As(err, &e) {
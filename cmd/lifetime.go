@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// resolveLifetime returns the lifetime of a new session. The --ttl and --idle-timeout
+// flags override the ttl and idle_timeout of the spec.
+func resolveLifetime() (session.Lifetime, error) {
+	spec, _ := selectedSpec()
+
+	ttl := spec.TTL
+	if config.TTL != "" {
+		ttl = config.TTL
+	}
+	idleTimeout := spec.IdleTimeout
+	if config.IdleTimeout != "" {
+		idleTimeout = config.IdleTimeout
+	}
+
+	var lifetime session.Lifetime
+	var err error
+	if ttl != "" {
+		if lifetime.TTL, err = session.ParseAge(ttl); err != nil {
+			return lifetime, fmt.Errorf("invalid TTL: %w", err)
+		}
+	}
+	if idleTimeout != "" {
+		if lifetime.IdleTimeout, err = session.ParseAge(idleTimeout); err != nil {
+			return lifetime, fmt.Errorf("invalid idle timeout: %w", err)
+		}
+	}
+	return lifetime, nil
+}
+
+// mustResolveLifetime returns the lifetime of a new session and exits if it's invalid.
+func mustResolveLifetime() session.Lifetime {
+	lifetime, err := resolveLifetime()
+	if err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
+	return lifetime
+}
+
+// installWatchdog starts the watchdog that enforces the lifetime of a session, if it has
+// one. It should be called once the session is running.
+func installWatchdog(ctx context.Context, e types.Exec, prvKey string) error {
+	lifetimes, err := sessionLifetimeStore()
+	if err != nil {
+		return err
+	}
+	all, err := lifetimes.All()
+	if err != nil {
+		return err
+	}
+	lifetime, ok := all[e.ID]
+	if !ok || lifetime.IsZero() {
+		return nil
+	}
+
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	w := session.Watchdog{
+		Lifetime:  lifetime,
+		CreatedAt: createdAt,
+	}
+
+	t, err := ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		return fmt.Errorf("failed to connect to session: %w", err)
+	}
+	defer t.Close()
+
+	if err = w.Install(ctx, t); err != nil {
+		return err
+	}
+	ui.Infof("⏲️  %s", describeLifetime(lifetime))
+	return nil
+}
+
+// setupWatchdog installs the watchdog of a new session and warns if that fails, since
+// the session then runs until it's terminated by hand.
+func setupWatchdog(ctx context.Context, e types.Exec, prvKey string) {
+	if err := installWatchdog(ctx, e, prvKey); err != nil {
+		ui.Attentionf("The session won't be powered off automatically: %s", err)
+	}
+}
+
+func describeLifetime(l session.Lifetime) string {
+	var parts []string
+	if l.TTL > 0 {
+		parts = append(parts, fmt.Sprintf("after %s", l.TTL))
+	}
+	if l.IdleTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("once idle for %s", l.IdleTimeout))
+	}
+	return "The session will be powered off " + strings.Join(parts, " or ") +
+		". Run `unweave terminate` if `unweave ls` shows it as expired but still running"
+}
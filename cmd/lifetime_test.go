@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/session"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestResolveLifetime(t *testing.T) {
+	config.Config.Project = &config.Project{Specs: []config.Spec{
+		{Name: "default"},
+		{Name: "sweep", TTL: "4h", IdleTimeout: "30m"},
+	}}
+	t.Cleanup(func() {
+		config.SpecName, config.TTL, config.IdleTimeout = "", "", ""
+	})
+
+	t.Run("should be unlimited by default", func(t *testing.T) {
+		config.SpecName, config.TTL, config.IdleTimeout = "", "", ""
+		lifetime, err := resolveLifetime()
+		assert.NoError(t, err)
+		assert.True(t, lifetime.IsZero())
+	})
+
+	t.Run("should use the lifetime of the spec", func(t *testing.T) {
+		config.SpecName, config.TTL, config.IdleTimeout = "sweep", "", ""
+		lifetime, err := resolveLifetime()
+		assert.NoError(t, err)
+		assert.Equal(t, session.Lifetime{TTL: 4 * time.Hour, IdleTimeout: 30 * time.Minute}, lifetime)
+	})
+
+	t.Run("should override the spec with flags", func(t *testing.T) {
+		config.SpecName, config.TTL, config.IdleTimeout = "sweep", "1d", ""
+		lifetime, err := resolveLifetime()
+		assert.NoError(t, err)
+		assert.Equal(t, session.Lifetime{TTL: 24 * time.Hour, IdleTimeout: 30 * time.Minute}, lifetime)
+	})
+
+	t.Run("should reject invalid durations", func(t *testing.T) {
+		config.SpecName, config.TTL, config.IdleTimeout = "", "", "soon"
+		_, err := resolveLifetime()
+		assert.Error(t, err)
+	})
+}
+
+func TestRemainingColumn(t *testing.T) {
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	column := sessionColumns["remaining"]
+	info := func(status types.Status, l *session.Lifetime) sessionInfo {
+		return sessionInfo{Exec: types.Exec{CreatedAt: created, Status: status}, Lifetime: l}
+	}
+
+	assert.Equal(t, "-", column.value(info(types.StatusRunning, nil), created))
+	assert.Equal(t, "-", column.value(info(types.StatusRunning, &session.Lifetime{IdleTimeout: time.Hour}), created))
+	assert.Equal(t, "expired", column.value(info(types.StatusRunning, &session.Lifetime{TTL: time.Hour}), created.Add(2*time.Hour)),
+		"the session is only powered off, it still has to be terminated")
+	assert.Equal(t, "-", column.value(info(types.StatusTerminated, &session.Lifetime{TTL: time.Hour}), created.Add(2*time.Hour)))
+}
@@ -27,12 +27,14 @@ func Link(cmd *cobra.Command, args []string) error {
 	uwc := config.InitUnweaveClient()
 	project, err := uwc.Account.ProjectGet(ctx, owner, projectName)
 	if err != nil {
-		return ui.HandleError(err)
+		ui.HandleError(err)
+		os.Exit(1)
 	}
 
 	account, err := uwc.Account.AccountGet(ctx, config.Config.Unweave.User.ID)
 	if err != nil {
-		return ui.HandleError(err)
+		ui.HandleError(err)
+		os.Exit(1)
 	}
 
 	if config.IsProjectLinked() {
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/skratchdot/open-golang/open"
@@ -14,11 +18,24 @@ import (
 	"github.com/unweave/unweave/api/types"
 )
 
+const (
+	loginTimeout         = 5 * time.Minute
+	loginPollInterval    = time.Second
+	loginMaxPollInterval = 10 * time.Second
+)
+
 func Login(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
+	if config.LoginWithToken {
+		return loginWithToken(cmd.InOrStdin())
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
 	uwc := config.InitUnweaveClient()
-	code, err := uwc.Account.PairingTokenCreate(cmd.Context())
+	code, err := uwc.Account.PairingTokenCreate(ctx)
 	if err != nil {
 		var e *types.Error
 		if errors.As(err, &e) {
@@ -31,7 +48,7 @@ func Login(cmd *cobra.Command, args []string) error {
 	}
 
 	authURL := config.Config.Unweave.AppURL + "/auth/pair?code=" + code
-	openBrowser := ui.Confirm("Do you want to open the browser to login", "y")
+	openBrowser := !config.LoginNoBrowser && ui.Confirm("Do you want to open the browser to login", "y")
 
 	ui.Attentionf("Auth Code: %s", code)
 	var openErr error
@@ -41,38 +58,36 @@ func Login(cmd *cobra.Command, args []string) error {
 
 	if !openBrowser || openErr != nil {
 		fmt.Println("Open the following URL in your browser to login: ", authURL)
+		if config.LoginQR {
+			qr, err := ui.QRCode(authURL)
+			if err != nil {
+				ui.Debugf("Failed to render QR code: %s", err)
+			} else {
+				fmt.Println(qr)
+			}
+		}
 	}
 
-	var token string
-	var account *types.Account
-
-	sleep := time.Duration(2)
-	timeout := 5 * time.Minute
-	retryUntil := time.Now().Add(timeout)
-
-	for {
-		if time.Now().After(retryUntil) {
-			fmt.Printf("Login timed out after %f minutes \n", timeout.Minutes())
+	token, account, err := waitForPairing(ctx, code)
+	if err != nil {
+		var e *types.Error
+		if errors.As(err, &e) {
+			uie := &ui.Error{Error: e}
+			fmt.Println(uie.Verbose())
 			os.Exit(1)
 			return nil
 		}
-
-		token, account, err = uwc.Account.PairingTokenExchange(cmd.Context(), code)
-		if err != nil {
-			var e *types.Error
-			if errors.As(err, &e) {
-				if e.Code == http.StatusUnauthorized {
-					time.Sleep(sleep * time.Second)
-					continue
-				}
-				uie := &ui.Error{Error: e}
-				fmt.Println(uie.Verbose())
-				os.Exit(1)
-				return nil
-			}
-			return err
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Printf("Login timed out after %.0f minutes \n", loginTimeout.Minutes())
+			os.Exit(1)
+			return nil
 		}
-		break
+		if errors.Is(err, context.Canceled) {
+			ui.Infof("Login cancelled")
+			os.Exit(1)
+			return nil
+		}
+		return err
 	}
 
 	config.Config.Unweave.User.Token = token
@@ -83,5 +98,73 @@ func Login(cmd *cobra.Command, args []string) error {
 	}
 
 	ui.Successf("Logged in as %q", account.Email)
+	ui.Debugf("Token saved to the %s", config.CredentialStore().Name())
+	return nil
+}
+
+// waitForPairing polls until the pairing code has been confirmed in the browser. The
+// interval between polls grows from loginPollInterval to loginMaxPollInterval. It stops
+// after loginTimeout or when ctx is cancelled.
+func waitForPairing(ctx context.Context, code string) (string, *types.Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+
+	uwc := config.InitUnweaveClient()
+	interval := loginPollInterval
+
+	for {
+		token, account, err := uwc.Account.PairingTokenExchange(ctx, code)
+		if err == nil {
+			return token, account, nil
+		}
+
+		var e *types.Error
+		if !errors.As(err, &e) || e.Code != http.StatusUnauthorized {
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			return "", nil, err
+		}
+
+		// Not confirmed yet
+		ui.Debugf("Pairing not confirmed yet, checking again in %s", interval)
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", nil, ctx.Err()
+		}
+
+		interval = interval * 3 / 2
+		if interval > loginMaxPollInterval {
+			interval = loginMaxPollInterval
+		}
+	}
+}
+
+// loginWithToken saves a token read from r for the active context, e.g. a token piped in
+// from a secret manager in a script.
+func loginWithToken(r io.Reader) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(buf))
+	if token == "" || strings.ContainsAny(token, " \n\t") {
+		ui.Errorf("Expected a single token on stdin, e.g. `echo $UNWEAVE_TOKEN | unweave login --with-token`")
+		os.Exit(1)
+	}
+
+	// The token may belong to someone else than the previous login
+	config.Config.Unweave.User.Token = token
+	config.Config.Unweave.User.ID = ""
+	config.Config.Unweave.User.Email = ""
+	if err = config.Config.Unweave.Save(); err != nil {
+		return err
+	}
+
+	ui.Successf("Token saved for context %q", config.ActiveContext.Name)
+	ui.Debugf("Token saved to the %s", config.CredentialStore().Name())
 	return nil
 }
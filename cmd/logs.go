@@ -6,12 +6,10 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
-	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/jobs"
 	"github.com/unweave/cli/ui"
 )
 
-const execLogFile = "/logs/exec.log"
-
 func Logs(cmd *cobra.Command, args []string) error {
 
 	if len(args) == 0 {
@@ -42,10 +40,10 @@ func Logs(cmd *cobra.Command, args []string) error {
 		command = append(command, "-f")
 	}
 
-	command = append(command, execLogFile)
+	command = append(command, jobs.LatestLogFile)
 
 	prvKey := config.SSHPrivateKeyPath
-	if err := ssh.Connect(ctx, e.Network, prvKey, config.SSHConnectionOptions, command); err != nil {
+	if err := connect(ctx, e.Network, prvKey, config.SSHConnectionOptions, command); err != nil {
 		ui.Errorf("%s", err)
 		os.Exit(1)
 	}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -51,7 +52,8 @@ func ProviderListGPUTypes(cmd *cobra.Command, args []string) error {
 
 	res, err := uwc.Provider.ListNodeTypes(cmd.Context(), provider, filterAvailable)
 	if err != nil {
-		return ui.HandleError(err)
+		ui.HandleError(err)
+		os.Exit(1)
 	}
 
 	cols, rows := gpuTypesToTable(res)
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/resources"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// Plan handles the Cobra command for showing how apply would change the resources of
+// the project to match the manifest
+func Plan(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	plan := planResources(cmd.Context())
+	if ui.OutputJSON {
+		ui.JSON(plan)
+		return nil
+	}
+	renderPlan(plan)
+	return nil
+}
+
+// Apply handles the Cobra command for changing the resources of the project to match
+// the manifest
+func Apply(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	plan := planResources(ctx)
+	renderPlan(plan)
+	if len(plan.Changes) == 0 && len(plan.Reattach) == 0 {
+		if ui.OutputJSON {
+			ui.JSON(applyResult{Applied: []resources.Change{}, Skipped: []resources.Change{}})
+		}
+		return nil
+	}
+
+	// Reattaching evals changes nothing, so it needs no confirmation
+	if len(plan.Changes) > 0 && !config.Yes {
+		msg := fmt.Sprintf("Apply %d changes", len(plan.Changes))
+		if plan.Destructive() {
+			msg += ", permanently deleting the data of the volumes deleted or replaced"
+		}
+		if !ui.Confirm(msg, "n") {
+			return nil
+		}
+	}
+
+	owner, project, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	a := &resourcesApplier{
+		uwc:       config.InitUnweaveClient(),
+		owner:     owner,
+		project:   project,
+		endpoints: map[string]string{},
+	}
+
+	result := a.applyAll(ctx, append(append([]resources.Change{}, plan.Changes...), plan.Reattach...))
+	renderApplyResult(result)
+	if ui.OutputJSON {
+		ui.JSON(result)
+	}
+	if result.Failed != nil {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// applyResult is what apply changed. Apply stops at the first change that fails, and
+// skips the ones after it.
+type applyResult struct {
+	Applied []resources.Change `json:"applied"`
+	Failed  *failedChange      `json:"failed,omitempty"`
+	Skipped []resources.Change `json:"skipped"`
+}
+
+type failedChange struct {
+	resources.Change
+	Error string `json:"error"`
+}
+
+func renderApplyResult(r applyResult) {
+	if r.Failed == nil {
+		ui.Successf("Applied %d changes", len(r.Applied))
+		return
+	}
+	ui.Errorf("Applied %d of %d changes, %s failed", len(r.Applied), len(r.Applied)+1+len(r.Skipped), r.Failed.Change)
+	for _, c := range r.Skipped {
+		ui.Infof("Not applied: %s", c)
+	}
+}
+
+// planResources diffs the resources manifest against the resources the project has,
+// exiting if either can't be read.
+func planResources(ctx context.Context) *resources.Plan {
+	path := config.ResourcesFile
+	if path == "" {
+		var err error
+		if path, err = config.FindResourcesFile(); err != nil {
+			ui.HandleError(err)
+			os.Exit(1)
+		}
+	}
+	manifest, issues, err := config.LoadResources(path)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			ui.Errorf("%s", issue)
+		}
+		os.Exit(1)
+	}
+
+	owner, project, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	uwc := config.InitUnweaveClient()
+
+	var state resources.State
+	if state.Volumes, err = uwc.Volume.List(ctx, owner, project); err != nil {
+		ui.HandleError(fmt.Errorf("failed to list volumes: %w", err))
+		os.Exit(1)
+	}
+	if state.Endpoints, err = uwc.Endpoints.List(ctx, owner, project); err != nil {
+		ui.HandleError(fmt.Errorf("failed to list endpoints: %w", err))
+		os.Exit(1)
+	}
+	if state.Evals, err = uwc.Evals.List(ctx, owner, project); err != nil {
+		ui.HandleError(fmt.Errorf("failed to list evals: %w", err))
+		os.Exit(1)
+	}
+
+	plan, err := resources.NewPlan(manifest, state, resources.Options{Prune: config.Prune})
+	if err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
+	return plan
+}
+
+func renderPlan(plan *resources.Plan) {
+	if len(plan.Changes) == 0 {
+		ui.Successf("No changes, the resources of the project match the manifest")
+	} else {
+		for _, c := range plan.Changes {
+			ui.Infof("%s", c)
+		}
+	}
+
+	names := func(rs []resources.Resource) string {
+		s := make([]string, len(rs))
+		for i, r := range rs {
+			s[i] = fmt.Sprintf("%s %s", r.Kind, r.Name)
+		}
+		return strings.Join(s, ", ")
+	}
+	if len(plan.Unchecked) > 0 {
+		ui.Infof("Left as they are, since the API doesn't return their command, spec and port: %s", names(plan.Unchecked))
+	}
+	if len(plan.Reattach) > 0 {
+		evals := make([]string, len(plan.Reattach))
+		for i, c := range plan.Reattach {
+			evals[i] = fmt.Sprintf("eval %s -> endpoint %s", c.EvalID, c.Name)
+		}
+		ui.Infof("Attached again, which changes nothing if they already are: %s", strings.Join(evals, ", "))
+	}
+	if len(plan.Unmanaged) > 0 {
+		ui.Infof("Not in the manifest: %s", names(plan.Unmanaged))
+	}
+}
+
+// resourcesApplier makes the changes of a plan.
+type resourcesApplier struct {
+	uwc            *client.Client
+	owner, project string
+	// endpoints are the IDs of the endpoints created by name, to attach evals to.
+	endpoints map[string]string
+}
+
+// applyAll makes changes in order until one fails.
+func (a *resourcesApplier) applyAll(ctx context.Context, changes []resources.Change) applyResult {
+	result := applyResult{Applied: []resources.Change{}, Skipped: []resources.Change{}}
+	for i, c := range changes {
+		if err := a.apply(ctx, c); err != nil {
+			ui.Errorf("✗ %s: %s", c, err)
+			result.Failed = &failedChange{Change: c, Error: err.Error()}
+			result.Skipped = append(result.Skipped, changes[i+1:]...)
+			return result
+		}
+		ui.Successf("✓ %s", c)
+		result.Applied = append(result.Applied, c)
+	}
+	return result
+}
+
+func (a *resourcesApplier) apply(ctx context.Context, c resources.Change) error {
+	switch c.Action {
+	case resources.ActionCreate:
+		if c.Kind == resources.KindEndpoint {
+			return a.deployEndpoint(ctx, *c.Endpoint)
+		}
+		return a.createVolume(ctx, *c.Volume)
+	case resources.ActionResize:
+		return a.uwc.Volume.Update(ctx, a.owner, a.project, c.ID, types.VolumeResizeRequest{IDOrName: c.ID, Size: c.Volume.Size})
+	case resources.ActionReplace:
+		if err := a.uwc.Volume.Delete(ctx, a.owner, a.project, c.ID); err != nil {
+			return err
+		}
+		return a.createVolume(ctx, *c.Volume)
+	case resources.ActionDelete:
+		return a.uwc.Volume.Delete(ctx, a.owner, a.project, c.ID)
+	case resources.ActionAttachEval:
+		id := c.ID
+		if id == "" {
+			id = a.endpoints[strings.ToLower(c.Name)]
+		}
+		return a.uwc.Endpoints.EvalAttach(ctx, a.owner, a.project, id, c.EvalID)
+	}
+	return fmt.Errorf("unknown action %q", c.Action)
+}
+
+func (a *resourcesApplier) createVolume(ctx context.Context, v config.VolumeResource) error {
+	_, err := a.uwc.Volume.Create(ctx, a.owner, a.project, types.VolumeCreateRequest{
+		Size:     v.Size,
+		Name:     v.Name,
+		Provider: types.Provider(v.Provider),
+	})
+	return err
+}
+
+// deployEndpoint creates an endpoint the way unweave deploy does, by running its
+// command on a new session.
+func (a *resourcesApplier) deployEndpoint(ctx context.Context, e config.EndpointResource) error {
+	end, _, err := deployEndpoint(ctx, endpointDeployment{name: e.Name, command: e.Command, spec: e.Spec, port: e.Port})
+	if err != nil {
+		return err
+	}
+	a.endpoints[strings.ToLower(e.Name)] = end.ID
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/resources"
+)
+
+func TestApplyAll(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/volumes/") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 400, "message": "volume is attached"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	a := &resourcesApplier{
+		uwc:       client.NewClient(client.Config{ApiURL: srv.URL, Retry: &client.RetryPolicy{MaxAttempts: 1}}),
+		owner:     "owner",
+		project:   "project",
+		endpoints: map[string]string{},
+	}
+	changes := []resources.Change{
+		{Kind: resources.KindVolume, Action: resources.ActionCreate, Name: "data", Volume: &config.VolumeResource{Name: "data", Size: 10}},
+		{Kind: resources.KindVolume, Action: resources.ActionResize, Name: "cache", ID: "vol_cache", Volume: &config.VolumeResource{Name: "cache", Size: 20}},
+		{Kind: resources.KindEndpoint, Action: resources.ActionAttachEval, Name: "api", ID: "end_api", EvalID: "evl_1"},
+	}
+
+	result := a.applyAll(context.Background(), changes)
+	assert.Equal(t, changes[:1], result.Applied)
+	require.NotNil(t, result.Failed)
+	assert.Equal(t, "cache", result.Failed.Name)
+	assert.Contains(t, result.Failed.Error, "volume is attached")
+	assert.Equal(t, changes[2:], result.Skipped)
+	assert.Len(t, requests, 2, "changes after the failed one aren't applied")
+}
@@ -6,12 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/unweave/cli/client"
 	"github.com/unweave/cli/config"
 	"github.com/unweave/cli/session"
 	"github.com/unweave/cli/ssh"
@@ -41,11 +44,16 @@ func setupSSHKey(ctx context.Context) (string, []byte, error) {
 		if err != nil {
 			return "", nil, err
 		}
+		ui.Infof("Generated public key from private key at path: %s", config.SSHPrivateKeyPath)
 		return name, pub, nil
 	}
 
 	// No key details provided, try using ~/.unweave_global/.ssh/
-	name, pub, err := getFirstPublicKeyInPath(ctx, config.GetUnweaveSSHKeysFolder())
+	dir, err := config.GetUnweaveSSHKeysFolder()
+	if err != nil {
+		return "", nil, err
+	}
+	name, pub, err := getFirstPublicKeyInPath(ctx, dir)
 	if err == nil {
 		return name, pub, nil
 	}
@@ -55,7 +63,10 @@ func setupSSHKey(ctx context.Context) (string, []byte, error) {
 }
 
 func generateSSHKey(ctx context.Context) (string, []byte, error) {
-	dir := config.GetUnweaveSSHKeysFolder()
+	dir, err := config.GetUnweaveSSHKeysFolder()
+	if err != nil {
+		return "", nil, err
+	}
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return "", nil, err
@@ -68,7 +79,7 @@ func generateSSHKey(ctx context.Context) (string, []byte, error) {
 
 		config.SSHPublicKeyPath = filepath.Join(dir, entry.Name())
 
-		keyname, err := ssh.Add(ctx, config.SSHPublicKeyPath, config.Config.Unweave.User.ID, nil)
+		keyname, err := ssh.Add(ctx, config.SSHPublicKeyPath, config.AccountID(), nil)
 		if err != nil {
 			return "", nil, err
 		}
@@ -79,7 +90,7 @@ func generateSSHKey(ctx context.Context) (string, []byte, error) {
 	ui.Attentionf("No SSH key found at %s", dir)
 	ui.Attentionf("Generating new SSH key")
 
-	name, keypath, pub, err := ssh.Generate(ctx, config.Config.Unweave.User.ID, nil)
+	name, keypath, pub, err := ssh.Generate(ctx, config.AccountID(), nil)
 	if err != nil {
 		return "", nil, err
 	}
@@ -92,7 +103,9 @@ func generateSSHKey(ctx context.Context) (string, []byte, error) {
 	return name, pub, nil
 }
 
-func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig types.GitConfig) (string, error) {
+// sessionCreate creates a session with lifetime, which the watchdog enforces once the
+// session runs. See session.Watchdog.
+func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig types.GitConfig, lifetime session.Lifetime) (string, error) {
 	var region, image *string
 
 	if config.Config.Project.DefaultProvider == "" && config.Provider == "" {
@@ -106,6 +119,26 @@ func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig t
 
 	spec, err := parseHardwareSpec()
 	if err != nil {
+		ui.HandleError(err)
+		return "", err
+	}
+
+	labels, err := session.ParseLabels(config.Labels)
+	if err != nil {
+		ui.Errorf("%s", err)
+		return "", err
+	}
+	if err = validateNotifyTargets(); err != nil {
+		ui.Errorf("%s", err)
+		return "", err
+	}
+	if _, err = resolveSessionEnv(); err != nil {
+		ui.Errorf("%s", err)
+		return "", err
+	}
+	budget, err := checkSessionCost(ctx, types.Provider(provider), spec, lifetime)
+	if err != nil {
+		ui.Errorf("%s", err)
 		return "", err
 	}
 
@@ -119,10 +152,12 @@ func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig t
 
 	name, pub, err := setupSSHKey(ctx)
 	if err != nil {
+		ui.HandleError(err)
 		return "", err
 	}
 	volumes, err := config.GetVolumeAttachParams()
 	if err != nil {
+		ui.HandleError(err)
 		return "", err
 	}
 
@@ -141,7 +176,22 @@ func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig t
 		InternalPort: config.InternalPort,
 	}
 
-	sessionID, err := session.Create(ctx, params)
+	sc, err := session.FromConfig()
+	if err != nil {
+		ui.HandleError(err)
+		return "", err
+	}
+	first := session.Placement{Provider: params.Provider, GPUType: spec.GPU.Type}
+	if region != nil {
+		first.Region = *region
+	}
+	fallback := sessionFallback(ctx, first, spec, budget)
+	exec, placement, err := sc.CreateWithFallback(ctx, params, fallback)
+	waited := false
+	if config.WaitForCapacity && session.IsOutOfCapacity(err) {
+		exec, placement, err = waitForCapacity(ctx, sc, params, fallback)
+		waited = err == nil
+	}
 	if err != nil {
 		var e *types.Error
 		if errors.As(err, &e) {
@@ -162,40 +212,461 @@ func sessionCreate(ctx context.Context, execConfig types.ExecConfig, gitConfig t
 			fmt.Println(uie.Verbose())
 			return "", e
 		}
+		ui.Errorf("Failed to create session: %v", err)
 		return "", err
 	}
+	if placement != first {
+		ui.Successf("Created the session with %s instead of %s", placement, first)
+	}
+	placedSpec := spec
+	placedSpec.GPU.Type = placement.GPUType
+	recordSessionStart(ctx, *exec, placement.Provider, placedSpec)
+	info := sessionInfo{Exec: *exec}
+	if len(labels) > 0 {
+		info.Labels = labels
+		if err = saveSessionLabels(exec.ID, labels); err != nil {
+			ui.Attentionf("Failed to save the labels of the session: %s", err)
+		}
+	}
+	if !lifetime.IsZero() {
+		info.Lifetime = &lifetime
+		if err = saveSessionLifetime(exec.ID, lifetime); err != nil {
+			ui.Attentionf("Failed to save the lifetime of the session: %s", err)
+		}
+	}
+	renderSession("Session Created:", info)
+	if waited {
+		notifySessionCreated(ctx, *exec, placement)
+	}
 
-	return sessionID, nil
+	return exec.ID, nil
 }
 
-func execCreateAndWatch(ctx context.Context, execConfig types.ExecConfig, gitConfig types.GitConfig) (exech chan types.Exec, errch chan error, err error) {
-	execID, err := sessionCreate(ctx, execConfig, gitConfig)
+func execCreateAndWatch(ctx context.Context, execConfig types.ExecConfig, gitConfig types.GitConfig, lifetime session.Lifetime) <-chan session.Event {
+	execID, err := sessionCreate(ctx, execConfig, gitConfig, lifetime)
 	if err != nil {
-		ui.Errorf("Failed to create session: %v", err)
 		os.Exit(1)
-		return nil, nil, nil
+		return nil
+	}
+	events, err := watchSession(ctx, execID)
+	if err != nil {
+		handleWaitError(err)
+		return nil
+	}
+	return events
+}
+
+// watchSession reports the status transitions of a session until it's running, waiting
+// for at most the configured timeout.
+func watchSession(ctx context.Context, execID string) (<-chan session.Event, error) {
+	sc, err := session.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+	waiter := sc.Waiter()
+	waiter.Timeout = config.WaitTimeout
+	return waiter.Watch(ctx, execID), nil
+}
+
+// renderSession prints the details of a session under title.
+func renderSession(title string, info sessionInfo) {
+	ui.ResultTitle(title)
+	ui.Result(sessionResults(info), ui.IndentWidth)
+}
+
+func sessionResults(info sessionInfo) []ui.ResultEntry {
+	exec := info.Exec
+	instanceType := exec.Spec.GPU.Type
+	if instanceType == "" {
+		instanceType = exec.Spec.CPU.Type
+	}
+
+	created := "-"
+	if !exec.CreatedAt.IsZero() {
+		created = exec.CreatedAt.Local().Format(time.RFC1123)
+	}
+
+	results := []ui.ResultEntry{
+		{Key: "Name", Value: exec.Name},
+		{Key: "ID", Value: exec.ID},
+		{Key: "Status", Value: fmt.Sprintf("%s", exec.Status)},
+		{Key: "Provider", Value: exec.Provider.DisplayName()},
+		{Key: "Region", Value: fmt.Sprintf("%v", dashIfZeroValue(exec.Region))},
+		{Key: "Instance Type", Value: fmt.Sprintf("%v", dashIfZeroValue(instanceType))},
+		{Key: "Created", Value: created},
+		{Key: "Labels", Value: fmt.Sprintf("%v", dashIfZeroValue(info.Labels.String()))},
+		{Key: "Image", Value: fmt.Sprintf("%v", dashIfZeroValue(exec.Image))},
+		{Key: "Command", Value: fmt.Sprintf("%v", dashIfZeroValue(strings.Join(exec.Command, " ")))},
+		{Key: "CPUs", Value: formatHardwareRange(exec.Spec.CPU.HardwareRequestRange, "")},
+		{Key: "RAM", Value: formatHardwareRange(exec.Spec.RAM, "GB")},
+		{Key: "HDD", Value: formatHardwareRange(exec.Spec.HDD, "GB")},
+		{Key: "GPU Type", Value: fmt.Sprintf("%v", dashIfZeroValue(exec.Spec.GPU.Type))},
+		{Key: "NumGPUs", Value: formatHardwareRange(exec.Spec.GPU.Count, "")},
+		{Key: "GPU Memory", Value: formatHardwareRange(exec.Spec.GPU.RAM, "GB")},
+		{Key: "Volumes", Value: ui.FormatVolumes(exec.Volumes)},
+		{Key: "SSHKeys", Value: fmt.Sprintf("%v", dashIfZeroValue(getSSHKeyNames(exec.Keys)))},
+	}
+
+	if n := exec.Network; n.Host != "" {
+		results = append(results,
+			ui.ResultEntry{Key: "Host", Value: n.Host},
+			ui.ResultEntry{Key: "Port", Value: fmt.Sprintf("%d", n.Port)},
+			ui.ResultEntry{Key: "User", Value: n.User},
+			ui.ResultEntry{Key: "SSH", Value: fmt.Sprintf("ssh -p %d %s@%s", n.Port, n.User, n.Host)},
+		)
+	}
+	if svc := exec.Network.HTTPService; svc != nil {
+		results = append(results,
+			ui.ResultEntry{Key: "HTTP Service", Value: fmt.Sprintf("%v", dashIfZeroValue(svc.Hostname))},
+			ui.ResultEntry{Key: "InternalPort", Value: fmt.Sprintf("%d", svc.InternalPort)},
+		)
+	}
+	if l := info.Lifetime; l != nil {
+		if l.TTL > 0 {
+			remaining, _ := l.Remaining(exec.CreatedAt, time.Now())
+			results = append(results, ui.ResultEntry{Key: "TTL", Value: fmt.Sprintf("%s (%s left)", l.TTL, formatAge(remaining))})
+		}
+		if l.IdleTimeout > 0 {
+			results = append(results, ui.ResultEntry{Key: "Idle Timeout", Value: l.IdleTimeout.String()})
+		}
+	}
+	if exec.BuildID != nil {
+		results = append(results, ui.ResultEntry{Key: "Build ID", Value: *exec.BuildID})
+	}
+	if exec.GitURL != nil {
+		results = append(results, ui.ResultEntry{Key: "Git URL", Value: *exec.GitURL})
+	}
+	if exec.CommitID != nil {
+		results = append(results, ui.ResultEntry{Key: "Commit", Value: *exec.CommitID})
+	}
+	return results
+}
+
+// formatHardwareRange formats a requested amount of a resource as "4GB", or "4-8GB" if
+// any amount in the range is fine. Ranges without a minimum or maximum are open-ended,
+// e.g. ">=24GB".
+func formatHardwareRange(r types.HardwareRequestRange, unit string) string {
+	switch {
+	case r.Min == 0 && r.Max == 0:
+		return "-"
+	case r.Max == 0:
+		return fmt.Sprintf(">=%d%s", r.Min, unit)
+	case r.Min == 0:
+		return fmt.Sprintf("<=%d%s", r.Max, unit)
+	case r.Max <= r.Min:
+		return fmt.Sprintf("%d%s", r.Min, unit)
+	}
+	return fmt.Sprintf("%d-%d%s", r.Min, r.Max, unit)
+}
+
+func getSSHKeyNames(keys []types.SSHKey) string {
+	keyNames := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		keyNames = append(keyNames, key.Name)
+	}
+
+	return strings.Join(keyNames, ", ")
+}
+
+// renderWaitEvent prints the progress of a session that isn't running yet.
+func renderWaitEvent(event session.Event) {
+	if event.Err != nil || event.Exec.Status == types.StatusRunning {
+		return
+	}
+	ui.Infof("Waiting for session %q to start (%s)...", event.Exec.ID, event.Exec.Status)
+}
+
+// handleWaitError renders the error that stopped waiting for a session and exits.
+func handleWaitError(err error) {
+	ui.HandleError(err)
+	if errors.Is(err, session.ErrWaitTimeout) {
+		ui.Infof("Run `unweave ls` to see the status of your session.")
 	}
-	return session.Wait(ctx, execID)
+	os.Exit(1)
 }
 
 func SessionCreateCmd(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
-	if _, err := sessionCreate(cmd.Context(), types.ExecConfig{}, types.GitConfig{}); err != nil {
+	ctx := cmd.Context()
+
+	lifetime := mustResolveLifetime()
+	execID, err := sessionCreate(ctx, types.ExecConfig{}, types.GitConfig{}, lifetime)
+	if err != nil {
+		os.Exit(1)
+		return nil
+	}
+
+	// The watchdog that enforces the lifetime and the environment variables can only be
+	// installed once the session runs
+	env, _ := resolveSessionEnv()
+	if !lifetime.IsZero() || len(env) > 0 {
+		sc, err := session.FromConfig()
+		if err != nil {
+			ui.HandleError(err)
+			os.Exit(1)
+		}
+		waiter := sc.Waiter()
+		waiter.Timeout = config.WaitTimeout
+		e, err := waiter.Until(ctx, execID, renderWaitEvent)
+		if err != nil {
+			handleWaitError(err)
+		}
+		prvKey, err := getDefaultKey(ctx, *e, config.SSHPrivateKeyPath)
+		if err != nil {
+			if len(env) > 0 {
+				ui.Errorf("Failed to set the environment variables of the session: failed to get private key: %s", err)
+				os.Exit(1)
+			}
+			ui.Attentionf("The session won't be powered off automatically: failed to get private key: %s", err)
+			return nil
+		}
+		setupWatchdog(ctx, *e, prvKey)
+		setupEnv(ctx, *e, prvKey, true)
+	}
+	return nil
+}
+
+// SessionGet handles the Cobra command for showing the details of a session
+func SessionGet(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	sc, err := session.FromConfig()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	exec, err := getSession(ctx, sc, args[0])
+	if err != nil {
+		ui.HandleError(err)
+		if errors.Is(err, client.ErrNotFound) {
+			ui.Infof("Run `unweave ls --all` to see the sessions of this project.")
+		}
 		os.Exit(1)
+	}
+
+	info := getSessionInfo(*exec)
+	if ui.OutputJSON {
+		ui.JSON(info)
 		return nil
 	}
+	renderSession("Session:", info)
 	return nil
 }
 
+// getSession returns the full record of the session with the ID or name ref, including
+// terminated sessions.
+func getSession(ctx context.Context, sc *session.Client, ref string) (*types.Exec, error) {
+	exec, err := sc.Get(ctx, ref)
+	if err == nil || !errors.Is(err, client.ErrNotFound) {
+		return exec, err
+	}
+
+	// Not an ID, look it up by name
+	execs, lerr := sc.List(ctx, true)
+	if lerr != nil {
+		return nil, lerr
+	}
+	for _, e := range execs {
+		if e.Name == ref {
+			return sc.Get(ctx, e.ID)
+		}
+	}
+	return nil, err
+}
+
+// sessionInfo is a session along with its labels and lifetime, which the API doesn't
+// return.
+type sessionInfo struct {
+	types.Exec
+	Labels   session.Labels    `json:"labels,omitempty"`
+	Lifetime *session.Lifetime `json:"lifetime,omitempty"`
+}
+
+// sessionColumn is a column of the session list that it can be sorted by.
+type sessionColumn struct {
+	Title string
+	value func(e sessionInfo, now time.Time) string
+	// less orders sessions by the column. If it's nil, they are ordered by value.
+	less func(a, b sessionInfo) bool
+}
+
+var sessionColumns = map[string]sessionColumn{
+	"name":     {Title: "Name", value: func(e sessionInfo, _ time.Time) string { return e.Name }},
+	"id":       {Title: "ID", value: func(e sessionInfo, _ time.Time) string { return e.ID }},
+	"status":   {Title: "Status", value: func(e sessionInfo, _ time.Time) string { return string(e.Status) }},
+	"provider": {Title: "Provider", value: func(e sessionInfo, _ time.Time) string { return e.Provider.String() }},
+	"region":   {Title: "Region", value: func(e sessionInfo, _ time.Time) string { return dashIfEmpty(e.Region) }},
+	"instance-type": {Title: "Instance Type", value: func(e sessionInfo, _ time.Time) string {
+		if e.Spec.GPU.Type != "" {
+			return e.Spec.GPU.Type
+		}
+		return e.Spec.CPU.Type
+	}},
+	"gpu-type": {Title: "GPU Type", value: func(e sessionInfo, _ time.Time) string { return dashIfEmpty(e.Spec.GPU.Type) }},
+	"created": {
+		Title: "Created",
+		value: func(e sessionInfo, _ time.Time) string { return e.CreatedAt.Local().Format("2006-01-02 15:04:05") },
+		less:  func(a, b sessionInfo) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	},
+	"age": {
+		Title: "Age",
+		value: func(e sessionInfo, now time.Time) string { return formatAge(now.Sub(e.CreatedAt)) },
+		less:  func(a, b sessionInfo) bool { return a.CreatedAt.After(b.CreatedAt) },
+	},
+	"labels": {Title: "Labels", value: func(e sessionInfo, _ time.Time) string { return dashIfEmpty(e.Labels.String()) }},
+	"remaining": {
+		Title: "Remaining",
+		value: func(e sessionInfo, now time.Time) string {
+			if e.Lifetime == nil || e.Status == types.StatusTerminated || e.Status == types.StatusError {
+				return "-"
+			}
+			remaining, ok := e.Lifetime.Remaining(e.CreatedAt, now)
+			if !ok {
+				return "-"
+			}
+			if remaining == 0 {
+				// The watchdog powered the machine off, but only unweave terminate ends
+				// the session
+				return "expired"
+			}
+			return formatAge(remaining)
+		},
+		// Sessions without a TTL come last
+		less: func(a, b sessionInfo) bool {
+			ra, aok := sessionDeadline(a)
+			rb, bok := sessionDeadline(b)
+			if aok != bok {
+				return aok
+			}
+			return ra.Before(rb)
+		},
+	},
+}
+
+var defaultSessionColumns = []string{"name", "provider", "instance-type", "status", "remaining"}
+
+// sessionDeadline returns when a session reaches its TTL and false if it has none.
+func sessionDeadline(e sessionInfo) (time.Time, bool) {
+	if e.Lifetime == nil || e.Lifetime.TTL <= 0 {
+		return time.Time{}, false
+	}
+	return e.CreatedAt.Add(e.Lifetime.TTL), true
+}
+
+// sessionColumnByName returns the column called name. label:<key> is a column with the
+// value of the label key.
+func sessionColumnByName(name string) (sessionColumn, error) {
+	if key := strings.TrimPrefix(name, "label:"); key != name && key != "" {
+		return sessionColumn{
+			Title: key,
+			value: func(e sessionInfo, _ time.Time) string { return dashIfEmpty(e.Labels[key]) },
+		}, nil
+	}
+	col, ok := sessionColumns[name]
+	if !ok {
+		names := make([]string, 0, len(sessionColumns))
+		for n := range sessionColumns {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return sessionColumn{}, fmt.Errorf("unknown column %q, expected one of %s or label:<key>", name, strings.Join(names, ", "))
+	}
+	return col, nil
+}
+
+type sessionListOptions struct {
+	filter     session.Filter
+	columns    []sessionColumn
+	sortBy     sessionColumn
+	descending bool
+}
+
+func parseSessionListOptions() (sessionListOptions, error) {
+	opts := sessionListOptions{}
+
+	for _, s := range config.ListLabelSelectors {
+		sel, err := session.ParseLabelSelector(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.filter.Labels = append(opts.filter.Labels, sel)
+	}
+	for _, s := range config.ListStatuses {
+		opts.filter.Statuses = append(opts.filter.Statuses, types.Status(s))
+	}
+	for _, p := range config.ListProviders {
+		opts.filter.Providers = append(opts.filter.Providers, types.Provider(p))
+	}
+	opts.filter.GPUTypes = config.ListGPUTypes
+
+	var err error
+	if config.ListNewerThan != "" {
+		if opts.filter.NewerThan, err = session.ParseAge(config.ListNewerThan); err != nil {
+			return opts, err
+		}
+	}
+	if config.ListOlderThan != "" {
+		if opts.filter.OlderThan, err = session.ParseAge(config.ListOlderThan); err != nil {
+			return opts, err
+		}
+	}
+
+	columns := config.ListColumns
+	if len(columns) == 0 {
+		columns = defaultSessionColumns
+	}
+	for _, name := range columns {
+		col, err := sessionColumnByName(strings.TrimSpace(name))
+		if err != nil {
+			return opts, err
+		}
+		opts.columns = append(opts.columns, col)
+	}
+
+	sortBy := config.ListSort
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	if strings.HasPrefix(sortBy, "-") {
+		opts.descending = true
+		sortBy = sortBy[1:]
+	}
+	if opts.sortBy, err = sessionColumnByName(sortBy); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// SessionList handles the Cobra command for listing the sessions of the project
 func SessionList(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
+	ctx := cmd.Context()
 
-	uwc := config.InitUnweaveClient()
-	listTerminated := config.All
+	opts, err := parseSessionListOptions()
+	if err != nil {
+		ui.Errorf("%s", err)
+		os.Exit(1)
+	}
+
+	sc, err := session.FromConfig()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	if config.Watch {
+		if ui.OutputJSON {
+			ui.Errorf("--watch can't be used with --json")
+			os.Exit(1)
+		}
+		return watchSessionList(ctx, sc, opts)
+	}
 
-	owner, projectName := config.GetProjectOwnerAndName()
-	sessions, err := uwc.Exec.List(cmd.Context(), owner, projectName, listTerminated)
+	sessions, err := listSessions(ctx, sc, opts)
 	if err != nil {
 		var e *types.Error
 		if errors.As(err, &e) {
@@ -206,81 +677,204 @@ func SessionList(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	renderSessionListWithSessions(sessions)
-
+	if ui.OutputJSON {
+		ui.JSON(sessions)
+		return nil
+	}
+	if len(sessions) == 0 {
+		ui.Infof("No matching sessions")
+	}
+	fmt.Print(formatSessionList(sessions, opts, time.Now()))
 	return nil
 }
 
-func renderSessionListWithSessions(sessions []types.Exec) {
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].Name < sessions[j].Name
-	})
+// listSessions returns the sessions of the project that match the filter, with their
+// labels, sorted as requested.
+func listSessions(ctx context.Context, sc *session.Client, opts sessionListOptions) ([]sessionInfo, error) {
+	execs, err := sc.List(ctx, config.All || opts.filter.IncludesTerminated())
+	if err != nil {
+		return nil, err
+	}
 
-	if config.OutputJSON {
-		if sessions == nil {
-			sessions = []types.Exec{}
+	labels, lifetimes := loadSessionMetadata()
+
+	now := time.Now()
+	sessions := make([]sessionInfo, 0, len(execs))
+	for _, e := range execs {
+		if !opts.filter.Matches(e, labels[e.ID], now) {
+			continue
 		}
-		ui.JSON(sessions)
-		return
+		info := sessionInfo{Exec: e, Labels: labels[e.ID]}
+		if l, ok := lifetimes[e.ID]; ok {
+			info.Lifetime = &l
+		}
+		sessions = append(sessions, info)
 	}
 
-	if len(sessions) == 0 {
-		ui.Infof("No active sessions")
+	less := opts.sortBy.less
+	if less == nil {
+		less = func(a, b sessionInfo) bool { return opts.sortBy.value(a, now) < opts.sortBy.value(b, now) }
 	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if opts.descending {
+			return less(sessions[j], sessions[i])
+		}
+		return less(sessions[i], sessions[j])
+	})
+	return sessions, nil
+}
 
-	// EITHER min length of title + 5 for padding OR the max field length + 5 for padding
-	cols := []ui.Column{
-		{
-			Title: "Name",
-			Width: 3 + ui.MaxFieldLength(sessions, func(exec types.Exec) string {
-				return exec.Name
-			}),
-		},
-		{
-			Title: "Provider",
-			Width: 5 + ui.MaxFieldLength(sessions, func(exec types.Exec) string {
-				return exec.Provider.String()
-			}),
-		},
-		{
-			Title: "Instance Type",
-			Width: 7 + ui.MaxFieldLength(sessions, func(exec types.Exec) string {
-				return exec.Spec.GPU.Type
-			}),
-		},
-		{
-			Title: "Status",
-			Width: 5 + ui.MaxFieldLength(sessions, func(exec types.Exec) string {
-				return string(exec.Status)
-			}),
-		},
+func formatSessionList(sessions []sessionInfo, opts sessionListOptions, now time.Time) string {
+	cols := make([]ui.Column, len(opts.columns))
+	for i, c := range opts.columns {
+		cols[i] = ui.Column{Title: c.Title, Width: -1}
 	}
-
 	rows := make([]ui.Row, len(sessions))
-
 	for idx, s := range sessions {
-		instanceType := s.Spec.GPU.Type
-		if instanceType == "" {
-			instanceType = s.Spec.CPU.Type
+		row := make(ui.Row, len(opts.columns))
+		for i, c := range opts.columns {
+			row[i] = c.value(s, now)
 		}
+		rows[idx] = row
+	}
+	return ui.FormatTable("Sessions", cols, rows)
+}
+
+// watchSessionList redraws the session list whenever it changes until interrupted.
+func watchSessionList(ctx context.Context, sc *session.Client, opts sessionListOptions) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(config.WatchInterval)
+	defer ticker.Stop()
 
-		row := ui.Row{
-			fmt.Sprintf("%s", s.Name),
-			fmt.Sprintf("%s", s.Provider),
-			fmt.Sprintf("%s", instanceType),
-			fmt.Sprintf("%s", s.Status),
+	last := ""
+	for {
+		sessions, err := listSessions(ctx, sc, opts)
+		if err != nil && ctx.Err() == nil {
+			// Keep showing the last list, the next refresh may work
+			ui.Debugf("Failed to list sessions: %s", err)
 		}
-		rows[idx] = row
+		if err == nil {
+			out := formatSessionList(sessions, opts, time.Now())
+			if out != last {
+				// Clear the screen and move the cursor to the top left
+				fmt.Print("\033[H\033[2J")
+				fmt.Print(out)
+				fmt.Printf("\nEvery %s, press Ctrl+C to stop\n", config.WatchInterval)
+				last = out
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func sessionLabelStore() (*session.LabelStore, error) {
+	dir, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return session.NewLabelStore(filepath.Join(dir, "labels.json")), nil
+}
+
+func sessionUsageStore() (*session.UsageStore, error) {
+	dir, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return session.NewUsageStore(filepath.Join(dir, "usage.json")), nil
+}
+
+func saveSessionLabels(sessionID string, labels session.Labels) error {
+	store, err := sessionLabelStore()
+	if err != nil {
+		return err
+	}
+	return store.Set(sessionID, labels)
+}
+
+func sessionLifetimeStore() (*session.LifetimeStore, error) {
+	dir, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return session.NewLifetimeStore(filepath.Join(dir, "lifetimes.json")), nil
+}
+
+func saveSessionLifetime(sessionID string, lifetime session.Lifetime) error {
+	store, err := sessionLifetimeStore()
+	if err != nil {
+		return err
+	}
+	return store.Set(sessionID, lifetime)
+}
+
+// loadSessionMetadata returns the labels and lifetimes of all sessions by session ID. A
+// store that can't be read is skipped.
+func loadSessionMetadata() (map[string]session.Labels, map[string]session.Lifetime) {
+	var labels map[string]session.Labels
+	var lifetimes map[string]session.Lifetime
+
+	labelStore, err := sessionLabelStore()
+	if err == nil {
+		labels, err = labelStore.All()
+	}
+	if err != nil {
+		ui.Debugf("Failed to read session labels: %s", err)
 	}
+	lifetimeStore, err := sessionLifetimeStore()
+	if err == nil {
+		lifetimes, err = lifetimeStore.All()
+	}
+	if err != nil {
+		ui.Debugf("Failed to read session lifetimes: %s", err)
+	}
+	return labels, lifetimes
+}
+
+func getSessionInfo(exec types.Exec) sessionInfo {
+	labels, lifetimes := loadSessionMetadata()
+	info := sessionInfo{Exec: exec, Labels: labels[exec.ID]}
+	if l, ok := lifetimes[exec.ID]; ok {
+		info.Lifetime = &l
+	}
+	return info
+}
+
+// formatAge formats the time since a session was created in its largest unit, e.g. 3h.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
 
-	ui.Table("Sessions", cols, rows)
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
 
 func sessionTerminate(ctx context.Context, execID string) error {
 	uwc := config.InitUnweaveClient()
-	owner, projectName := config.GetProjectOwnerAndName()
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
-	err := uwc.Exec.Terminate(ctx, owner, projectName, execID)
+	err = uwc.Exec.Terminate(ctx, owner, projectName, execID)
 	if err != nil {
 		var e *types.Error
 		if errors.As(err, &e) {
@@ -290,12 +884,19 @@ func sessionTerminate(ctx context.Context, execID string) error {
 		}
 		return err
 	}
+	recordSessionsEnded(execID)
 	return nil
 }
 
+// SessionTerminate handles the Cobra command for terminating sessions. Without
+// arguments or selectors, the session is picked interactively.
 func SessionTerminate(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
+	if len(args) > 1 || len(config.TerminateSelectors) > 0 || config.TerminateAllIdle || config.TerminateOlderThan != "" {
+		return sessionTerminateMany(cmd.Context(), args)
+	}
+
 	var execID string
 
 	if len(args) == 1 {
@@ -329,9 +930,11 @@ func SessionTerminate(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	confirm := ui.Confirm(fmt.Sprintf("Are you sure you want to terminate session %q", execID), "n")
-	if !confirm {
-		return nil
+	if !config.Yes {
+		confirm := ui.Confirm(fmt.Sprintf("Are you sure you want to terminate session %q", execID), "n")
+		if !confirm {
+			return nil
+		}
 	}
 
 	if err := sessionTerminate(cmd.Context(), execID); err != nil {
@@ -343,6 +946,184 @@ func SessionTerminate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// sessionTerminateMany terminates the sessions named in args or matching the selector
+// flags after a single confirmation.
+func sessionTerminateMany(ctx context.Context, args []string) error {
+	sc, err := session.FromConfig()
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+
+	execs, err := selectSessionsToTerminate(ctx, sc, args)
+	if err != nil {
+		ui.HandleError(err)
+		os.Exit(1)
+	}
+	if len(execs) == 0 {
+		if ui.OutputJSON {
+			ui.JSON([]terminateResult{})
+			return nil
+		}
+		ui.Infof("No sessions match")
+		return nil
+	}
+
+	if !ui.OutputJSON {
+		opts := sessionListOptions{}
+		for _, name := range []string{"name", "id", "status", "age", "labels"} {
+			col, _ := sessionColumnByName(name)
+			opts.columns = append(opts.columns, col)
+		}
+		fmt.Print(formatSessionList(execs, opts, time.Now()))
+	}
+
+	if !config.Yes {
+		if !ui.Confirm(fmt.Sprintf("Terminate %d sessions", len(execs)), "n") {
+			return nil
+		}
+	}
+
+	toTerminate := make([]types.Exec, len(execs))
+	for i, e := range execs {
+		toTerminate[i] = e.Exec
+	}
+	results := sc.TerminateAll(ctx, toTerminate, config.Parallelism, func(r session.Result) {
+		if ui.OutputJSON {
+			return
+		}
+		if r.Err != nil {
+			ui.Errorf("✗ %s: %s", r.Exec.Name, r.Err)
+			return
+		}
+		ui.Successf("✓ %s terminated", r.Exec.Name)
+	})
+
+	failed := 0
+	out := make([]terminateResult, len(results))
+	var terminated []string
+	for i, r := range results {
+		out[i] = terminateResult{ID: r.Exec.ID, Name: r.Exec.Name}
+		if r.Err != nil {
+			out[i].Error = r.Err.Error()
+			failed++
+			continue
+		}
+		terminated = append(terminated, r.Exec.ID)
+	}
+	recordSessionsEnded(terminated...)
+	ui.JSON(out)
+
+	if failed > 0 {
+		if !ui.OutputJSON {
+			ui.Errorf("Failed to terminate %d of %d sessions", failed, len(results))
+		}
+		os.Exit(1)
+	}
+	return nil
+}
+
+type terminateResult struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// selectSessionsToTerminate returns the active sessions named in args, or else the ones
+// matching the selector flags.
+func selectSessionsToTerminate(ctx context.Context, sc *session.Client, args []string) ([]sessionInfo, error) {
+	if len(args) > 0 && (len(config.TerminateSelectors) > 0 || config.TerminateAllIdle || config.TerminateOlderThan != "") {
+		return nil, errors.New("sessions can either be named or selected with flags, not both")
+	}
+
+	filter := session.Filter{}
+	for _, s := range config.TerminateSelectors {
+		sel, err := session.ParseLabelSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		filter.Labels = append(filter.Labels, sel)
+	}
+	if config.TerminateOlderThan != "" {
+		age, err := session.ParseAge(config.TerminateOlderThan)
+		if err != nil {
+			return nil, err
+		}
+		filter.OlderThan = age
+	}
+
+	all, err := listSessions(ctx, sc, sessionListOptions{filter: filter, sortBy: sessionColumns["name"]})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		var execs []sessionInfo
+		for _, ref := range args {
+			found := false
+			for _, e := range all {
+				if e.ID == ref || e.Name == ref {
+					execs = append(execs, e)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no active session called %q", ref)
+			}
+		}
+		return execs, nil
+	}
+
+	if config.TerminateAllIdle {
+		return idleSessions(ctx, all), nil
+	}
+	return all, nil
+}
+
+// idleSessions returns the running sessions that nobody is connected to and that run
+// nothing. Sessions whose activity can't be inspected are left out.
+func idleSessions(ctx context.Context, execs []sessionInfo) []sessionInfo {
+	idle := make([]bool, len(execs))
+	errs := make([]error, len(execs))
+
+	session.ForEach(len(execs), config.Parallelism, func(i int) {
+		if execs[i].Status != types.StatusRunning {
+			return
+		}
+		activity, err := inspectSessionActivity(ctx, execs[i].Exec)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		idle[i] = activity.Idle()
+	})
+
+	var res []sessionInfo
+	for i, e := range execs {
+		if errs[i] != nil {
+			ui.Attentionf("Skipping %s, failed to check if it's idle: %s", e.Name, errs[i])
+		}
+		if idle[i] {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func inspectSessionActivity(ctx context.Context, e types.Exec) (session.Activity, error) {
+	prvKey, err := getDefaultKey(ctx, e, config.SSHPrivateKeyPath)
+	if err != nil {
+		return session.Activity{}, fmt.Errorf("failed to get private key: %w", err)
+	}
+	t, err := ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		return session.Activity{}, err
+	}
+	defer t.Close()
+	return session.InspectActivity(ctx, t)
+}
+
 // sessionSelectSSHExecRef selects an exec id from all sessions in the Unweave environment or whether to create a new
 // provides an option to create a new Exec an error or exits if unrecoverable
 func sessionSelectSSHExecRef(ctx context.Context, execRef string, allowNew bool) (execID string, isNewSession bool, err error) {
@@ -362,9 +1143,9 @@ func sessionSelectSSHExecRef(ctx context.Context, execRef string, allowNew bool)
 	var selectionIdByIdx = make(map[int]string, len(execs))
 
 	if allowNew {
-		cobraOpts, selectionIdByIdx = formatExecCobraOpts(execs)
-	} else {
 		cobraOpts, selectionIdByIdx = formatExecCobraOpts(execs, newSessionOpt)
+	} else {
+		cobraOpts, selectionIdByIdx = formatExecCobraOpts(execs)
 	}
 
 	execRef, err = renderCobraSelection(ctx, cobraOpts, selectionIdByIdx, "Select a session to connect to")
@@ -396,31 +1177,13 @@ func formatExecCobraOpts(execs []types.Exec, prepend ...string) ([]string, map[i
 }
 
 func parseHardwareSpec() (types.HardwareSpec, error) {
-	specName := "default"
-	if config.SpecName != "" {
-		specName = config.SpecName
-	}
-
-	spec, found := findSpec(specName, config.Config.Project.Specs)
-	if !found {
-		return types.HardwareSpec{}, &types.Error{
-			Message:    fmt.Sprintf("cannot find spec with name %q", specName),
-			Suggestion: fmt.Sprintf("ensure a spec with name %q exists in .unweave/config.toml", specName),
-		}
+	spec, err := selectedSpec()
+	if err != nil {
+		return types.HardwareSpec{}, err
 	}
-
-	baseHardwaveSpec := types.HardwareSpec{
-		GPU: types.GPU{
-			Type:  spec.GPU.Type,
-			Count: types.HardwareRequestRange{Min: spec.GPU.Count, Max: spec.GPU.Count},
-			RAM:   types.HardwareRequestRange{Min: spec.GPU.Memory, Max: spec.GPU.Memory},
-		},
-		CPU: types.CPU{
-			Type:                 spec.CPU.Type,
-			HardwareRequestRange: types.HardwareRequestRange{Min: spec.CPU.Count, Max: spec.CPU.Count},
-		},
-		RAM: types.HardwareRequestRange{Min: spec.CPU.Memory, Max: spec.CPU.Memory},
-		HDD: types.HardwareRequestRange{Min: spec.HDD.Size, Max: spec.HDD.Size},
+	baseHardwaveSpec, err := spec.HardwareSpec()
+	if err != nil {
+		return types.HardwareSpec{}, err
 	}
 
 	setNotEmptyValue(config.GPUType, &(baseHardwaveSpec.GPU.Type))
@@ -438,14 +1201,26 @@ func parseHardwareSpec() (types.HardwareSpec, error) {
 	return baseHardwaveSpec, nil
 }
 
-func findSpec(name string, specs []config.Spec) (config.Spec, bool) {
-	for _, s := range specs {
-		if strings.EqualFold(s.Name, name) {
-			return s, true
-		}
+// selectedSpec returns the spec chosen with --spec, or the default spec, with the specs
+// it extends applied.
+func selectedSpec() (config.Spec, error) {
+	specName := "default"
+	if config.SpecName != "" {
+		specName = config.SpecName
 	}
+	return resolveSpec(specName)
+}
 
-	return config.Spec{}, false
+// resolveSpec returns the spec called specName with the specs it extends applied.
+func resolveSpec(specName string) (config.Spec, error) {
+	spec, err := config.ResolveSpec(specName, config.Config.Project.Specs)
+	if errors.Is(err, config.ErrSpecNotFound) {
+		return config.Spec{}, &types.Error{
+			Message:    fmt.Sprintf("cannot find spec with name %q", specName),
+			Suggestion: fmt.Sprintf("ensure a spec with name %q exists in .unweave/config.toml", specName),
+		}
+	}
+	return spec, err
 }
 
 func setNotEmptyValue[T comparable](val T, dst *T) {
@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/client/clientfakes"
 	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/session"
 	"github.com/unweave/unweave/api/types"
 )
 
@@ -22,7 +29,7 @@ type = "x86_64"
 `)
 		f.Sync()
 
-		secrets, project := config.InitProjectConfigFrom(f.Name(), "")
+		secrets, project, _ := config.InitProjectConfigFrom(f.Name(), "")
 		config.Config.Project = project
 		config.Config.Project.Env = secrets
 
@@ -63,7 +70,7 @@ size = 10
 `)
 		f.Sync()
 
-		secrets, project := config.InitProjectConfigFrom(f.Name(), "")
+		secrets, project, _ := config.InitProjectConfigFrom(f.Name(), "")
 		config.Config.Project = project
 		config.Config.Project.Env = secrets
 
@@ -96,3 +103,77 @@ size = 10
 func hwRange(min, max int) types.HardwareRequestRange {
 	return types.HardwareRequestRange{Min: min, Max: max}
 }
+
+func TestFormatHardwareRange(t *testing.T) {
+	tests := []struct {
+		name string
+		r    types.HardwareRequestRange
+		want string
+	}{
+		{"unset", hwRange(0, 0), "-"},
+		{"exact", hwRange(24, 24), "24GB"},
+		{"range", hwRange(16, 32), "16-32GB"},
+		{"open-ended", hwRange(24, 0), ">=24GB"},
+		{"maximum only", hwRange(0, 32), "<=32GB"},
+		{"maximum below minimum", hwRange(24, 16), "24GB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatHardwareRange(tt.r, "GB"))
+		})
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	ctx := context.Background()
+	notFound := fmt.Errorf("session: %w", client.ErrNotFound)
+
+	setup := func() (*session.Client, *clientfakes.FakeExecer) {
+		execer := new(clientfakes.FakeExecer)
+		return session.NewClient(&client.Client{Exec: execer}, "owner", "project"), execer
+	}
+
+	t.Run("should get a session by ID", func(t *testing.T) {
+		sc, execer := setup()
+		execer.GetReturns(&types.Exec{ID: "sess-1", Name: "train"}, nil)
+
+		e, err := getSession(ctx, sc, "sess-1")
+		require.NoError(t, err)
+		assert.Equal(t, "sess-1", e.ID)
+		assert.Equal(t, 0, execer.ListCallCount())
+	})
+
+	t.Run("should fall back to looking it up by name", func(t *testing.T) {
+		sc, execer := setup()
+		execer.GetReturnsOnCall(0, nil, notFound)
+		execer.GetReturnsOnCall(1, &types.Exec{ID: "sess-2", Name: "train"}, nil)
+		execer.ListReturns([]types.Exec{{ID: "sess-1", Name: "eval"}, {ID: "sess-2", Name: "train"}}, nil)
+
+		e, err := getSession(ctx, sc, "train")
+		require.NoError(t, err)
+		assert.Equal(t, "sess-2", e.ID)
+
+		_, _, _, listTerminated := execer.ListArgsForCall(0)
+		assert.True(t, listTerminated, "terminated sessions can be looked up too")
+		_, _, _, id := execer.GetArgsForCall(1)
+		assert.Equal(t, "sess-2", id)
+	})
+
+	t.Run("should return the not found error if no session has the name", func(t *testing.T) {
+		sc, execer := setup()
+		execer.GetReturns(nil, notFound)
+		execer.ListReturns([]types.Exec{{ID: "sess-1", Name: "eval"}}, nil)
+
+		_, err := getSession(ctx, sc, "train")
+		assert.ErrorIs(t, err, client.ErrNotFound)
+	})
+
+	t.Run("should not fall back for other errors", func(t *testing.T) {
+		sc, execer := setup()
+		execer.GetReturns(nil, errors.New("connection refused"))
+
+		_, err := getSession(ctx, sc, "train")
+		assert.EqualError(t, err, "connection refused")
+		assert.Equal(t, 0, execer.ListCallCount())
+	})
+}
@@ -1,16 +1,12 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
@@ -50,163 +46,150 @@ type execCmdArgs struct {
 	// skipCopy indicates that no directories
 	// should be copied to the remote on startup
 	skipCopy bool
+
+	// sync keeps the project directory in sync
+	// with the remote while connected.
+	sync bool
 }
 
 type sshConnectionCommandFlow interface {
 	parseArgs(cmd *cobra.Command, args []string) execCmdArgs
-	getExec(cmd *cobra.Command, command execCmdArgs) (chan types.Exec, bool, chan error)
-	onSshCommandFinish(ctx context.Context, execID string) error
+	getExec(cmd *cobra.Command, command execCmdArgs) (<-chan session.Event, bool, error)
+	onSshCommandFinish(ctx context.Context, e types.Exec, prvKey string) error
 }
 
 func runSSHConnectionCommand(cmd *cobra.Command, args []string, flow sshConnectionCommandFlow) error {
 	commandArgs := flow.parseArgs(cmd, args)
 
 	prvKey := config.SSHPrivateKeyPath
-	execCh, isNew, errCh := flow.getExec(cmd, commandArgs)
+	events, isNew, err := flow.getExec(cmd, commandArgs)
+	if err != nil {
+		handleWaitError(err)
+		return nil
+	}
 	ctx := cmd.Context()
 
-	for {
-		select {
-		case e := <-execCh:
-			if e.Status == types.StatusRunning {
-				defer cleanupHosts(e)
-				prvKey, err := getDefaultKey(ctx, e, prvKey)
-				if prvKey == "" {
-					ui.Errorf("Expected private key to be none empty string")
-					os.Exit(1)
-				}
-				if err != nil {
-					ui.Errorf("Failed to get private key: %s", err)
-					os.Exit(1)
-				}
-
-				ensureHosts(e, prvKey)
-
-				shouldCopySource := !config.NoCopySource && !commandArgs.skipCopy
-
-				if err = handleCopySourceDir(shouldCopySource, isNew, e, prvKey, commandArgs.copyDir); err != nil {
-					ui.HandleError(err)
-					os.Exit(1)
-				}
-
-				if err := ssh.Connect(ctx, e.Network, prvKey, commandArgs.sshConnectionOptions, commandArgs.execCommand); err != nil {
-					ui.Errorf("%s", err)
-					os.Exit(1)
-				}
-
-				if err := flow.onSshCommandFinish(ctx, e.ID); err != nil {
-					return err
-				}
-
-				return nil
+	for event := range events {
+		if event.Err != nil {
+			handleWaitError(event.Err)
+			return nil
+		}
+		renderWaitEvent(event)
+
+		if e := event.Exec; e.Status == types.StatusRunning {
+			defer cleanupHosts(e)
+			prvKey, err := getDefaultKey(ctx, e, prvKey)
+			if prvKey == "" {
+				ui.Errorf("Expected private key to be none empty string")
+				os.Exit(1)
+			}
+			if err != nil {
+				ui.Errorf("Failed to get private key: %s", err)
+				os.Exit(1)
 			}
 
-		case err := <-errCh:
-			var e *types.Error
-			if errors.As(err, &e) {
-				uie := &ui.Error{Error: e}
-				fmt.Println(uie.Verbose())
+			ensureHosts(e, prvKey)
+
+			shouldCopySource := !config.NoCopySource && !commandArgs.skipCopy
+
+			if err = handleCopySourceDir(ctx, shouldCopySource, isNew, e, prvKey, commandArgs.copyDir); err != nil {
+				ui.HandleError(err)
 				os.Exit(1)
 			}
-			return err
+			if isNew {
+				setupWatchdog(ctx, e, prvKey)
+			}
+			setupEnv(ctx, e, prvKey, isNew)
+
+			stopSync := func() {}
+			if commandArgs.sync {
+				stopSync = startSync(ctx, e, prvKey)
+			}
+
+			err = connect(ctx, e.Network, prvKey, commandArgs.sshConnectionOptions, commandArgs.execCommand)
+			stopSync()
+			if err != nil {
+				ui.Errorf("%s", err)
+				os.Exit(1)
+			}
+
+			if err := flow.onSshCommandFinish(ctx, e, prvKey); err != nil {
+				return err
+			}
 
-		case <-ctx.Done():
 			return nil
 		}
 	}
+	return nil
 }
 
 type sshCommandFlow struct{}
 
 func (s *sshCommandFlow) parseArgs(cmd *cobra.Command, args []string) execCmdArgs {
-	command := execCmdArgs{}
+	command := execCmdArgs{sync: config.Config.Project.Sessions.Sync}
 
-	if len(args) == 1 {
-		command.execRef = args[0]
+	// The arguments after -- are passed to ssh, and only the session may come before it
+	argsBeforeDoubleDash := args
+	if doubleDashIdx := cmd.ArgsLenAtDash(); doubleDashIdx >= 0 {
+		argsBeforeDoubleDash = args[:doubleDashIdx]
+		command.sshConnectionOptions = args[doubleDashIdx:]
 	}
 
-	// If the number of args is great than one, we always expect the first arg to be
-	// the separator flag "--". If the number of args is one, we expect it to be the
-	// execID or name
-	if len(args) > 1 {
-		command.execRef = args[0]
-		command.sshConnectionOptions = args[1:]
-
-		if command.sshConnectionOptions[0] != "--" {
-			const errMsg = "❌ Invalid arguments. If you want to pass arguments to the ssh command, " +
-				"use the -- flag. See `unweave ssh --help` for more information"
-			ui.Errorf(errMsg)
-			os.Exit(1)
-		}
+	if len(argsBeforeDoubleDash) > 1 {
+		const errMsg = "❌ Invalid arguments. If you want to pass arguments to the ssh command, " +
+			"use the -- flag. See `unweave ssh --help` for more information"
+		ui.Errorf(errMsg)
+		os.Exit(1)
+	}
+	if len(argsBeforeDoubleDash) == 1 {
+		command.execRef = argsBeforeDoubleDash[0]
 	}
 
 	return command
 }
 
-func (s *sshCommandFlow) getExec(cmd *cobra.Command, command execCmdArgs) (chan types.Exec, bool, chan error) {
+func (s *sshCommandFlow) getExec(cmd *cobra.Command, command execCmdArgs) (<-chan session.Event, bool, error) {
 	return getOrCreateExec(cmd, command.execRef)
 }
 
-func (s *sshCommandFlow) onSshCommandFinish(ctx context.Context, execID string) error {
+func (s *sshCommandFlow) onSshCommandFinish(ctx context.Context, e types.Exec, prvKey string) error {
 	if terminate := ui.Confirm("SSH session terminated. Do you want to terminate the session?", "n"); terminate {
-		if err := sessionTerminate(ctx, execID); err != nil {
+		if err := sessionTerminate(ctx, e.ID); err != nil {
 			return err
 		}
-		ui.Infof("Session %q terminated.", execID)
+		ui.Infof("Session %q terminated.", e.ID)
 	}
 
 	return nil
 }
 
 // getOrCreateExec handles the flow to spawn a new Exec or get an existing one, returns whether to expect a new Exec
-func getOrCreateExec(cmd *cobra.Command, execRef string) (chan types.Exec, bool, chan error) {
+func getOrCreateExec(cmd *cobra.Command, execRef string) (<-chan session.Event, bool, error) {
 	cmd.SilenceUsage = true
 	ctx := cmd.Context()
 
-	execCh := make(chan types.Exec)
-	errCh := make(chan error)
-	isNew := false
-	var err error
-
-	watchCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
 	if config.CreateExec {
 		ui.Infof("Initializing node...")
+		return execCreateAndWatch(ctx, types.ExecConfig{}, types.GitConfig{}, mustResolveLifetime()), true, nil
+	}
 
-		_, errCh, err := execCreateAndWatch(watchCtx, types.ExecConfig{}, types.GitConfig{})
+	var createNewExec bool
+	if execRef == "" {
+		var err error
+		execRef, createNewExec, err = sessionSelectSSHExecRef(ctx, execRef, true)
 		if err != nil {
-			errCh <- err
-			return nil, false, errCh
-		}
-		isNew = true
-	} else {
-		var createNewExec bool
-		if execRef == "" {
-			execRef, createNewExec, err = sessionSelectSSHExecRef(ctx, execRef, false)
-			if err != nil {
-				errCh <- err
-				return nil, false, errCh
-			}
-		}
-
-		if createNewExec {
-			execCh, errCh, err = execCreateAndWatch(ctx, types.ExecConfig{}, types.GitConfig{})
-			if err != nil {
-				errCh <- err
-				return nil, false, errCh
-			}
-			isNew = true
-		} else {
-			execCh, errCh, err = session.Wait(ctx, execRef)
-			if err != nil {
-				errCh <- err
-				return nil, false, errCh
-			}
+			return nil, false, err
 		}
 	}
 
-	return execCh, isNew, nil
+	if createNewExec {
+		return execCreateAndWatch(ctx, types.ExecConfig{}, types.GitConfig{}, mustResolveLifetime()), true, nil
+	}
+	events, err := watchSession(ctx, execRef)
+	if err != nil {
+		return nil, false, err
+	}
+	return events, false, nil
 }
 
 func cleanupHosts(e types.Exec) {
@@ -239,7 +222,7 @@ func ensureHosts(e types.Exec, identityFile string) {
 	}
 }
 
-func handleCopySourceDir(shouldCopy, isNew bool, e types.Exec, privKey, copyPath string) error {
+func handleCopySourceDir(ctx context.Context, shouldCopy, isNew bool, e types.Exec, privKey, copyPath string) error {
 	// TODO: Wait until port is open before cleaning up the source code
 
 	if shouldCopy && isNew {
@@ -248,12 +231,11 @@ func handleCopySourceDir(shouldCopy, isNew bool, e types.Exec, privKey, copyPath
 		if copyPath == "" {
 			copyPath, err = config.GetActiveProjectPath()
 			if err != nil {
-				ui.Errorf("Failed to get active project path. Skipping copying source directory")
 				return fmt.Errorf("failed to get active project path: %v", err)
 			}
 		}
 
-		if err := copyDirFromLocalAndUnzip(e.ID, copyPath, config.ProjectHostDir(), e.Network, privKey); err != nil {
+		if err := copyDirFromLocalAndUnzip(ctx, e.ID, copyPath, config.ProjectHostDir(), e.Network, privKey); err != nil {
 			return err
 		}
 
@@ -264,120 +246,43 @@ func handleCopySourceDir(shouldCopy, isNew bool, e types.Exec, privKey, copyPath
 	return nil
 }
 
-func copyDirFromLocalAndUnzip(execID, rootDir, dstPath string, connectionInfo types.ExecNetwork, privKeyPath string) error {
+func copyDirFromLocalAndUnzip(ctx context.Context, execID, rootDir, dstPath string, connectionInfo types.ExecNetwork, privKeyPath string) error {
 	ui.Infof("🧳 Gathering context from %q", rootDir)
 
 	tmpFile, err := createTempContextFile(execID)
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmpFile.Name())
+
 	if err := gatherContext(rootDir, tmpFile, "tar"); err != nil {
 		return fmt.Errorf("failed to gather context: %v", err)
 	}
+	tmpFile.Close()
 
 	tmpDstPath := filepath.Join("/tmp", fmt.Sprintf("uw-context-%s.tar.gz", execID))
 
 	ui.Infof("🔄 Copying source to %q", dstPath)
 
-	// target to copy to i.e. /home/user/Desktop/ user@your.server.example.com:/path/to/foo
-	remoteTarget := fmt.Sprintf("%s@%s:%s", connectionInfo.User, connectionInfo.Host, tmpDstPath)
-	if err := copySourceSCP(tmpFile.Name(), remoteTarget, privKeyPath); err != nil {
-		return fmt.Errorf("failed to copy source: %w", err)
-	}
-
-	if err := copySourceUnTar(tmpDstPath, dstPath, connectionInfo, privKeyPath); err != nil {
-		return fmt.Errorf("failed to extract source: %w", err)
-	}
-
-	ui.Infof("✅  Successfully copied source directory to remote host")
-
-	return nil
-}
-
-func copyDirFromRemoteAndUnzip(sshTarget, localDirectory, privateKey string) error {
-	ui.Infof("🧳 Gathering context from %q", sshTarget)
-
-	remotePath, err := tarRemoteDirectory(sshTarget, privateKey)
+	t, err := ssh.Dial(ctx, ssh.Options{Network: connectionInfo, PrivateKeyPath: privKeyPath})
 	if err != nil {
-		return fmt.Errorf("Failed to zip the remote directory. Expected both a remote target and directory in %s", sshTarget)
+		return fmt.Errorf("failed to connect to session: %w", err)
 	}
+	defer t.Close()
 
-	ui.Infof("📦 Copying the archive of the remote path to the host...")
-
-	sshTargetAndDir := strings.Split(sshTarget, ":")
-	if len(sshTargetAndDir) != 2 {
-		return fmt.Errorf("Expected target to be in the format 'user@host:directory'")
+	if err := t.Upload(ctx, tmpFile.Name(), tmpDstPath); err != nil {
+		return fmt.Errorf("failed to copy source: %w", err)
 	}
-	sshTargetDirectory := sshTargetAndDir[0] + ":" + remotePath
-	remoteFilename := filepath.Base(remotePath)
-	archiveLocalTargetDir := config.GetGlobalConfigPath()
-	archiveLocalTarget := filepath.Join(archiveLocalTargetDir, remoteFilename)
 
-	err = copySourceSCP(sshTargetDirectory, config.GetGlobalConfigPath(), privateKey)
-	if err != nil {
-		return fmt.Errorf("Failed to copy the archive of your remote path to the host. "+
-			"Please check if %s exists on the remote and ensure Unweave has the necessary permissions to access %s",
-			sshTargetDirectory, archiveLocalTargetDir)
+	if err := copySourceUnTar(ctx, t, tmpDstPath, dstPath); err != nil {
+		return fmt.Errorf("failed to extract source: %w", err)
 	}
 
-	ui.Infof("🗜️ Unzipping the copied archive to the local directory...")
-
-	cmd := exec.Command("tar", "-xf", archiveLocalTarget, "-C", localDirectory, "--strip-components=1")
-
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("Failed to unzip the copied archive of your code from %s to %s. "+
-			"Please ensure that Unweave has the necessary permissions to access %s and perform this operation manually",
-			archiveLocalTarget, localDirectory, archiveLocalTarget)
-	}
+	ui.Infof("✅  Successfully copied source directory to remote host")
 
 	return nil
 }
 
-// tarRemoteDirectory takes an ssh target, and zips up the contents of that target to a returned in the remote /tmp
-func tarRemoteDirectory(sshTarget, privateKeyPath string) (remoteArchiveLoc string, err error) {
-	sshTargetAndDir := strings.Split(sshTarget, ":")
-	if len(sshTargetAndDir) != 2 {
-		return "", fmt.Errorf("Failed to zip remote directory, expected both a remote target and directory in %s", sshTarget)
-	}
-
-	timestamp := time.Now().Unix()
-	remoteArchiveLoc = fmt.Sprintf("/tmp/uw-context-%d.tar.gz", timestamp)
-	tarCmd := fmt.Sprintf("tar -czf %s -C %s .", remoteArchiveLoc, sshTargetAndDir[1])
-
-	sshCommand := exec.Command(
-		"ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-i", privateKeyPath,
-		sshTargetAndDir[0],
-		tarCmd,
-	)
-
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-
-	sshCommand.Stdout = stdout
-	sshCommand.Stderr = stderr
-
-	if err := sshCommand.Run(); err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Exited with non-zero exit code
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 255 {
-					ui.Infof("The remote host closed the connection.")
-					return "", fmt.Errorf("failed to copy source: %w", err)
-				}
-			}
-			ui.Infof("Failed to extract source directory on remote host: %s", stderr.String())
-			return "", err
-		}
-		return "", fmt.Errorf("failed to unzip on remote host: %v", err)
-	}
-
-	return remoteArchiveLoc, nil
-}
-
 func createTempContextFile(execID string) (*os.File, error) {
 	name := fmt.Sprintf("uw-context-%s.tar.gz", execID)
 	tmpFile, err := os.CreateTemp(os.TempDir(), name)
@@ -387,75 +292,27 @@ func createTempContextFile(execID string) (*os.File, error) {
 	return tmpFile, nil
 }
 
-func copySourceSCP(from, to string, privKeyPath string) error {
-	scpCommandArgs := []string{
-		"-r",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-	}
-	if privKeyPath != "" {
-		scpCommandArgs = append(scpCommandArgs, "-i", privKeyPath)
-	}
-
-	scpCommandArgs = append(scpCommandArgs, []string{from, to}...)
-
-	scpCommand := exec.Command("scp", scpCommandArgs...)
-
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-
-	scpCommand.Stdout = stdout
-	scpCommand.Stderr = stderr
-
-	if err := scpCommand.Run(); err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Exited with non-zero exit code
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 255 {
-					ui.Infof("The remote host closed the connection.")
-					return fmt.Errorf("failed to copy source: %w", err)
-				}
-			}
-			ui.Infof("Failed to copy source directory to remote host: %s", stderr.String())
-			return err
-		}
-		return fmt.Errorf("scp command failed: %v", err)
+// connect opens an interactive terminal on the session host like ssh.Connect, and
+// tells the user if the remote host closed the connection.
+func connect(ctx context.Context, network types.ExecNetwork, prvKeyPath string, args []string, command []string) error {
+	err := ssh.Connect(ctx, network, prvKeyPath, args, command)
+	if errors.Is(err, ssh.ErrConnectionClosed) {
+		ui.Infof("The remote host closed the connection.")
+		return nil
 	}
-
-	return nil
+	return err
 }
 
-func copySourceUnTar(srcPath, dstPath string, connectionInfo types.ExecNetwork, prvKeyPath string) error {
-	sshCommand := exec.Command(
-		"ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-i", prvKeyPath,
-		fmt.Sprintf("%s@%s", connectionInfo.User, connectionInfo.Host),
-		// ensure dstPath exist and root logs into that path
-		fmt.Sprintf("mkdir -p %s && echo 'cd %s' > /root/.bashrc &&", dstPath, dstPath),
-		fmt.Sprintf("tar -xzf %s -C %s && rm -rf %s", srcPath, dstPath, srcPath),
-	)
-
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-
-	sshCommand.Stdout = stdout
-	sshCommand.Stderr = stderr
-
-	if err := sshCommand.Run(); err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Exited with non-zero exit code
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 255 {
-					ui.Infof("The remote host closed the connection.")
-					return fmt.Errorf("failed to copy source: %w", err)
-				}
-			}
-			ui.Infof("Failed to extract source directory on remote host: %s", stderr.String())
-			return err
+func copySourceUnTar(ctx context.Context, t ssh.Transport, srcPath, dstPath string) error {
+	// ensure dstPath exist and root logs into that path
+	command := fmt.Sprintf("mkdir -p %s && echo 'cd %s' > /root/.bashrc && tar -xzf %s -C %s && rm -rf %s",
+		dstPath, dstPath, srcPath, dstPath, srcPath)
+
+	if _, err := t.Run(ctx, command); err != nil {
+		if errors.Is(err, ssh.ErrConnectionClosed) {
+			ui.Infof("The remote host closed the connection.")
 		}
-		return fmt.Errorf("failed to unzip on remote host: %v", err)
+		return err
 	}
 
 	return nil
@@ -473,7 +330,10 @@ func getDefaultKey(ctx context.Context, e types.Exec, defaultKey string) (string
 	}
 	execKeyName := e.Keys[0].Name
 
-	keysFolder := config.GetUnweaveSSHKeysFolder()
+	keysFolder, err := config.GetUnweaveSSHKeysFolder()
+	if err != nil {
+		return "", err
+	}
 	dirEntries, err := os.ReadDir(keysFolder)
 	if err != nil {
 		return "", fmt.Errorf("failed to read SSH keys folder: %w", err)
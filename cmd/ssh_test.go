@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHParseArgs(t *testing.T) {
+	parse := func(args ...string) execCmdArgs {
+		c := &cobra.Command{}
+		require.NoError(t, c.Flags().Parse(args))
+		return (&sshCommandFlow{}).parseArgs(c, c.Flags().Args())
+	}
+
+	t.Run("should pass the arguments after -- to ssh", func(t *testing.T) {
+		got := parse("my-session", "--", "-L", "8080:localhost:8080")
+		assert.Equal(t, "my-session", got.execRef)
+		assert.Equal(t, []string{"-L", "8080:localhost:8080"}, got.sshConnectionOptions)
+	})
+
+	t.Run("should allow ssh arguments without a session", func(t *testing.T) {
+		got := parse("--", "-L", "8080:localhost:8080")
+		assert.Equal(t, "", got.execRef)
+		assert.Equal(t, []string{"-L", "8080:localhost:8080"}, got.sshConnectionOptions)
+	})
+
+	t.Run("should take the session without ssh arguments", func(t *testing.T) {
+		got := parse("my-session")
+		assert.Equal(t, "my-session", got.execRef)
+		assert.Empty(t, got.sshConnectionOptions)
+	})
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/filesync"
+	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+// Sync keeps the project directory in sync with a running session until interrupted.
+func Sync(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	ctx := cmd.Context()
+
+	execRef := ""
+	if len(args) == 1 {
+		execRef = args[0]
+	} else {
+		var err error
+		execRef, _, err = sessionSelectSSHExecRef(ctx, execRef, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	events, err := watchSession(ctx, execRef)
+	if err != nil {
+		handleWaitError(err)
+		return nil
+	}
+
+	for event := range events {
+		if event.Err != nil {
+			handleWaitError(event.Err)
+			return nil
+		}
+		renderWaitEvent(event)
+
+		if e := event.Exec; e.Status == types.StatusRunning {
+			prvKey, err := getDefaultKey(ctx, e, config.SSHPrivateKeyPath)
+			if err != nil {
+				ui.Errorf("Failed to get private key: %s", err)
+				os.Exit(1)
+			}
+
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			ui.Infof("🔄 Syncing to %q on session %q. Press Ctrl+C to stop.", config.ProjectHostDir(), e.ID)
+			if err = syncProject(ctx, e, prvKey, config.SyncPull, renderSyncEvent); err != nil {
+				ui.Errorf("Sync stopped: %s", err)
+				os.Exit(1)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// syncProject syncs the active project directory to the session until ctx is done.
+func syncProject(ctx context.Context, e types.Exec, prvKey string, pull bool, onSync func(filesync.Event)) error {
+	dir, err := config.GetActiveProjectPath()
+	if err != nil {
+		return fmt.Errorf("failed to get active project path: %w", err)
+	}
+
+	t, err := ssh.Dial(ctx, ssh.Options{Network: e.Network, PrivateKeyPath: prvKey})
+	if err != nil {
+		return fmt.Errorf("failed to connect to session: %w", err)
+	}
+	defer t.Close()
+
+	syncer := &filesync.Syncer{
+		Transport: t,
+		LocalDir:  dir,
+		RemoteDir: config.ProjectHostDir(),
+		Ignore:    compileIgnore(dir),
+		Pull:      pull,
+		OnSync:    onSync,
+	}
+	return syncer.Run(ctx)
+}
+
+// startSync syncs the project to the session in the background. Errors are only logged
+// so they don't interfere with the terminal. The returned function stops syncing.
+func startSync(ctx context.Context, e types.Exec, prvKey string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := syncProject(ctx, e, prvKey, false, nil); err != nil {
+			ui.Debugf("Sync to session %q stopped: %v", e.ID, err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func renderSyncEvent(event filesync.Event) {
+	updated, removed := "⬆️ ", "🗑️ "
+	if event.Direction == filesync.Pull {
+		updated = "⬇️ "
+	}
+	for _, p := range event.Changes.Updated {
+		ui.Infof("%s %s", updated, p)
+	}
+	for _, p := range event.Changes.Removed {
+		ui.Infof("%s %s", removed, p)
+	}
+}
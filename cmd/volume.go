@@ -3,12 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/config"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/cli/volume"
+	"github.com/unweave/unweave/api/types"
 )
 
 func VolumeCreate(cmd *cobra.Command, args []string) error {
@@ -43,7 +46,7 @@ func VolumeCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		ui.Fatal("There was a problem rendering the newly created volume", err)
 	}
-	volume.RenderVolumesList(volumes, &vol)
+	renderVolumesList(volumes, &vol)
 
 	return nil
 }
@@ -88,7 +91,7 @@ func VolumeList(cmd *cobra.Command, args []string) error {
 		os.Exit(1)
 	}
 
-	volume.RenderVolumesList(volumes, nil)
+	renderVolumesList(volumes, nil)
 
 	return nil
 }
@@ -123,3 +126,69 @@ func VolumeResize(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// renderVolumesList prints volumes, newest first, with highlight marked.
+func renderVolumesList(volumes []types.Volume, highlight *types.Volume) {
+	const highlighted = " *"
+	cols := []ui.Column{
+		{
+			Title: "Name",
+			Width: 5 + ui.MaxFieldLength(volumes, func(volume types.Volume) string {
+				if highlight != nil {
+					return volume.Name + highlighted
+				}
+				return volume.Name
+			}),
+		}, {
+			Title: "Size",
+			Width: 5 + ui.MaxFieldLength(volumes, func(volume types.Volume) string {
+				return fmt.Sprintf("%v", volume.Size)
+			}),
+		},
+		{
+			Title: "Created At",
+			Width: 5 + ui.MaxFieldLength(volumes, func(volume types.Volume) string {
+				return volume.State.CreatedAt.Format(time.RFC3339)
+			}),
+		}, {
+			Title: "Provider",
+			Width: 5 + ui.MaxFieldLength(volumes, func(volume types.Volume) string {
+				return volume.Provider.DisplayName()
+			}),
+		},
+	}
+
+	rows := make([]ui.Row, len(volumes))
+
+	if len(volumes) == 0 {
+		ui.Infof("No existing volumes")
+		return
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		a := volumes[i].State.CreatedAt
+		b := volumes[j].State.CreatedAt
+		return a.After(b)
+	})
+
+	for idx, volume := range volumes {
+		if highlight != nil && volume.ID == highlight.ID {
+			rows[idx] = []string{
+				highlight.Name + highlighted,
+				fmt.Sprintf("%d GB", highlight.Size),
+				highlight.State.CreatedAt.Format(time.RFC3339),
+				highlight.Provider.DisplayName(),
+			}
+			continue
+		}
+
+		rows[idx] = []string{
+			volume.Name,
+			fmt.Sprintf("%d GB", volume.Size),
+			volume.State.CreatedAt.Format(time.RFC3339),
+			volume.Provider.DisplayName(),
+		}
+	}
+
+	ui.Table("Volumes", cols, rows)
+}
@@ -0,0 +1,50 @@
+package config
+
+import "os"
+
+// TokenSource is where the token used to authenticate with the API comes from.
+type TokenSource string
+
+const (
+	TokenSourceNone TokenSource = ""
+	// TokenSourceFlag is a token passed with --token.
+	TokenSourceFlag TokenSource = "flag"
+	// TokenSourceProject is a project token from UNWEAVE_PROJECT_TOKEN or the project's
+	// .env file. It's scoped to the project and meant for CI.
+	TokenSourceProject TokenSource = "project"
+	// TokenSourceUser is the token of the user logged in to the active context.
+	TokenSourceUser TokenSource = "user"
+)
+
+// Token returns the token to authenticate with and where it comes from. A token passed
+// with --token takes precedence over a project token, which takes precedence over the
+// token of the logged-in user.
+func Token() (string, TokenSource) {
+	if AuthToken != "" {
+		return AuthToken, TokenSourceFlag
+	}
+	if t := os.Getenv("UNWEAVE_PROJECT_TOKEN"); t != "" {
+		return t, TokenSourceProject
+	}
+	if Config.Project != nil && Config.Project.Env != nil && Config.Project.Env.ProjectToken != "" {
+		return Config.Project.Env.ProjectToken, TokenSourceProject
+	}
+	if Config.Unweave.User.Token != "" {
+		return Config.Unweave.User.Token, TokenSourceUser
+	}
+	return "", TokenSourceNone
+}
+
+// AccountID returns the account that owns resources such as SSH keys. That's the
+// logged-in user, unless a project token is used or nobody is logged in, in which case
+// it's the owner of the project.
+func AccountID() string {
+	if _, source := Token(); source != TokenSourceProject && Config.Unweave.User.ID != "" {
+		return Config.Unweave.User.ID
+	}
+	owner, _, err := GetProjectOwnerAndName()
+	if err != nil {
+		return ""
+	}
+	return owner
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	prevProject, prevUnweave, prevToken := Config.Project, Config.Unweave, AuthToken
+	t.Cleanup(func() { Config.Project, Config.Unweave, AuthToken = prevProject, prevUnweave, prevToken })
+	t.Setenv("UNWEAVE_PROJECT_TOKEN", "")
+
+	Config.Project = &Project{URI: "team/models", Env: &Secrets{}}
+	Config.Unweave = &unweave{User: &user{ID: "uid", Token: "uw:user"}}
+	AuthToken = ""
+
+	token, source := Token()
+	assert.Equal(t, "uw:user", token)
+	assert.Equal(t, TokenSourceUser, source)
+	assert.Equal(t, "uid", AccountID())
+
+	Config.Project.Env.ProjectToken = "uw:dotenv"
+	token, source = Token()
+	assert.Equal(t, "uw:dotenv", token)
+	assert.Equal(t, TokenSourceProject, source)
+	assert.Equal(t, "team", AccountID())
+
+	t.Setenv("UNWEAVE_PROJECT_TOKEN", "uw:env")
+	token, _ = Token()
+	assert.Equal(t, "uw:env", token)
+
+	AuthToken = "uw:flag"
+	token, source = Token()
+	assert.Equal(t, "uw:flag", token)
+	assert.Equal(t, TokenSourceFlag, source)
+	assert.Equal(t, "uid", AccountID())
+
+	AuthToken = ""
+	t.Setenv("UNWEAVE_PROJECT_TOKEN", "")
+	Config.Project.Env.ProjectToken = ""
+	Config.Unweave.User = &user{}
+	_, source = Token()
+	assert.Equal(t, TokenSourceNone, source)
+	assert.Equal(t, "team", AccountID())
+}
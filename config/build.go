@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxContextSize is the largest build context uploaded if neither the
+// --max-context-size flag nor build.max_context_size in the project config set one.
+const DefaultMaxContextSize = "2GiB"
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseSize parses a size in bytes, e.g. 500MB, 1.5GiB or 1024. Units are B, KB, MB, GB
+// and TB, or KiB, MiB, GiB and TiB for powers of 1024, regardless of case.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(trimmed)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[i:]))]
+	n, err := strconv.ParseFloat(trimmed[:i], 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// ContextSizeLimit returns the largest build context to upload in bytes. It's set with
+// the --max-context-size flag, or else build.max_context_size in the project config.
+// Zero means there's no limit.
+func ContextSizeLimit() (int64, error) {
+	size := DefaultMaxContextSize
+	if Config.Project.Build.MaxContextSize != "" {
+		size = Config.Project.Build.MaxContextSize
+	}
+	if MaxContextSize != "" {
+		size = MaxContextSize
+	}
+	return ParseSize(size)
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"0":      0,
+		"1024":   1024,
+		"512B":   512,
+		"500MB":  500 * 1000 * 1000,
+		"500 mb": 500 * 1000 * 1000,
+		"2GB":    2 * 1000 * 1000 * 1000,
+		"1.5GiB": 3 << 29,
+		"64KiB":  64 << 10,
+		" 1TiB ": 1 << 40,
+	}
+	for in, want := range cases {
+		got, err := ParseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "GB", "-1GB", "1.2.3MB", "10 bytes", "1e3"} {
+		_, err := ParseSize(in)
+		assert.EqualError(t, err, `invalid size "`+in+`"`)
+	}
+}
@@ -2,13 +2,13 @@ package config
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
 
-	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/tools/gonfig"
 )
 
@@ -44,18 +44,34 @@ var (
 	}
 )
 
-func GetProjectOwnerAndName() (owner string, name string) {
-	uri := Config.Project.URI
-	parts := strings.Split(uri, "/")
+var (
+	// ErrNoProject is returned when no project is linked and none is set with the
+	// --project flag.
+	ErrNoProject = errors.New("no project set, run `unweave link` first or use the `--project` flag")
+	// ErrInvalidProjectURI is returned when the project URI isn't of the form
+	// <owner>/<project>.
+	ErrInvalidProjectURI = errors.New("invalid project URI")
+)
+
+// issues are the issues found in the config files when they were loaded.
+var issues []Issue
 
-	if len(parts) != 2 {
-		ui.Errorf("Invalid project URI: %q. Should be of type '<owner>/<project>", uri)
-		os.Exit(1)
+// Issues returns the issues found in the project and user config files by Init.
+func Issues() []Issue {
+	return issues
+}
+
+func GetProjectOwnerAndName() (owner string, name string, err error) {
+	uri := Config.Project.URI
+	if uri == "" {
+		return "", "", ErrNoProject
 	}
 
-	owner = strings.Split(uri, "/")[0]
-	name = strings.Split(uri, "/")[1]
-	return owner, name
+	parts := strings.Split(uri, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%w %q, should be of type '<owner>/<project>'", ErrInvalidProjectURI, uri)
+	}
+	return parts[0], parts[1], nil
 }
 
 // GetActiveProjectPath returns the active project directory by recursively going up the
@@ -91,31 +107,36 @@ func GetActiveProjectPath() (string, error) {
 	return activeProjectDir, nil
 }
 
-func GetGlobalConfigPath() string {
+func GetGlobalConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		ui.Errorf("Could not get user home directory")
-		os.Exit(1)
+		return "", fmt.Errorf("could not get user home directory: %w", err)
 	}
-	return filepath.Join(home, GlobalConfigDirName)
+	return filepath.Join(home, GlobalConfigDirName), nil
 }
 
-func InitProjectConfigFrom(projectConfigPath, envConfigPath string) (*Secrets, *Project) {
+// InitProjectConfigFrom loads the project config and its environment config. Files that
+// can't be read are returned as issues and left empty.
+func InitProjectConfigFrom(projectConfigPath, envConfigPath string) (*Secrets, *Project, []Issue) {
 	envConfig := &Secrets{}
 	projectConfig := &Project{}
+	var readIssues []Issue
 
 	if err := readAndUnmarshal(projectConfigPath, &projectConfig); err != nil {
-		ui.Infof("Failed to read project config at path %q", projectConfigPath)
+		readIssues = append(readIssues, Issue{File: projectConfigPath, Message: fmt.Sprintf("failed to read: %s", err)})
 	}
 	if err := readAndUnmarshal(envConfigPath, envConfig); err != nil {
-		ui.Infof("Failed to read environment config at path %q", envConfigPath)
+		readIssues = append(readIssues, Issue{File: envConfigPath, Message: fmt.Sprintf("failed to read: %s", err)})
 	}
 
-	return envConfig, projectConfig
+	return envConfig, projectConfig, readIssues
 }
 
-func Init() {
+// Init loads the project and user config. Errors reading the config files are returned
+// after the remaining config has been loaded, so callers may choose to carry on.
+func Init() error {
 	// ----- ProjectConfig -----
+	issues = nil
 	envConfig := &Secrets{}
 	projectConfig := &Project{}
 
@@ -123,51 +144,64 @@ func Init() {
 	if err == nil {
 		projectConfigPath = filepath.Join(projectDir, projectConfigPath)
 		envConfigPath = filepath.Join(projectDir, envConfigPath)
-		envConfig, projectConfig = InitProjectConfigFrom(projectConfigPath, envConfigPath)
+		var readIssues []Issue
+		envConfig, projectConfig, readIssues = InitProjectConfigFrom(projectConfigPath, envConfigPath)
+		issues = append(issues, readIssues...)
+		if f, err := openFile(projectConfigPath, projectSchema); err == nil {
+			issues = append(issues, f.Validate()...)
+		}
 	}
 
 	projectConfig.Env = envConfig
 
 	// ----- Unweave Config -----
 
-	env := "production"
-	if e, ok := os.LookupEnv("UNWEAVE_ENV"); ok {
-		env = e
-	}
-	apiURL := Config.Unweave.ApiURL
-	appURL := Config.Unweave.AppURL
-
-	switch env {
-	case "staging", "stg":
-		unweaveConfigPath = filepath.Join(GetGlobalConfigPath(), "stg-config.toml")
-		apiURL = "https://api.staging-unweave.io"
-		appURL = "https://app.staging-unweave.io"
-	case "development", "dev":
-		unweaveConfigPath = filepath.Join(GetGlobalConfigPath(), "dev-config.toml")
-		apiURL = "http://localhost:4000"
-		appURL = "http://localhost:3000"
-	case "production", "prod":
-		unweaveConfigPath = filepath.Join(GetGlobalConfigPath(), "config.toml")
-	default:
-		// If anything else, assume production
-		fmt.Println("Unrecognized environment. Assuming production.")
+	ctx, err := resolveContext()
+	if errors.Is(err, ErrContextNotFound) && ContextName == "" {
+		// Only UNWEAVE_ENV can name a context that doesn't exist at this point
+		issues = append(issues, Issue{
+			Key:     "UNWEAVE_ENV",
+			Message: fmt.Sprintf("unrecognized environment, assuming %s", DefaultContextName),
+		})
+		ctx, err = (&contexts{}).get(DefaultContextName)
+	}
+	if err != nil {
+		return err
+	}
+	ActiveContext = ctx
+
+	globalConfigPath, err := GetGlobalConfigPath()
+	if err != nil {
+		return err
 	}
+	unweaveConfigPath = filepath.Join(globalConfigPath, ctx.configFile)
 
 	// Load saved config - create the empty config if it doesn't exist
+	var loadErr error
 	if err = readAndUnmarshal(unweaveConfigPath, Config.Unweave); os.IsNotExist(err) {
-		err = Config.Unweave.Save()
-		if err != nil {
-			ui.Errorf("Failed to create config file: %v", err)
+		if err = marshalAndWrite(unweaveConfigPath, Config.Unweave); err != nil {
+			loadErr = fmt.Errorf("failed to create config file: %w", err)
 		}
 	} else if err != nil {
-		ui.Errorf("Failed to read config file: %v", err)
+		loadErr = fmt.Errorf("failed to read config file: %w", err)
+	}
+	if f, err := openFile(unweaveConfigPath, userSchema); err == nil {
+		issues = append(issues, f.Validate()...)
+	}
+	if err = loadToken(ctx); err != nil && loadErr == nil {
+		loadErr = err
 	}
 
 	// Need to set these after reading the config file so that they can be overridden
-	Config.Unweave.ApiURL = apiURL
-	Config.Unweave.AppURL = appURL
+	Config.Unweave.ApiURL = ctx.ApiURL
+	Config.Unweave.AppURL = ctx.AppURL
+	if projectConfig.URI == "" {
+		projectConfig.URI = ctx.Project
+	}
 	Config.Project = projectConfig
 
 	// Override with environment variables
 	gonfig.GetFromEnvVariables(Config)
+
+	return loadErr
 }
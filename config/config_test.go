@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,12 +29,19 @@ memory = 8
 size = 10
 [[specs]]
 name = "my-spec"
+ttl = "4h"
+idle_timeout = "30m"
 [specs.cpu]
 type = "x86_64"
 [specs.gpu]
 type = "rtx_5000"
 count = 1
 memory = 4
+[specs.fallback]
+gpu_types = ["rtx_4000", "a100"]
+regions = ["us_west_2"]
+providers = ["lambdalabs"]
+max_hourly_price = 2.5
 `
 		f, err := os.CreateTemp("", "unweave-config-*.toml")
 		if err != nil {
@@ -46,23 +54,33 @@ memory = 4
 		}
 		f.Sync()
 
-		var p project
+		var p Project
 		if err := readAndUnmarshal(f.Name(), &p); err != nil {
 			t.Error(err)
 		}
 
 		want :=
-			project{
+			Project{
 				DefaultProvider: "unweave",
-				Specs: []spec{
+				Specs: []Spec{
 					{
-						CPU: specResources{Type: "x86_64", Count: 3, Memory: 4},
-						GPU: specResources{Type: "rtx_4000", Count: 2, Memory: 8},
-						HDD: specHDD{Size: 10},
+						Name: "default",
+						CPU:  specResources{Type: "x86_64", Count: "3", Memory: "4"},
+						GPU:  specResources{Type: "rtx_4000", Count: "2", Memory: "8"},
+						HDD:  specHDD{Size: "10"},
 					},
 					{
-						CPU: specResources{Type: "x86_64"},
-						GPU: specResources{Type: "rtx_5000", Count: 1, Memory: 4},
+						Name:        "my-spec",
+						CPU:         specResources{Type: "x86_64"},
+						GPU:         specResources{Type: "rtx_5000", Count: "1", Memory: "4"},
+						TTL:         "4h",
+						IdleTimeout: "30m",
+						Fallback: &specFallback{
+							GPUTypes:       []string{"rtx_4000", "a100"},
+							Regions:        []string{"us_west_2"},
+							Providers:      []string{"lambdalabs"},
+							MaxHourlyPrice: 2.5,
+						},
 					},
 				},
 			}
@@ -71,3 +89,30 @@ memory = 4
 
 	})
 }
+
+func TestInitProjectConfigFrom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should return the files it can't read as issues", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		projectPath := filepath.Join(dir, "config.toml")
+		envPath := filepath.Join(dir, ".env")
+		if err := os.WriteFile(projectPath, []byte("default_provider = \"unweave\"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, project, issues := InitProjectConfigFrom(projectPath, envPath)
+		assert.Equal(t, "unweave", project.DefaultProvider)
+		if assert.Len(t, issues, 1) {
+			assert.Equal(t, envPath, issues[0].File)
+			assert.Contains(t, issues[0].String(), "failed to read")
+		}
+	})
+
+	t.Run("should leave out the file of issues with the environment", func(t *testing.T) {
+		t.Parallel()
+		issue := Issue{Key: "UNWEAVE_ENV", Message: "unrecognized environment, assuming prod"}
+		assert.Equal(t, "UNWEAVE_ENV: unrecognized environment, assuming prod", issue.String())
+	})
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultContextName is the context used if none is selected.
+const DefaultContextName = "production"
+
+var (
+	// ErrContextNotFound is returned when a context doesn't exist.
+	ErrContextNotFound = errors.New("context not found")
+	// ErrContextExists is returned when adding a context with the name of an existing one.
+	ErrContextExists = errors.New("context already exists")
+	// ErrBuiltinContext is returned when trying to change or remove a built-in context.
+	ErrBuiltinContext = errors.New("built-in contexts can't be changed")
+	// ErrInvalidContextName is returned for context names that aren't made of letters,
+	// digits, dashes and underscores.
+	ErrInvalidContextName = errors.New("context names may only contain letters, digits, - and _")
+)
+
+// contextNamePattern is what context names look like. They name the config file of the
+// context, so they must not contain path separators.
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ActiveContext is the context the CLI runs against. It's set by Init.
+var ActiveContext Context
+
+// Context is a named API environment and account. The user of a context is stored in
+// its own config file, so switching contexts doesn't log you out of the others.
+type Context struct {
+	Name   string `toml:"-" json:"name"`
+	ApiURL string `toml:"api_url" json:"api_url"`
+	AppURL string `toml:"app_url,omitempty" json:"app_url,omitempty"`
+	// Token, if set when adding the context, is saved as the token of its user, so the
+	// context can be used without logging in.
+	Token string `toml:"-" json:"-"`
+	// Project is the project used when no project is linked.
+	Project string `toml:"project,omitempty" json:"project,omitempty"`
+
+	builtin    bool
+	configFile string
+}
+
+// Builtin returns whether the context ships with the CLI.
+func (c Context) Builtin() bool {
+	return c.builtin
+}
+
+type contexts struct {
+	Current  string             `toml:"current"`
+	Contexts map[string]Context `toml:"contexts"`
+}
+
+// builtinContexts replace the environments previously selected with UNWEAVE_ENV. They
+// keep using the config files of those environments.
+var builtinContexts = []Context{
+	{
+		Name:       "production",
+		ApiURL:     "https://api.unweave.io",
+		AppURL:     "https://app.unweave.io",
+		builtin:    true,
+		configFile: "config.toml",
+	},
+	{
+		Name:       "staging",
+		ApiURL:     "https://api.staging-unweave.io",
+		AppURL:     "https://app.staging-unweave.io",
+		builtin:    true,
+		configFile: "stg-config.toml",
+	},
+	{
+		Name:       "development",
+		ApiURL:     "http://localhost:4000",
+		AppURL:     "http://localhost:3000",
+		builtin:    true,
+		configFile: "dev-config.toml",
+	},
+}
+
+// contextAliases are the short names UNWEAVE_ENV accepts.
+var contextAliases = map[string]string{
+	"prod": "production",
+	"stg":  "staging",
+	"dev":  "development",
+}
+
+func contextsPath() (string, error) {
+	dir, err := GetGlobalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "contexts.toml"), nil
+}
+
+func loadContexts() (*contexts, error) {
+	c := &contexts{}
+	path, err := contextsPath()
+	if err != nil {
+		return nil, err
+	}
+	if err = readAndUnmarshal(path, c); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read contexts: %w", err)
+	}
+	if c.Contexts == nil {
+		c.Contexts = map[string]Context{}
+	}
+	return c, nil
+}
+
+func (c *contexts) save() error {
+	path, err := contextsPath()
+	if err != nil {
+		return err
+	}
+	return marshalAndWrite(path, c)
+}
+
+// get returns the context called name, which may be an alias of a built-in context.
+func (c *contexts) get(name string) (Context, error) {
+	if alias, ok := contextAliases[name]; ok {
+		name = alias
+	}
+	for _, b := range builtinContexts {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	if !contextNamePattern.MatchString(name) {
+		return Context{}, fmt.Errorf("%w: %q", ErrInvalidContextName, name)
+	}
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return Context{}, fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	ctx.Name = name
+	ctx.configFile = name + "-config.toml"
+	return ctx, nil
+}
+
+// ListContexts returns the built-in contexts followed by the user's contexts sorted by
+// name, and the name of the context that is used by default.
+func ListContexts() ([]Context, string, error) {
+	c, err := loadContexts()
+	if err != nil {
+		return nil, "", err
+	}
+
+	list := append([]Context{}, builtinContexts...)
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ctx, err := c.get(name)
+		if err != nil {
+			// Contexts with invalid names can only have been added by hand
+			continue
+		}
+		list = append(list, ctx)
+	}
+
+	current := c.Current
+	if current == "" {
+		current = DefaultContextName
+	}
+	return list, current, nil
+}
+
+// UseContext makes the context called name the default.
+func UseContext(name string) error {
+	c, err := loadContexts()
+	if err != nil {
+		return err
+	}
+	ctx, err := c.get(name)
+	if err != nil {
+		return err
+	}
+	c.Current = ctx.Name
+	return c.save()
+}
+
+// AddContext adds a new context.
+func AddContext(ctx Context) error {
+	if ctx.Name == "" || ctx.ApiURL == "" {
+		return errors.New("a context needs a name and an API URL")
+	}
+	if !contextNamePattern.MatchString(ctx.Name) {
+		return fmt.Errorf("%w: %q", ErrInvalidContextName, ctx.Name)
+	}
+	c, err := loadContexts()
+	if err != nil {
+		return err
+	}
+	if existing, err := c.get(ctx.Name); err == nil {
+		if existing.builtin {
+			return fmt.Errorf("%w: %s", ErrBuiltinContext, ctx.Name)
+		}
+		return fmt.Errorf("%w: %s", ErrContextExists, ctx.Name)
+	}
+	if ctx.AppURL == "" {
+		ctx.AppURL = ctx.ApiURL
+	}
+	if ctx.Token != "" {
+		if err = CredentialStore().Set(ctx.Name, ctx.Token); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+	}
+	c.Contexts[ctx.Name] = ctx
+	return c.save()
+}
+
+// RemoveContext removes the context called name. If it was the default, the default goes
+// back to DefaultContextName.
+func RemoveContext(name string) error {
+	c, err := loadContexts()
+	if err != nil {
+		return err
+	}
+	ctx, err := c.get(name)
+	if err != nil {
+		return err
+	}
+	if ctx.builtin {
+		return fmt.Errorf("%w: %s", ErrBuiltinContext, ctx.Name)
+	}
+
+	delete(c.Contexts, ctx.Name)
+	if c.Current == ctx.Name {
+		c.Current = ""
+	}
+	if err = c.save(); err != nil {
+		return err
+	}
+
+	dir, err := GetGlobalConfigPath()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(filepath.Join(dir, ctx.configFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return CredentialStore().Delete(ctx.Name)
+}
+
+// resolveContext returns the active context. The --context flag takes precedence over
+// UNWEAVE_ENV, which takes precedence over the default set with UseContext.
+func resolveContext() (Context, error) {
+	c, err := loadContexts()
+	if err != nil {
+		return Context{}, err
+	}
+
+	name := c.Current
+	if env, ok := os.LookupEnv("UNWEAVE_ENV"); ok && env != "" {
+		name = env
+	}
+	if ContextName != "" {
+		name = ContextName
+	}
+	if name == "" {
+		name = DefaultContextName
+	}
+	return c.get(name)
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContexts(t *testing.T) {
+	useTestCredentialStore(t)
+	t.Setenv("UNWEAVE_ENV", "")
+
+	t.Run("should default to production", func(t *testing.T) {
+		ctx, err := resolveContext()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultContextName, ctx.Name)
+		assert.Equal(t, "config.toml", ctx.configFile)
+	})
+
+	t.Run("should map UNWEAVE_ENV onto the built-in contexts", func(t *testing.T) {
+		t.Setenv("UNWEAVE_ENV", "stg")
+		ctx, err := resolveContext()
+		require.NoError(t, err)
+		assert.Equal(t, "staging", ctx.Name)
+		assert.Equal(t, "https://api.staging-unweave.io", ctx.ApiURL)
+		assert.Equal(t, "stg-config.toml", ctx.configFile)
+	})
+
+	t.Run("should add, use and remove a context", func(t *testing.T) {
+		require.NoError(t, AddContext(Context{Name: "team", ApiURL: "https://api.example.com", Project: "team/models", Token: "uw:team"}))
+		token, err := CredentialStore().Get("team")
+		require.NoError(t, err)
+		assert.Equal(t, "uw:team", token)
+
+		assert.ErrorIs(t, AddContext(Context{Name: "team", ApiURL: "https://other.example.com"}), ErrContextExists)
+		assert.ErrorIs(t, AddContext(Context{Name: "dev", ApiURL: "https://other.example.com"}), ErrBuiltinContext)
+
+		require.NoError(t, UseContext("team"))
+		ctx, err := resolveContext()
+		require.NoError(t, err)
+		assert.Equal(t, "team", ctx.Name)
+		assert.Equal(t, "https://api.example.com", ctx.AppURL)
+		assert.Equal(t, "team/models", ctx.Project)
+
+		list, current, err := ListContexts()
+		require.NoError(t, err)
+		assert.Equal(t, "team", current)
+		require.Len(t, list, 4)
+		assert.Equal(t, "team", list[3].Name)
+
+		// The flag wins over the default
+		ContextName = "production"
+		ctx, err = resolveContext()
+		ContextName = ""
+		require.NoError(t, err)
+		assert.Equal(t, "production", ctx.Name)
+
+		home, _ := os.UserHomeDir()
+		loginFile := filepath.Join(home, GlobalConfigDirName, "team-config.toml")
+		require.NoError(t, os.WriteFile(loginFile, []byte{}, 0600))
+
+		assert.ErrorIs(t, RemoveContext("production"), ErrBuiltinContext)
+		require.NoError(t, RemoveContext("team"))
+		assert.NoFileExists(t, loginFile)
+		_, err = CredentialStore().Get("team")
+		assert.Error(t, err)
+
+		_, current, err = ListContexts()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultContextName, current)
+		assert.ErrorIs(t, UseContext("team"), ErrContextNotFound)
+	})
+
+	t.Run("should reject names that aren't safe file names", func(t *testing.T) {
+		home, _ := os.UserHomeDir()
+		outside := filepath.Join(home, "foo-config.toml")
+		require.NoError(t, os.WriteFile(outside, []byte{}, 0600))
+
+		for _, name := range []string{"../foo", "../../foo", "a/b", "team.prod", "with space"} {
+			assert.ErrorIs(t, AddContext(Context{Name: name, ApiURL: "https://api.example.com"}), ErrInvalidContextName, name)
+		}
+		assert.ErrorIs(t, RemoveContext("../foo"), ErrInvalidContextName)
+		assert.FileExists(t, outside)
+		assert.ErrorIs(t, UseContext("../foo"), ErrInvalidContextName)
+
+		require.NoError(t, AddContext(Context{Name: "team_2-eu", ApiURL: "https://api.example.com"}))
+	})
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/unweave/cli/credentials"
+	"github.com/unweave/cli/ui"
+)
+
+var (
+	credentialStoreOnce sync.Once
+	credentialStore     credentials.Store
+)
+
+// CredentialStore returns the store auth tokens are kept in.
+func CredentialStore() credentials.Store {
+	credentialStoreOnce.Do(func() {
+		dir, err := GetGlobalConfigPath()
+		if err != nil {
+			// Without a home directory there's no config to keep tokens next to either
+			dir = "."
+		}
+		credentialStore = credentials.Default(dir)
+	})
+	return credentialStore
+}
+
+// loadToken loads the token of the user of ctx from the credential store. A token still
+// saved in plain text in the config file is moved to the credential store first.
+func loadToken(ctx Context) error {
+	store := CredentialStore()
+
+	if Config.Unweave.User.Token != "" {
+		if err := Config.Unweave.Save(); err != nil {
+			return fmt.Errorf("failed to move token to the %s: %w", store.Name(), err)
+		}
+		ui.Debugf("Moved token of context %q from %s to the %s", ctx.Name, unweaveConfigPath, store.Name())
+		return nil
+	}
+
+	token, err := store.Get(ctx.Name)
+	if errors.Is(err, credentials.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read token from the %s: %w", store.Name(), err)
+	}
+	Config.Unweave.User.Token = token
+	return nil
+}
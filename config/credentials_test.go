@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestCredentialStore points the credential store at an encrypted file in a
+// temporary home directory.
+func useTestCredentialStore(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("UNWEAVE_CREDENTIAL_STORE", "file")
+	t.Setenv("UNWEAVE_CREDENTIALS_PASSPHRASE", "test")
+	credentialStoreOnce = sync.Once{}
+	t.Cleanup(func() { credentialStoreOnce = sync.Once{} })
+	return home
+}
+
+func TestTokenMigration(t *testing.T) {
+	home := useTestCredentialStore(t)
+
+	path := filepath.Join(home, GlobalConfigDirName, "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("[user]\nid = \"uid\"\ntoken = \"uw:plaintext\"\n"), 0777))
+
+	prevPath, prevCtx, prevUnweave := unweaveConfigPath, ActiveContext, Config.Unweave
+	t.Cleanup(func() { unweaveConfigPath, ActiveContext, Config.Unweave = prevPath, prevCtx, prevUnweave })
+
+	unweaveConfigPath = path
+	ActiveContext = builtinContexts[0]
+	Config.Unweave = &unweave{User: &user{}}
+	require.NoError(t, readAndUnmarshal(path, Config.Unweave))
+	require.NoError(t, loadToken(ActiveContext))
+
+	t.Run("should move the token out of the config file", func(t *testing.T) {
+		buf, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(buf), "uw:plaintext")
+		assert.Contains(t, string(buf), "uid")
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		token, err := CredentialStore().Get("production")
+		require.NoError(t, err)
+		assert.Equal(t, "uw:plaintext", token)
+	})
+
+	t.Run("should load the token from the credential store", func(t *testing.T) {
+		Config.Unweave = &unweave{User: &user{}}
+		require.NoError(t, readAndUnmarshal(path, Config.Unweave))
+		require.NoError(t, loadToken(ActiveContext))
+		assert.Equal(t, "uw:plaintext", Config.Unweave.User.Token)
+		s, err := Config.String()
+		require.NoError(t, err)
+		assert.NotContains(t, s, "uw:plaintext")
+	})
+
+	t.Run("should remove the token on logout", func(t *testing.T) {
+		Config.Unweave.User.Token = ""
+		require.NoError(t, Config.Unweave.Save())
+
+		Config.Unweave = &unweave{User: &user{}}
+		require.NoError(t, loadToken(ActiveContext))
+		assert.Empty(t, Config.Unweave.User.Token)
+	})
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// document is a TOML file that can be read and edited by key while keeping its comments
+// and layout. Paths in a document are full keys in which the elements of arrays of
+// tables are their index, e.g. specs.0.gpu.type.
+type document struct {
+	data    []byte
+	entries []docEntry
+	// tables starts with the root table.
+	tables []docTable
+	// values is the decoded document, used to look up array elements by name.
+	values map[string]any
+}
+
+// docEntry is a key-value of a document.
+type docEntry struct {
+	path []string
+	line int
+	// valueStart and valueEnd are the offsets of the value.
+	valueStart, valueEnd int
+}
+
+// docTable is a table with a header in a document, or the root table.
+type docTable struct {
+	path []string
+	line int
+	// insertAt is where new keys of the table go: after its last key-value, or after
+	// its header.
+	insertAt int
+}
+
+func parseDocument(data []byte) (*document, error) {
+	doc := &document{data: data, tables: []docTable{{}}}
+	if err := toml.Unmarshal(data, &doc.values); err != nil {
+		return nil, err
+	}
+
+	p := &unstable.Parser{}
+	p.Reset(data)
+	current := 0
+	arrays := map[string]int{}
+
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			var path []string
+			it := expr.Key()
+			var last *unstable.Node
+			for it.Next() {
+				last = it.Node()
+				path = append(path, string(last.Data))
+				if it.IsLast() && expr.Kind == unstable.ArrayTable {
+					arrays[strings.Join(path, ".")]++
+				}
+				if n, ok := arrays[strings.Join(path, ".")]; ok {
+					path = append(path, strconv.Itoa(n-1))
+				}
+			}
+			end := lineEnd(data, int(last.Raw.Offset+last.Raw.Length))
+			doc.tables = append(doc.tables, docTable{path: path, line: lineOf(data, int(last.Raw.Offset)), insertAt: end})
+			current = len(doc.tables) - 1
+
+		case unstable.KeyValue:
+			path := append([]string{}, doc.tables[current].path...)
+			it := expr.Key()
+			var first, last *unstable.Node
+			for it.Next() {
+				if first == nil {
+					first = it.Node()
+				}
+				last = it.Node()
+				path = append(path, string(last.Data))
+			}
+			start := int(last.Raw.Offset + last.Raw.Length)
+			start += bytes.IndexByte(data[start:], '=') + 1
+			for start < len(data) && (data[start] == ' ' || data[start] == '\t') {
+				start++
+			}
+			end := scanValue(data, start)
+			doc.entries = append(doc.entries, docEntry{
+				path:       path,
+				line:       lineOf(data, int(first.Raw.Offset)),
+				valueStart: start,
+				valueEnd:   end,
+			})
+			doc.tables[current].insertAt = lineEnd(data, end)
+		}
+	}
+	return doc, p.Error()
+}
+
+func (d *document) entry(path []string) (docEntry, bool) {
+	for _, e := range d.entries {
+		if equalPath(e.path, path) {
+			return e, true
+		}
+	}
+	return docEntry{}, false
+}
+
+func (d *document) table(path []string) (docTable, bool) {
+	for _, t := range d.tables {
+		if equalPath(t.path, path) {
+			return t, true
+		}
+	}
+	return docTable{}, false
+}
+
+// line returns the line of the key at path, or of the closest table that contains it.
+// It returns 0 if the path isn't in the document.
+func (d *document) line(path ...string) int {
+	if e, ok := d.entry(path); ok {
+		return e.line
+	}
+	for n := len(path); n > 0; n-- {
+		if t, ok := d.table(path[:n]); ok {
+			return t.line
+		}
+	}
+	return 0
+}
+
+// lookup returns the decoded value at path.
+func (d *document) lookup(path []string) (any, bool) {
+	var v any = d.values
+	for _, seg := range path {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[seg]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// elementIndex returns the index of the element of the array of tables at path with
+// the name or index selector.
+func (d *document) elementIndex(path []string, selector string) (int, bool) {
+	v, _ := d.lookup(path)
+	elements, _ := v.([]any)
+	if i, err := strconv.Atoi(selector); err == nil {
+		return i, i >= 0 && i < len(elements)
+	}
+	for i, e := range elements {
+		if m, ok := e.(map[string]any); ok {
+			if name, ok := m["name"].(string); ok && strings.EqualFold(name, selector) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// set returns the data of the document with the key at path set to value, which must
+// be TOML. Keys that don't exist yet are added to the closest table that contains
+// them, or to a new table at the end of the document.
+func (d *document) set(path []string, value string) []byte {
+	if e, ok := d.entry(path); ok {
+		return splice(d.data, e.valueStart, e.valueEnd, value)
+	}
+
+	for n := len(path) - 1; n >= 0; n-- {
+		t, ok := d.table(path[:n])
+		if !ok {
+			continue
+		}
+		rest := path[n:]
+		if n == 0 && len(rest) > 1 {
+			// Keys of the root table have to come before the first table, so new tables
+			// go at the end instead.
+			var buf bytes.Buffer
+			buf.Write(d.data)
+			if len(d.data) > 0 && !bytes.HasSuffix(d.data, []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString("\n[" + formatKey(rest[:len(rest)-1]) + "]\n")
+			buf.WriteString(formatKey(rest[len(rest)-1:]) + " = " + value + "\n")
+			return buf.Bytes()
+		}
+		line := formatKey(rest) + " = " + value + "\n"
+		if t.insertAt == len(d.data) && len(d.data) > 0 && !bytes.HasSuffix(d.data, []byte("\n")) {
+			line = "\n" + line
+		}
+		return splice(d.data, t.insertAt, t.insertAt, line)
+	}
+	return d.data
+}
+
+// appendElement returns the data of the document with a new element of the array of
+// tables at path, named name.
+func (d *document) appendElement(path []string, name string) []byte {
+	var buf bytes.Buffer
+	buf.Write(d.data)
+	if len(d.data) > 0 && !bytes.HasSuffix(d.data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("\n[[" + formatKey(path) + "]]\n")
+	buf.WriteString("name = " + quoteString(name) + "\n")
+	return buf.Bytes()
+}
+
+var bareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func formatKey(path []string) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		if bareKeyRegex.MatchString(p) {
+			parts[i] = p
+		} else {
+			parts[i] = quoteString(p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteString returns s as a TOML basic string.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(`\u` + strconv.FormatInt(int64(r)+0x10000, 16)[1:])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// scanValue returns the offset of the end of the TOML value that starts at i.
+func scanValue(b []byte, i int) int {
+	depth := 0
+	for i < len(b) {
+		switch c := b[i]; {
+		case c == '"' || c == '\'':
+			i = skipString(b, i)
+			if depth == 0 {
+				return i
+			}
+			continue
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case c == '#':
+			if depth == 0 {
+				return i
+			}
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',':
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return i
+}
+
+// skipString returns the offset after the TOML string that starts at i.
+func skipString(b []byte, i int) int {
+	quote := b[i]
+	if bytes.HasPrefix(b[i:], []byte{quote, quote, quote}) {
+		delim := []byte{quote, quote, quote}
+		for j := i + 3; j < len(b); j++ {
+			if quote == '"' && b[j] == '\\' {
+				j++
+				continue
+			}
+			if bytes.HasPrefix(b[j:], delim) {
+				// Up to two quotes may directly precede the closing delimiter.
+				j += 3
+				for j < len(b) && b[j] == quote {
+					j++
+				}
+				return j
+			}
+		}
+		return len(b)
+	}
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			if quote == '"' {
+				j++
+			}
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(b)
+}
+
+func splice(data []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(s))
+	out = append(out, data[:start]...)
+	out = append(out, s...)
+	return append(out, data[end:]...)
+}
+
+// lineOf returns the line of the offset, starting at 1.
+func lineOf(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// lineEnd returns the offset after the end of the line of the offset.
+func lineEnd(data []byte, offset int) int {
+	if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(data)
+}
+
+func equalPath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
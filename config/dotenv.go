@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseDotEnv parses a .env file. Each line is KEY=VALUE, optionally preceded by export.
+// Values may be unquoted, 'single-quoted' or "double-quoted", and quoted values may span
+// lines. Double quotes support \n, \t, \r, \", \\ and \$ escapes. Comments start with #,
+// and after unquoted values must follow whitespace.
+//
+// ${VAR}, $VAR and ${VAR:-default} are expanded in unquoted and double-quoted values,
+// with the variables defined earlier in the file or else those returned by lookup,
+// which may be nil. Errors name the line at fault.
+func ParseDotEnv(r io.Reader, lookup func(string) (string, bool)) (map[string]string, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotEnvParser{src: strings.ReplaceAll(string(buf), "\r\n", "\n"), line: 1, lookup: lookup, values: map[string]string{}}
+	for p.pos < len(p.src) {
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+	return p.values, nil
+}
+
+type dotEnvParser struct {
+	src    string
+	pos    int
+	line   int
+	lookup func(string) (string, bool)
+	values map[string]string
+}
+
+func (p *dotEnvParser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// parseLine parses the line at pos and moves pos to the start of the next line.
+func (p *dotEnvParser) parseLine() error {
+	line := p.line
+	text := strings.TrimSpace(p.restOfLine())
+	if text == "" || strings.HasPrefix(text, "#") {
+		p.advance(len(p.restOfLine()) + 1)
+		return nil
+	}
+
+	p.skipSpaces()
+	if strings.HasPrefix(p.src[p.pos:], "export ") || strings.HasPrefix(p.src[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+
+	eq := strings.IndexByte(p.restOfLine(), '=')
+	if eq < 0 {
+		return p.errorf(line, "expected KEY=VALUE, got %q", text)
+	}
+	key := strings.TrimSpace(p.src[p.pos : p.pos+eq])
+	if !dotEnvKeyRegex.MatchString(key) {
+		return p.errorf(line, "invalid key %q, keys are letters, digits and underscores", key)
+	}
+	p.pos += eq + 1
+	p.skipSpaces()
+
+	value, err := p.parseValue(line)
+	if err != nil {
+		return err
+	}
+	p.values[key] = value
+	return nil
+}
+
+// parseValue parses the value at pos and what follows it on its last line.
+func (p *dotEnvParser) parseValue(line int) (string, error) {
+	if p.pos >= len(p.src) {
+		return "", nil
+	}
+
+	switch quote := p.src[p.pos]; quote {
+	case '\'', '"':
+		closing := p.findClosingQuote(quote)
+		if closing < 0 {
+			return "", p.errorf(line, "unterminated %c-quoted value", quote)
+		}
+		raw := p.src[p.pos+1 : closing]
+		p.advance(closing + 1 - p.pos)
+
+		// Only a comment may follow the closing quote.
+		rest := p.restOfLine()
+		if trailing := strings.TrimSpace(rest); trailing != "" && !strings.HasPrefix(trailing, "#") {
+			return "", p.errorf(p.line, "unexpected %q after the closing quote", trailing)
+		}
+		p.advance(len(rest) + 1)
+
+		if quote == '\'' {
+			return raw, nil
+		}
+		return p.expand(unescapeDoubleQuoted(raw), line)
+
+	default:
+		value := p.restOfLine()
+		p.advance(len(value) + 1)
+		if strings.HasPrefix(value, "#") {
+			return "", nil
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		if i := strings.Index(value, "\t#"); i >= 0 {
+			value = value[:i]
+		}
+		return p.expand(strings.ReplaceAll(strings.TrimSpace(value), `\$`, escapedDollar), line)
+	}
+}
+
+// findClosingQuote returns the offset of the quote that closes the one at pos, or -1.
+func (p *dotEnvParser) findClosingQuote(quote byte) int {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// escapedDollar stands in for \$ until variables are expanded.
+const escapedDollar = "\x00"
+
+// unescapeDoubleQuoted replaces the escapes of a double-quoted value. Escaped dollar
+// signs are replaced by escapedDollar.
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case '$':
+			b.WriteString(escapedDollar)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// expand replaces the variables in s, and escapedDollar by dollar signs.
+func (p *dotEnvParser) expand(s string, line int) (string, error) {
+	value, err := expandVariables(s, p.variable, shellDollars)
+	if err != nil {
+		return "", p.errorf(line, "%s", err)
+	}
+	return value, nil
+}
+
+// ExpandEnvValue expands the values of the [env] table of the config. Only ${VAR} and
+// ${VAR:-default} are expanded, with the variables returned by lookup, and $$ stands
+// for a dollar sign. Any other dollar sign is kept as it is, so that values like
+// passwords don't need escaping.
+func ExpandEnvValue(s string, lookup func(string) (string, bool)) (string, error) {
+	return expandVariables(s, lookup, literalDollars)
+}
+
+// dollarPolicy is what expandVariables makes of the dollar signs that don't start a
+// ${VAR}.
+type dollarPolicy int
+
+const (
+	// shellDollars expands $VAR too, like a shell, and escapedDollar stands for a dollar
+	// sign.
+	shellDollars dollarPolicy = iota
+	// literalDollars keeps $VAR as it is, and $$ stands for a dollar sign.
+	literalDollars
+)
+
+// expandVariables replaces ${VAR} and ${VAR:-default} in s with the variables returned by
+// lookup, and the other dollar signs according to policy.
+func expandVariables(s string, lookup func(string) (string, bool), policy dollarPolicy) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case policy == shellDollars && s[i] == escapedDollar[0]:
+			b.WriteByte('$')
+		case s[i] != '$' || i == len(s)-1:
+			b.WriteByte(s[i])
+		case s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				if policy == literalDollars {
+					return "", fmt.Errorf("unterminated ${ in %q, use $$ for a dollar sign", s)
+				}
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			name, fallback, hasFallback := strings.Cut(s[i+2:i+end], ":-")
+			if !dotEnvKeyRegex.MatchString(name) {
+				return "", fmt.Errorf("invalid variable %q", s[i:i+end+1])
+			}
+			value, ok := lookup(name)
+			if (!ok || value == "") && hasFallback {
+				value = fallback
+			}
+			b.WriteString(value)
+			i += end
+		case policy == literalDollars:
+			if s[i+1] == '$' {
+				i++
+			}
+			b.WriteByte('$')
+		default:
+			n := 1
+			for i+n < len(s) && (s[i+n] == '_' || isAlphaNum(s[i+n])) {
+				n++
+			}
+			if n == 1 || (s[i+1] >= '0' && s[i+1] <= '9') {
+				b.WriteByte('$')
+				continue
+			}
+			value, _ := lookup(s[i+1 : i+n])
+			b.WriteString(value)
+			i += n - 1
+		}
+	}
+	return b.String(), nil
+}
+
+func (p *dotEnvParser) variable(name string) (string, bool) {
+	if v, ok := p.values[name]; ok {
+		return v, true
+	}
+	if p.lookup != nil {
+		return p.lookup(name)
+	}
+	return "", false
+}
+
+// restOfLine returns the text from pos to the end of its line.
+func (p *dotEnvParser) restOfLine() string {
+	rest := p.src[p.pos:]
+	if end := strings.IndexByte(rest, '\n'); end >= 0 {
+		return rest[:end]
+	}
+	return rest
+}
+
+func (p *dotEnvParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// advance moves pos n bytes forward, counting the lines it passes.
+func (p *dotEnvParser) advance(n int) {
+	end := p.pos + n
+	if end > len(p.src) {
+		end = len(p.src)
+	}
+	p.line += strings.Count(p.src[p.pos:end], "\n")
+	p.pos = end
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	env := map[string]string{"HOME": "/home/ml", "EMPTY": ""}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cases := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{
+			name: "should read unquoted values",
+			in:   "A=1\nB = two words \n\nC=",
+			want: map[string]string{"A": "1", "B": "two words", "C": ""},
+		},
+		{
+			name: "should keep equal signs in values",
+			in:   "TOKEN=dG9rZW4=\nURL=https://example.com/?a=1&b=2",
+			want: map[string]string{"TOKEN": "dG9rZW4=", "URL": "https://example.com/?a=1&b=2"},
+		},
+		{
+			name: "should skip comments",
+			in:   "# comment\n  # indented comment\nA=1 # trailing comment\nB=#not-a-comment\nC=a#b\nD= # empty",
+			want: map[string]string{"A": "1", "B": "", "C": "a#b", "D": ""},
+		},
+		{
+			name: "should drop export",
+			in:   "export A=1\nexport\tB=2\nexported=3",
+			want: map[string]string{"A": "1", "B": "2", "exported": "3"},
+		},
+		{
+			name: "should read double-quoted values",
+			in:   `A="quoted # not a comment" # comment` + "\n" + `B="tab\there \"quote\" back\\slash"` + "\n" + `C=""`,
+			want: map[string]string{"A": "quoted # not a comment", "B": "tab\there \"quote\" back\\slash", "C": ""},
+		},
+		{
+			name: "should read single-quoted values literally",
+			in:   `A='$HOME \n "x"'`,
+			want: map[string]string{"A": `$HOME \n "x"`},
+		},
+		{
+			name: "should read multiline values",
+			in:   "KEY=\"-----BEGIN KEY-----\nabc\n-----END KEY-----\"\nB='x\ny'\nC=after",
+			want: map[string]string{"KEY": "-----BEGIN KEY-----\nabc\n-----END KEY-----", "B": "x\ny", "C": "after"},
+		},
+		{
+			name: "should expand variables",
+			in:   "A=1\nB=${A}-$A-${HOME}/data\nC=\"$B\"\nD=${MISSING}|$MISSING|${EMPTY:-fallback}|${A:-fallback}",
+			want: map[string]string{"A": "1", "B": "1-1-/home/ml/data", "C": "1-1-/home/ml/data", "D": "||fallback|1"},
+		},
+		{
+			name: "should not expand escaped dollar signs",
+			in:   `A=\$HOME` + "\n" + `B="\$HOME costs $5"` + "\nC=$",
+			want: map[string]string{"A": "$HOME", "B": "$HOME costs $5", "C": "$"},
+		},
+		{
+			name: "should read CRLF line endings",
+			in:   "A=1\r\nB=\"2\"\r\n",
+			want: map[string]string{"A": "1", "B": "2"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseDotEnv(strings.NewReader(c.in), lookup)
+			require.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+
+	errorCases := []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{"missing equal sign", "A=1\nB", `line 2: expected KEY=VALUE, got "B"`},
+		{"invalid key", "\n1A=1", `line 2: invalid key "1A", keys are letters, digits and underscores`},
+		{"empty key", "=1", `line 1: invalid key "", keys are letters, digits and underscores`},
+		{"unterminated quote", "A=1\nB=\"abc\nC=2", `line 2: unterminated "-quoted value`},
+		{"text after quote", "A=\"a\nb\" c", `line 2: unexpected "c" after the closing quote`},
+		{"unterminated expansion", "A=1\n\nB=${A", `line 3: unterminated ${ in "${A"`},
+		{"invalid expansion", "A=${A-B}", `line 1: invalid variable "${A-B}"`},
+	}
+	for _, c := range errorCases {
+		t.Run("should fail for "+c.name, func(t *testing.T) {
+			_, err := ParseDotEnv(strings.NewReader(c.in), lookup)
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestUnmarshalDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("export UNWEAVE_PROJECT_TOKEN=\"uwp_abc==\" # from the dashboard\nUNWEAVE_SSH_KEY_NAME=laptop\n"), 0600))
+
+	var s Secrets
+	require.NoError(t, readAndUnmarshal(path, &s))
+	assert.Equal(t, Secrets{ProjectToken: "uwp_abc==", SSHKeyName: "laptop"}, s)
+
+	require.NoError(t, os.WriteFile(path, []byte("UNWEAVE_PROJECT_TOKEN='abc\n"), 0600))
+	assert.EqualError(t, readAndUnmarshal(path, &s), path+": line 1: unterminated '-quoted value")
+}
+
+func TestExpandEnvValue(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "HF_TOKEN" {
+			return "hf_abc", true
+		}
+		return "", false
+	}
+
+	cases := []struct {
+		in, want string
+	}{
+		{"${HF_TOKEN}", "hf_abc"},
+		{"Bearer ${HF_TOKEN}", "Bearer hf_abc"},
+		{"${MISSING:-fallback}", "fallback"},
+		{"${MISSING}", ""},
+		{"pa$word", "pa$word"},
+		{"$HF_TOKEN", "$HF_TOKEN"},
+		{"cost: 5$", "cost: 5$"},
+		{"$${HF_TOKEN}", "${HF_TOKEN}"},
+		{"a$$b", "a$b"},
+	}
+	for _, c := range cases {
+		got, err := ExpandEnvValue(c.in, lookup)
+		require.NoError(t, err, c.in)
+		assert.Equal(t, c.want, got, c.in)
+	}
+
+	_, err := ExpandEnvValue("${HF_TOKEN", lookup)
+	assert.EqualError(t, err, `unterminated ${ in "${HF_TOKEN", use $$ for a dollar sign`)
+	_, err = ExpandEnvValue("${HF-TOKEN}", lookup)
+	assert.EqualError(t, err, `invalid variable "${HF-TOKEN}"`)
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// File is a config file that can be validated and edited by key. Edits keep the
+// comments and layout of the file.
+type File struct {
+	Path   string
+	schema schema
+	doc    *document
+}
+
+// OpenProjectConfig opens the config.toml of the active project.
+func OpenProjectConfig() (*File, error) {
+	if _, err := GetActiveProjectPath(); err != nil {
+		return nil, err
+	}
+	return openFile(projectConfigPath, projectSchema)
+}
+
+// OpenUserConfig opens the config file of the active context.
+func OpenUserConfig() (*File, error) {
+	return openFile(unweaveConfigPath, userSchema)
+}
+
+func openFile(path string, s schema) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Path: path, schema: s}
+	if f.doc, err = parseDocument(data); err != nil {
+		// Validate reports syntax errors with their line.
+		f.doc = &document{data: data}
+	}
+	return f, nil
+}
+
+// Validate returns the issues with the file, sorted by line.
+func (f *File) Validate() []Issue {
+	return f.schema.validate(f.Path, f.doc.data)
+}
+
+// Get returns the value of the key, e.g. specs.default.gpu.type. Elements of arrays of
+// tables are selected by name or index. Strings are returned as is and other values as
+// TOML, or JSON for arrays.
+func (f *File) Get(key string) (string, error) {
+	if err := f.checkSyntax(); err != nil {
+		return "", err
+	}
+	_, path, _, err := resolveKey(f.doc, f.schema, key, false)
+	if err != nil {
+		return "", err
+	}
+	v, ok := f.doc.lookup(path)
+	if !ok {
+		return "", fmt.Errorf("%s isn't set", key)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case map[string]any:
+		buf, err := toml.Marshal(v)
+		return strings.TrimSpace(string(buf)), err
+	case []any:
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// Set sets the key to value, which is parsed according to the type of the key. Arrays
+// are set from comma-separated values. Setting a key of an element of an array of tables
+// that doesn't exist adds it, e.g. specs.big.gpu.count adds a spec named big. Set fails
+// if the change would make the file invalid.
+func (f *File) Set(key, value string) error {
+	if err := f.checkSyntax(); err != nil {
+		return err
+	}
+	doc, path, t, err := resolveKey(f.doc, f.schema, key, true)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeValue(value, t)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	data := doc.set(path, encoded)
+	if doc, err = parseDocument(data); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	before := map[string]bool{}
+	for _, issue := range f.Validate() {
+		before[issue.Key+": "+issue.Message] = true
+	}
+	for _, issue := range f.schema.validate(f.Path, data) {
+		if !before[issue.Key+": "+issue.Message] {
+			return fmt.Errorf("%s: %s", issue.Key, issue.Message)
+		}
+	}
+	f.doc = doc
+	return nil
+}
+
+// Save writes the file.
+func (f *File) Save() error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(f.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(f.Path, f.doc.data, mode)
+}
+
+func (f *File) checkSyntax() error {
+	if f.doc.values == nil {
+		if issues := f.Validate(); len(issues) > 0 {
+			return fmt.Errorf("%s", issues[0])
+		}
+	}
+	return nil
+}
+
+// resolveKey returns the path in doc and the type of the key. Elements of arrays of
+// tables are selected by name or index. If create is set, elements selected by a name no
+// element has are added, and the document they were added to is returned.
+func resolveKey(doc *document, s schema, key string, create bool) (*document, []string, reflect.Type, error) {
+	segments := strings.Split(key, ".")
+	t := reflect.TypeOf(s.newTarget())
+	var path []string
+
+	for i := 0; i < len(segments); i++ {
+		seg := segments[i]
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if seg == "" {
+			return nil, nil, nil, fmt.Errorf("invalid key %q", key)
+		}
+
+		switch {
+		case t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType):
+			field, ok := fieldByKey(t, seg)
+			if !ok {
+				return nil, nil, nil, fmt.Errorf("unknown key %q", strings.Join(segments[:i+1], "."))
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			path = append(path, name)
+			t = field.Type
+
+		case t.Kind() == reflect.Map:
+			path = append(path, seg)
+			t = t.Elem()
+
+		case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+			index, ok := doc.elementIndex(path, seg)
+			if !ok {
+				if _, err := strconv.Atoi(seg); err == nil || !create {
+					return nil, nil, nil, fmt.Errorf("no element %s in %s", seg, strings.Join(segments[:i], "."))
+				}
+				// Add the element and select it again.
+				var err error
+				if doc, err = parseDocument(doc.appendElement(path, seg)); err != nil {
+					return nil, nil, nil, err
+				}
+				i--
+				continue
+			}
+			path = append(path, strconv.Itoa(index))
+			t = t.Elem()
+
+		default:
+			return nil, nil, nil, fmt.Errorf("unknown key %q", strings.Join(segments[:i+1], "."))
+		}
+	}
+	return doc, path, t, nil
+}
+
+// encodeValue returns value as a TOML value of type t.
+func encodeValue(value string, t reflect.Type) (string, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		if _, err := strconv.Atoi(value); err == nil {
+			return value, nil
+		}
+		return quoteString(value), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return quoteString(value), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q isn't true or false", value)
+		}
+		return strconv.FormatBool(b), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%q isn't an integer", value)
+		}
+		return strconv.Itoa(n), nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q isn't a number", value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			break
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, quoteString(item))
+			}
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("is a table, set one of its keys instead")
+}
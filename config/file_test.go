@@ -0,0 +1,271 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fileFixture = `# The project
+project_uri = "owner/project" # linked by unweave link
+default_provider = 'unweave'
+
+[provider.unweave]
+
+[[specs]]
+name = "default"
+ttl = "4h"
+[specs.cpu]
+type = "x86_64" # only x86_64 is supported
+count = 2
+
+[[specs]]
+name = "gpu"
+extends = "default"
+[specs.gpu]
+type = "rtx_4000"
+count = "1-4"
+[specs.fallback]
+gpu_types = [
+  "a100", # cheaper
+  "h100",
+]
+
+[sessions]
+sync = false
+`
+
+func openFixture(t *testing.T, content string) *File {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0640))
+	f, err := openFile(path, projectSchema)
+	require.NoError(t, err)
+	return f
+}
+
+func TestFileGet(t *testing.T) {
+	f := openFixture(t, fileFixture)
+
+	cases := map[string]string{
+		"project_uri":                  "owner/project",
+		"specs.default.cpu.type":       "x86_64",
+		"specs.DEFAULT.cpu.count":      "2",
+		"specs.1.gpu.count":            "1-4",
+		"specs.gpu.fallback.gpu_types": `["a100","h100"]`,
+		"sessions.sync":                "false",
+		"specs.gpu.gpu":                "count = '1-4'\ntype = 'rtx_4000'",
+	}
+	for key, want := range cases {
+		t.Run(key, func(t *testing.T) {
+			got, err := f.Get(key)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	errorCases := map[string]string{
+		"specs.default.gpu.type": "specs.default.gpu.type isn't set",
+		"specs.big.gpu.type":     "no element big in specs",
+		"specs.default.cpu.arch": `unknown key "specs.default.cpu.arch"`,
+		"sessions.sync.on":       `unknown key "sessions.sync.on"`,
+	}
+	for key, want := range errorCases {
+		t.Run(key, func(t *testing.T) {
+			_, err := f.Get(key)
+			assert.EqualError(t, err, want)
+		})
+	}
+}
+
+func TestFileSet(t *testing.T) {
+	cases := []struct {
+		name       string
+		key, value string
+		// want is the fixture with old replaced by new.
+		old, new string
+	}{
+		{
+			name: "should replace a value and keep its comment",
+			key:  "specs.default.cpu.type", value: "arm64",
+			old: `type = "x86_64" # only`, new: `type = "arm64" # only`,
+		},
+		{
+			name: "should replace a multiline array",
+			key:  "specs.gpu.fallback.gpu_types", value: "a100, rtx_5000",
+			old: "gpu_types = [\n  \"a100\", # cheaper\n  \"h100\",\n]", new: `gpu_types = ["a100", "rtx_5000"]`,
+		},
+		{
+			name: "should write ranges as integers if they are exact",
+			key:  "specs.gpu.gpu.count", value: "4",
+			old: `count = "1-4"`, new: `count = 4`,
+		},
+		{
+			name: "should add a key to its table",
+			key:  "specs.default.gpu.type", value: "a100",
+			old: "ttl = \"4h\"\n", new: "ttl = \"4h\"\ngpu.type = \"a100\"\n",
+		},
+		{
+			name: "should add a key to the root table",
+			key:  "default_provider", value: "lambdalabs",
+			old: `default_provider = 'unweave'`, new: `default_provider = "lambdalabs"`,
+		},
+		{
+			name: "should add a table",
+			key:  "budget.on_exceed", value: "block",
+			old: "sync = false\n", new: "sync = false\n\n[budget]\non_exceed = \"block\"\n",
+		},
+		{
+			name: "should add a spec",
+			key:  "specs.big.gpu.count", value: ">=8",
+			old: "sync = false\n", new: "sync = false\n\n[[specs]]\nname = \"big\"\ngpu.count = \">=8\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := openFixture(t, fileFixture)
+			require.NoError(t, f.Set(c.key, c.value))
+			require.NoError(t, f.Save())
+
+			buf, err := os.ReadFile(f.Path)
+			require.NoError(t, err)
+			require.Contains(t, fileFixture, c.old)
+			assert.Equal(t, strings.Replace(fileFixture, c.old, c.new, 1), string(buf))
+
+			got, err := f.Get(c.key)
+			require.NoError(t, err)
+			if c.key != "specs.gpu.fallback.gpu_types" {
+				assert.Equal(t, c.value, got)
+			}
+			assert.Empty(t, f.Validate())
+
+			info, err := os.Stat(f.Path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+		})
+	}
+
+	errorCases := map[string]struct{ key, value, err string }{
+		"type":     {"sessions.sync", "maybe", `sessions.sync: "maybe" isn't true or false`},
+		"range":    {"specs.default.cpu.count", "4-1", "specs.default.cpu.count: 4 is more than 1"},
+		"table":    {"specs.default.cpu", "x", "specs.default.cpu: is a table, set one of its keys instead"},
+		"unknown":  {"sessions.theme", "dark", `unknown key "sessions.theme"`},
+		"value":    {"budget.on_exceed", "warn", `budget.on_exceed: "warn" should be prompt or block`},
+		"provider": {"default_provider", "gcp", `default_provider: unknown provider "gcp", should be one of unweave, lambdalabs, aws`},
+	}
+	for name, c := range errorCases {
+		t.Run("should fail for an invalid "+name, func(t *testing.T) {
+			f := openFixture(t, fileFixture)
+			assert.EqualError(t, f.Set(c.key, c.value), c.err)
+			got, err := f.Get("default_provider")
+			require.NoError(t, err)
+			assert.Equal(t, "unweave", got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("should accept the fixture", func(t *testing.T) {
+		assert.Empty(t, openFixture(t, fileFixture).Validate())
+	})
+
+	t.Run("should report issues with their line", func(t *testing.T) {
+		f := openFixture(t, `project_uri = "project"
+default_provider = "gcp"
+colour = "blue"
+
+[provider.azure]
+
+[[specs]]
+name = "default"
+ttl = "forever"
+[specs.gpu]
+count = "lots"
+[specs.fallback]
+providers = ["aws", "gcp"]
+
+[[specs]]
+name = "Default"
+extends = "other"
+
+[budget]
+monthly = -1
+on_exceed = "warn"
+
+[sessions]
+scp = "yes"
+`)
+		var got []string
+		for _, issue := range f.Validate() {
+			assert.Equal(t, f.Path, issue.File)
+			issue.File = "config.toml"
+			got = append(got, issue.String())
+		}
+		// Type errors are reported first, as values can't be checked until they're fixed.
+		assert.Equal(t, []string{
+			"config.toml:3: colour: unknown key",
+			"config.toml:24: sessions.scp: expected a boolean, got a string",
+		}, got)
+
+		require.NoError(t, os.WriteFile(f.Path, []byte(strings.NewReplacer(`colour = "blue"`, "", `scp = "yes"`, "").Replace(string(f.doc.data))), 0600))
+		f, err := openFile(f.Path, projectSchema)
+		require.NoError(t, err)
+		got = nil
+		for _, issue := range f.Validate() {
+			issue.File = "config.toml"
+			got = append(got, issue.String())
+		}
+		assert.Equal(t, []string{
+			`config.toml:1: project_uri: "project" should be of the form <owner>/<project>`,
+			`config.toml:2: default_provider: unknown provider "gcp", should be one of unweave, lambdalabs, aws`,
+			"config.toml:5: provider.azure: unknown provider, should be one of unweave, lambdalabs, aws",
+			`config.toml:9: specs.default.ttl: invalid duration "forever", should be like 30m, 4h or 2d`,
+			`config.toml:11: specs.default.gpu.count: "lots" isn't a whole number like 4, a range like 1-4, >=24 or <=8`,
+			`config.toml:13: specs.default.fallback.providers: unknown provider "gcp", should be one of unweave, lambdalabs, aws`,
+			`config.toml:16: specs.Default.name: another spec is named "default"`,
+			`config.toml:17: specs.Default.extends: no spec named "other"`,
+			"config.toml:20: budget.monthly: can't be negative",
+			`config.toml:21: budget.on_exceed: "warn" should be prompt or block`,
+		}, got)
+	})
+
+	t.Run("should report cycles", func(t *testing.T) {
+		f := openFixture(t, "[[specs]]\nname = \"a\"\nextends = \"b\"\n\n[[specs]]\nname = \"b\"\nextends = \"a\"\n")
+		var got []string
+		for _, issue := range f.Validate() {
+			got = append(got, issue.Key+": "+issue.Message)
+		}
+		assert.Equal(t, []string{"specs.a.extends: a -> b -> a is a cycle", "specs.b.extends: b -> a -> b is a cycle"}, got)
+	})
+
+	t.Run("should report invalid environment variable names", func(t *testing.T) {
+		issues := openFixture(t, "[env]\nHF_TOKEN = \"${HF_TOKEN}\"\n\"MY-VAR\" = \"x\"\n").Validate()
+		require.Len(t, issues, 1)
+		assert.Equal(t, Issue{File: issues[0].File, Line: 3, Key: "env.MY-VAR", Message: "invalid environment variable name, names are letters, digits and underscores"}, issues[0])
+	})
+
+	t.Run("should report syntax errors", func(t *testing.T) {
+		issues := openFixture(t, "project_uri = \"owner/project\"\n[specs\n").Validate()
+		require.Len(t, issues, 1)
+		assert.Equal(t, 2, issues[0].Line)
+	})
+
+	t.Run("should check the user config", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("api_url = \"localhost:4000\"\nssh_transport = \"openssh\"\n[user]\nid = \"u\"\n"), 0600))
+		f, err := openFile(path, userSchema)
+		require.NoError(t, err)
+		var got []string
+		for _, issue := range f.Validate() {
+			got = append(got, issue.Key+": "+issue.Message)
+		}
+		assert.Equal(t, []string{
+			`api_url: "localhost:4000" should be an http or https URL`,
+			`ssh_transport: "openssh" should be native or binary`,
+		}, got)
+	})
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // All can be used across multiple commands. Example: unweave ls --all to list all projects
 var All = false
 
@@ -10,12 +12,18 @@ var AuthToken = ""
 // BuildID is the ID of the build to use when running commands that require a build.
 var BuildID = ""
 
+// ContextName is the name of the context to use instead of the default one.
+var ContextName = ""
+
 // CreateExec is used to denote whether to create a new exec when running commands that require a exec.
 var CreateExec = true
 
 // ExecAttach is used to determine if the exec command should stay attached to the exec after starting the command.
 var ExecAttach = false
 
+// ExecWait is used to determine if the exec command should wait for a detached command to finish and exit with its exit code.
+var ExecWait = false
+
 // SpecName is the name of the spec from config to use.
 var SpecName string
 
@@ -44,6 +52,19 @@ var Memory int
 // HDD is the amount of storage to allocate in GB.
 var HDD int
 
+// Labels are the key=value labels to attach to a new session.
+var Labels []string
+
+// LoginNoBrowser denotes whether login should print the pairing URL instead of opening a
+// browser
+var LoginNoBrowser = false
+
+// LoginQR denotes whether login should print the pairing URL as a QR code too
+var LoginQR = false
+
+// LoginWithToken denotes whether login should read a token from stdin instead of pairing
+var LoginWithToken = false
+
 // NodeRegion is the region to use when creating a new session
 var NodeRegion = ""
 
@@ -72,6 +93,10 @@ var SSHConnectionOptions []string
 // NoCopySource is a bool to denote whether to copy the source code to the session
 var NoCopySource = true
 
+// SyncPull denotes whether `unweave sync` copies changes on the session back to the
+// project directory
+var SyncPull = false
+
 // Volumes is a list of volumes to mount to the session
 var Volumes []string
 
@@ -82,3 +107,98 @@ var FollowLogs = false
 
 // OutputJSON denotes if the output should be in JSON format
 var OutputJSON = false
+
+// DevAPIAddr is the address the fake API server of the dev-api command listens on.
+var DevAPIAddr = ""
+
+// DevAPISSHAddr is the host:port of the SSH server sessions created on the fake API
+// server connect to.
+var DevAPISSHAddr = ""
+
+// WaitTimeout is how long to wait for a session to be running. Zero waits indefinitely.
+var WaitTimeout time.Duration
+
+// TTL is how long after it's created a new session is terminated, e.g. 4h.
+var TTL = ""
+
+// IdleTimeout is how long a new session may be idle before it's terminated, e.g. 30m.
+var IdleTimeout = ""
+
+// WaitForCapacity denotes whether to wait for capacity if the provider is out of
+// capacity for a new session.
+var WaitForCapacity = false
+
+// MaxWait is how long to wait for capacity at most. Zero waits indefinitely.
+var MaxWait time.Duration
+
+// Notify are the targets notified once a session is created after waiting for capacity,
+// either desktop or webhook URLs.
+var Notify []string
+
+// ListLabelSelectors select the sessions listed by label, as key=value, key!=value or key.
+var ListLabelSelectors []string
+
+// ListStatuses select the sessions listed by status.
+var ListStatuses []string
+
+// ListProviders select the sessions listed by provider.
+var ListProviders []string
+
+// ListGPUTypes select the sessions listed by GPU type.
+var ListGPUTypes []string
+
+// ListNewerThan and ListOlderThan select the sessions listed by the time since they were
+// created, e.g. 2h or 3d.
+var ListNewerThan, ListOlderThan string
+
+// ListColumns are the columns of the session list.
+var ListColumns []string
+
+// ListSort is the column the session list is sorted by. A leading - sorts in descending
+// order.
+var ListSort = ""
+
+// Watch denotes whether a list should be re-rendered as it changes until interrupted.
+var Watch = false
+
+// WatchInterval is how often a watched list is refreshed.
+var WatchInterval time.Duration
+
+// TerminateSelectors select the sessions to terminate by label, as key=value, key!=value
+// or key.
+var TerminateSelectors []string
+
+// TerminateAllIdle denotes whether to terminate all idle sessions.
+var TerminateAllIdle = false
+
+// TerminateOlderThan selects the sessions to terminate by the time since they were
+// created, e.g. 6h.
+var TerminateOlderThan = ""
+
+// Parallelism is how many sessions bulk operations work on at once.
+var Parallelism int
+
+// Yes denotes whether to skip confirmation prompts.
+var Yes = false
+
+// GlobalConfig denotes whether the config commands use the user config of the active
+// context instead of the project config.
+var GlobalConfig = false
+
+// EnvVars are the environment variables to inject into a session, as KEY=VALUE, or KEY
+// to take the value from the local environment.
+var EnvVars []string
+
+// EnvFiles are .env files with environment variables to inject into a session.
+var EnvFiles []string
+
+// ResourcesFile is the resources manifest plan and apply use instead of the one of the
+// active project.
+var ResourcesFile = ""
+
+// Prune denotes whether apply deletes the volumes that aren't in the resources manifest.
+var Prune = false
+
+// MaxContextSize is the largest build context to upload, e.g. 500MB. It overrides
+// build.max_context_size in the project config.
+var MaxContextSize = ""
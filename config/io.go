@@ -1,8 +1,8 @@
 package config
 
 import (
-	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -13,7 +13,7 @@ import (
 
 func createDir(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return os.MkdirAll(path, os.ModePerm)
+		return os.MkdirAll(path, 0700)
 	} else if err != nil {
 		return err
 	}
@@ -27,14 +27,9 @@ func unmarshalDotEnv(path string, config interface{}) error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	data := make(map[string]string)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, "=")
-		if len(parts) == 2 {
-			data[parts[0]] = parts[1]
-		}
+	data, err := ParseDotEnv(file, os.LookupEnv)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
 	}
 
 	configDataValue := reflect.ValueOf(config).Elem()
@@ -70,8 +65,8 @@ func readAndUnmarshal[T any](path string, config *T) error {
 	return nil
 }
 
-// marshalAndWrite marshals a RootConfig struct and writes it to disk. It reloads the
-// config variable after writing.
+// marshalAndWrite marshals a RootConfig struct and writes it to disk. The file is only
+// readable by the user.
 func marshalAndWrite[T any](path string, config *T) error {
 	if err := createDir(filepath.Dir(path)); err != nil {
 		return err
@@ -81,8 +76,9 @@ func marshalAndWrite[T any](path string, config *T) error {
 		return err
 	}
 
-	if err = os.WriteFile(path, buf, os.ModePerm); err != nil {
+	if err = os.WriteFile(path, buf, 0600); err != nil {
 		return err
 	}
-	return nil
+	// WriteFile keeps the mode of existing files, which older versions created world-readable
+	return os.Chmod(path, 0600)
 }
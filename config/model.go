@@ -2,16 +2,18 @@ package config
 
 import (
 	_ "embed"
+	"fmt"
 
 	"github.com/pelletier/go-toml/v2"
-	"github.com/unweave/cli/ui"
 )
 
 type (
 	user struct {
 		ID    string `toml:"id"`
 		Email string `toml:"email"`
-		Token string `toml:"token"`
+		// Token is kept in the credential store. It's only read from the config file to
+		// move tokens saved by older versions.
+		Token string `toml:"token,omitempty"`
 	}
 
 	Secrets struct {
@@ -24,35 +26,85 @@ type (
 	}
 
 	Spec struct {
-		Name string        `toml:"name"`
-		CPU  specResources `toml:"cpu"`
-		GPU  specResources `toml:"gpu"`
-		HDD  specHDD       `toml:"hdd"`
+		Name string `toml:"name"`
+		// Extends is the name of a spec this spec inherits the fields it doesn't set from.
+		Extends string        `toml:"extends,omitempty"`
+		CPU     specResources `toml:"cpu"`
+		GPU     specResources `toml:"gpu"`
+		HDD     specHDD       `toml:"hdd"`
+		// TTL and IdleTimeout limit how long sessions created with the spec run, e.g. 4h.
+		TTL         string `toml:"ttl,omitempty"`
+		IdleTimeout string `toml:"idle_timeout,omitempty"`
+		// Fallback is where sessions created with the spec may go if the provider is out
+		// of capacity.
+		Fallback *specFallback `toml:"fallback,omitempty"`
 	}
 
 	specResources struct {
 		Type   string `toml:"type"`
-		Count  int    `toml:"count"`
-		Memory int    `toml:"memory"`
+		Count  Range  `toml:"count"`
+		Memory Range  `toml:"memory"`
 	}
 
 	specHDD struct {
-		Size int `toml:"size"`
+		Size Range `toml:"size"`
+	}
+
+	// specFallback lists, in order of preference, the GPU types, regions and providers a
+	// session may fall back to if the provider is out of capacity.
+	specFallback struct {
+		GPUTypes  []string `toml:"gpu_types,omitempty"`
+		Regions   []string `toml:"regions,omitempty"`
+		Providers []string `toml:"providers,omitempty"`
+		// MaxHourlyPrice skips fallbacks that cost more per hour in USD. Defaults to the
+		// max_hourly_price of the budget.
+		MaxHourlyPrice float64 `toml:"max_hourly_price,omitempty"`
+	}
+
+	budget struct {
+		// Monthly is the most the sessions of the project should cost in the current
+		// calendar month in USD. The API doesn't report how long terminated sessions ran,
+		// so they count as long as this machine saw them running.
+		Monthly float64 `toml:"monthly"`
+		// MaxHourlyPrice is the most a session should cost per hour in USD.
+		MaxHourlyPrice float64 `toml:"max_hourly_price"`
+		// OnExceed is "prompt" to ask before going over the budget, or "block" to refuse.
+		OnExceed string `toml:"on_exceed"`
+	}
+
+	build struct {
+		// MaxContextSize is the largest build context to upload, e.g. 500MB. Zero means
+		// there's no limit.
+		MaxContextSize string `toml:"max_context_size,omitempty"`
+	}
+
+	sessions struct {
+		SCP    bool   `toml:"scp"`
+		Sync   bool   `toml:"sync"`
+		Editor string `toml:"editor"`
 	}
 
 	Project struct {
-		URI             string              `toml:"project_uri"`
-		Env             *Secrets            `toml:"env"`
-		Providers       map[string]provider `toml:"provider"`
-		Specs           []Spec              `toml:"specs"`
-		DefaultProvider string              `toml:"default_provider"`
+		URI string `toml:"project_uri"`
+		// Env is read from the .env file of the project.
+		Env       *Secrets            `toml:"-"`
+		Providers map[string]provider `toml:"provider"`
+		Specs     []Spec              `toml:"specs"`
+		// SessionEnv are the environment variables injected into new sessions. Values may
+		// refer to local environment variables as ${VAR}, see ExpandEnvValue.
+		SessionEnv      map[string]string `toml:"env,omitempty"`
+		DefaultProvider string            `toml:"default_provider"`
+		Sessions        sessions          `toml:"sessions"`
+		Budget          budget            `toml:"budget"`
+		Build           build             `toml:"build,omitempty"`
 	}
 
 	unweave struct {
-		UnwEnv string `toml:"unweave_env" env:"UNWEAVE_ENV"`
-		ApiURL string `toml:"api_url" env:"UNWEAVE_API_URL"`
-		AppURL string `toml:"app_url" env:"UNWEAVE_APP_URL"`
-		User   *user  `toml:"user"`
+		UnwEnv       string `toml:"unweave_env" env:"UNWEAVE_ENV"`
+		ApiURL       string `toml:"api_url" env:"UNWEAVE_API_URL"`
+		AppURL       string `toml:"app_url" env:"UNWEAVE_APP_URL"`
+		SSHTransport string `toml:"ssh_transport,omitempty" env:"UNWEAVE_SSH_TRANSPORT"`
+		User         *user  `toml:"user"`
 	}
 
 	config struct {
@@ -61,24 +113,47 @@ type (
 	}
 )
 
-func (c *config) String() string {
-	buf, err := toml.Marshal(c)
+// String returns the config as TOML without the token.
+func (c *config) String() (string, error) {
+	redacted := *c
+	if c.Unweave != nil && c.Unweave.User != nil {
+		redacted.Unweave = c.Unweave.withoutToken()
+	}
+	buf, err := toml.Marshal(&redacted)
 	if err != nil {
-		ui.Errorf("Failed to marshal config: %s", err)
+		return "", fmt.Errorf("failed to marshal config: %w", err)
 	}
-	return string(buf)
+	return string(buf), nil
 }
 
+// Save writes the config of the active context. The token goes into the credential
+// store, or is removed from it if it's empty.
 func (c *unweave) Save() error {
-	return marshalAndWrite(unweaveConfigPath, c)
+	store := CredentialStore()
+	if c.User.Token == "" {
+		if err := store.Delete(ActiveContext.Name); err != nil {
+			return err
+		}
+	} else if err := store.Set(ActiveContext.Name, c.User.Token); err != nil {
+		return err
+	}
+	return marshalAndWrite(unweaveConfigPath, c.withoutToken())
+}
+
+func (c *unweave) withoutToken() *unweave {
+	u := *c
+	usr := *c.User
+	usr.Token = ""
+	u.User = &usr
+	return &u
 }
 
-func (c *Project) String() string {
+func (c *Project) String() (string, error) {
 	buf, err := toml.Marshal(c)
 	if err != nil {
-		ui.Errorf("Failed to marshal config: %s", err)
+		return "", fmt.Errorf("failed to marshal config: %w", err)
 	}
-	return string(buf)
+	return string(buf), nil
 }
 
 func (c *Project) Save() error {
@@ -54,7 +54,7 @@ func WriteEnvConfig() error {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
-	if err := os.WriteFile(envConfigPath, buf.Bytes(), 0644); err != nil {
+	if err := os.WriteFile(envConfigPath, buf.Bytes(), 0600); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ResourcesFileNames are the paths, relative to the project directory, the resources
+// manifest is looked up at, in order.
+var ResourcesFileNames = []string{filepath.Join(ProjectConfigDirName, "resources.toml"), "unweave.toml"}
+
+// DefaultEndpointPort is the port endpoints of the resources manifest expose if they
+// don't set one. It's the default of unweave deploy.
+const DefaultEndpointPort = 8080
+
+// Resources is the resources manifest of a project. It declares the volumes and
+// endpoints the project should have, for unweave plan and unweave apply.
+type Resources struct {
+	Volumes   []VolumeResource   `toml:"volumes"`
+	Endpoints []EndpointResource `toml:"endpoints"`
+}
+
+// VolumeResource is a volume declared in the resources manifest.
+type VolumeResource struct {
+	Name string `toml:"name" json:"name"`
+	// Size is in GB.
+	Size     int    `toml:"size" json:"size"`
+	Provider string `toml:"provider" json:"provider"`
+}
+
+// EndpointResource is an endpoint declared in the resources manifest. It's deployed
+// like unweave deploy does, by running Command on a new session.
+type EndpointResource struct {
+	Name    string `toml:"name" json:"name"`
+	Command string `toml:"command" json:"command"`
+	Spec    string `toml:"spec" json:"spec"`
+	Port    int32  `toml:"port" json:"port"`
+	// Evals are the IDs of the evals to attach to the endpoint.
+	Evals []string `toml:"evals" json:"evals,omitempty"`
+}
+
+var resourcesSchema = schema{
+	newTarget: func() any { return &Resources{} },
+	check:     func(target any, c *checker) { checkResources(target.(*Resources), c) },
+}
+
+// FindResourcesFile returns the path of the resources manifest of the active project.
+func FindResourcesFile() (string, error) {
+	dir, err := GetActiveProjectPath()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range ResourcesFileNames {
+		path := filepath.Join(dir, name)
+		if _, err = os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no resources manifest found, create %s or %s",
+		filepath.Join(dir, ResourcesFileNames[0]), filepath.Join(dir, ResourcesFileNames[1]))
+}
+
+// LoadResources reads a resources manifest and fills in the defaults: volumes are
+// DefaultVolumeSize GB on the default provider of the project, and endpoints run on the
+// default spec and expose DefaultEndpointPort. If the manifest is invalid, its issues
+// are returned instead.
+func LoadResources(path string) (*Resources, []Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if issues := resourcesSchema.validate(path, data); len(issues) > 0 {
+		return nil, issues, nil
+	}
+
+	r := &Resources{}
+	if err = toml.Unmarshal(data, r); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range r.Volumes {
+		v := &r.Volumes[i]
+		if v.Size == 0 {
+			v.Size = DefaultVolumeSize
+		}
+		if v.Provider == "" {
+			v.Provider = Config.Project.DefaultProvider
+		}
+	}
+	for i := range r.Endpoints {
+		e := &r.Endpoints[i]
+		e.Name = strings.ReplaceAll(e.Name, "_", "-")
+		if e.Spec == "" {
+			e.Spec = "default"
+		}
+		if e.Port == 0 {
+			e.Port = DefaultEndpointPort
+		}
+	}
+	return r, nil, nil
+}
+
+func checkResources(r *Resources, c *checker) {
+	for i, v := range r.Volumes {
+		path := []string{"volumes", strconv.Itoa(i)}
+		at := func(key string) []string { return append(path[:2:2], key) }
+
+		if v.Name == "" {
+			c.add(path, "name is required")
+		}
+		for _, other := range r.Volumes[:i] {
+			if v.Name != "" && other.Name == v.Name {
+				c.add(at("name"), "another volume is named %q", other.Name)
+				break
+			}
+		}
+		if v.Size < 0 {
+			c.add(at("size"), "can't be negative")
+		}
+		if v.Provider != "" && !isProvider(v.Provider) {
+			c.add(at("provider"), "unknown provider %q, should be one of %s", v.Provider, providerList())
+		}
+	}
+
+	for i, e := range r.Endpoints {
+		path := []string{"endpoints", strconv.Itoa(i)}
+		at := func(key string) []string { return append(path[:2:2], key) }
+
+		if e.Name == "" {
+			c.add(path, "name is required")
+		}
+		for _, other := range r.Endpoints[:i] {
+			if e.Name != "" && strings.EqualFold(other.Name, e.Name) {
+				c.add(at("name"), "another endpoint is named %q", other.Name)
+				break
+			}
+		}
+		if strings.TrimSpace(e.Command) == "" {
+			c.add(path, "command is required")
+		}
+		if e.Spec != "" {
+			if _, err := ResolveSpec(e.Spec, Config.Project.Specs); errors.Is(err, ErrSpecNotFound) {
+				c.add(at("spec"), "no spec named %q in %s", e.Spec, filepath.Join(ProjectConfigDirName, "config.toml"))
+			}
+		}
+		if e.Port < 0 || e.Port > 65535 {
+			c.add(at("port"), "%d isn't a port", e.Port)
+		}
+		for j, id := range e.Evals {
+			if id == "" {
+				c.add(at("evals"), "eval IDs can't be empty")
+			}
+			for _, other := range e.Evals[:j] {
+				if id != "" && other == id {
+					c.add(at("evals"), "eval %q is listed twice", id)
+					break
+				}
+			}
+		}
+	}
+}
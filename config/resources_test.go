@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResources(t *testing.T) {
+	project := Config.Project
+	Config.Project = &Project{DefaultProvider: "unweave", Specs: []Spec{{Name: "default"}, {Name: "gpu"}}}
+	t.Cleanup(func() { Config.Project = project })
+
+	write := func(t *testing.T, data string) string {
+		path := filepath.Join(t.TempDir(), "resources.toml")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		return path
+	}
+
+	t.Run("should fill in the defaults", func(t *testing.T) {
+		r, issues, err := LoadResources(write(t, `[[volumes]]
+name = "data"
+
+[[volumes]]
+name = "weights"
+size = 100
+provider = "aws"
+
+[[endpoints]]
+name = "my_api"
+command = "python serve.py"
+
+[[endpoints]]
+name = "batch"
+command = "python batch.py"
+spec = "gpu"
+port = 9000
+evals = ["evl_1"]
+`))
+		require.NoError(t, err)
+		require.Empty(t, issues)
+		assert.Equal(t, &Resources{
+			Volumes: []VolumeResource{
+				{Name: "data", Size: DefaultVolumeSize, Provider: "unweave"},
+				{Name: "weights", Size: 100, Provider: "aws"},
+			},
+			Endpoints: []EndpointResource{
+				{Name: "my-api", Command: "python serve.py", Spec: "default", Port: DefaultEndpointPort},
+				{Name: "batch", Command: "python batch.py", Spec: "gpu", Port: 9000, Evals: []string{"evl_1"}},
+			},
+		}, r)
+	})
+
+	t.Run("should report issues with their line", func(t *testing.T) {
+		path := write(t, `[[volumes]]
+name = "data"
+size = -1
+provider = "gcp"
+
+[[volumes]]
+name = "data"
+
+[[endpoints]]
+name = "api"
+spec = "tpu"
+port = 70000
+evals = ["evl_1", "evl_1"]
+`)
+		r, issues, err := LoadResources(path)
+		require.NoError(t, err)
+		assert.Nil(t, r)
+
+		var got []string
+		for _, issue := range issues {
+			issue.File = "resources.toml"
+			got = append(got, issue.String())
+		}
+		assert.Equal(t, []string{
+			"resources.toml:3: volumes.data.size: can't be negative",
+			`resources.toml:4: volumes.data.provider: unknown provider "gcp", should be one of unweave, lambdalabs, aws`,
+			`resources.toml:7: volumes.data.name: another volume is named "data"`,
+			"resources.toml:9: endpoints.api: command is required",
+			`resources.toml:11: endpoints.api.spec: no spec named "tpu" in .unweave/config.toml`,
+			"resources.toml:12: endpoints.api.port: 70000 isn't a port",
+			`resources.toml:13: endpoints.api.evals: eval "evl_1" is listed twice`,
+		}, got)
+	})
+
+	t.Run("should report unknown keys", func(t *testing.T) {
+		_, issues, err := LoadResources(write(t, "[[volumes]]\nname = \"data\"\nsize_gb = 10\n"))
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, 3, issues[0].Line)
+		assert.Equal(t, "volumes.data.size_gb: unknown key", issues[0].Key+": "+issues[0].Message)
+	})
+}
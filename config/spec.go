@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+var (
+	// ErrSpecNotFound is returned if no spec has the name asked for.
+	ErrSpecNotFound = errors.New("spec not found")
+	// ErrInvalidSpec is returned for specs that can't be used. The error names the spec
+	// and the field at fault.
+	ErrInvalidSpec = errors.New("invalid spec")
+)
+
+// Range is an amount of hardware in a spec: exact (4), between two amounts (1-4), at
+// least (>=24) or at most (<=8) an amount. It's read from integers and strings alike.
+type Range string
+
+// UnmarshalText keeps the text as is. Ranges are parsed when a spec is validated, so
+// that errors can name the spec.
+func (r *Range) UnmarshalText(text []byte) error {
+	*r = Range(text)
+	return nil
+}
+
+// Parse returns the bounds of the range. A zero max is unbounded, and the zero Range
+// is 0-0.
+func (r Range) Parse() (min, max int, err error) {
+	s := strings.TrimSpace(string(r))
+	switch {
+	case s == "":
+		return 0, 0, nil
+	case strings.HasPrefix(s, ">="):
+		min, err = parseAmount(s[2:])
+		return min, 0, err
+	case strings.HasPrefix(s, "<="):
+		max, err = parseAmount(s[2:])
+		return 0, max, err
+	}
+
+	lo, hi, isRange := strings.Cut(s, "-")
+	if min, err = parseAmount(lo); err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return min, min, nil
+	}
+	if max, err = parseAmount(hi); err != nil {
+		return 0, 0, err
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("%d is more than %d", min, max)
+	}
+	return min, max, nil
+}
+
+func parseAmount(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q isn't a whole number like 4, a range like 1-4, >=24 or <=8", s)
+	}
+	return n, nil
+}
+
+// ResolveSpec returns the spec named name with the specs it extends applied. Fields set
+// on a spec override those of the spec it extends. Names are case-insensitive. The spec
+// returned is validated and doesn't extend anything.
+func ResolveSpec(name string, specs []Spec) (Spec, error) {
+	var chain []Spec
+	for current := name; current != ""; {
+		spec, ok := lookupSpec(current, specs)
+		if !ok {
+			if len(chain) == 0 {
+				return Spec{}, fmt.Errorf("%w: %q", ErrSpecNotFound, name)
+			}
+			return Spec{}, fmt.Errorf("%w %q: extends: no spec named %q", ErrInvalidSpec, chain[len(chain)-1].Name, current)
+		}
+		for _, s := range chain {
+			if strings.EqualFold(s.Name, spec.Name) {
+				names := make([]string, 0, len(chain)+1)
+				for _, c := range chain {
+					names = append(names, c.Name)
+				}
+				names = append(names, spec.Name)
+				return Spec{}, fmt.Errorf("%w %q: extends: %s is a cycle", ErrInvalidSpec, chain[len(chain)-1].Name, strings.Join(names, " -> "))
+			}
+		}
+		chain = append(chain, spec)
+		current = spec.Extends
+	}
+
+	resolved := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		resolved = chain[i].override(resolved)
+	}
+	resolved.Extends = ""
+	return resolved, resolved.Validate()
+}
+
+func lookupSpec(name string, specs []Spec) (Spec, bool) {
+	for _, s := range specs {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
+
+// override returns base with the fields set on s.
+func (s Spec) override(base Spec) Spec {
+	set := func(v string, dst *string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	setRange := func(v Range, dst *Range) {
+		if v != "" {
+			*dst = v
+		}
+	}
+
+	set(s.Name, &base.Name)
+	set(s.Extends, &base.Extends)
+	set(s.CPU.Type, &base.CPU.Type)
+	setRange(s.CPU.Count, &base.CPU.Count)
+	setRange(s.CPU.Memory, &base.CPU.Memory)
+	set(s.GPU.Type, &base.GPU.Type)
+	setRange(s.GPU.Count, &base.GPU.Count)
+	setRange(s.GPU.Memory, &base.GPU.Memory)
+	setRange(s.HDD.Size, &base.HDD.Size)
+	set(s.TTL, &base.TTL)
+	set(s.IdleTimeout, &base.IdleTimeout)
+	if s.Fallback != nil {
+		base.Fallback = s.Fallback
+	}
+	return base
+}
+
+// Validate returns an error wrapping ErrInvalidSpec if a range of the spec is invalid.
+func (s Spec) Validate() error {
+	_, err := s.HardwareSpec()
+	return err
+}
+
+// HardwareSpec returns the hardware the spec asks for.
+func (s Spec) HardwareSpec() (types.HardwareSpec, error) {
+	var spec types.HardwareSpec
+	spec.CPU.Type = s.CPU.Type
+	spec.GPU.Type = s.GPU.Type
+
+	ranges := []struct {
+		field string
+		value Range
+		dst   *types.HardwareRequestRange
+	}{
+		{"cpu.count", s.CPU.Count, &spec.CPU.HardwareRequestRange},
+		{"cpu.memory", s.CPU.Memory, &spec.RAM},
+		{"gpu.count", s.GPU.Count, &spec.GPU.Count},
+		{"gpu.memory", s.GPU.Memory, &spec.GPU.RAM},
+		{"hdd.size", s.HDD.Size, &spec.HDD},
+	}
+	for _, r := range ranges {
+		min, max, err := r.value.Parse()
+		if err != nil {
+			return types.HardwareSpec{}, fmt.Errorf("%w %q: %s: %v", ErrInvalidSpec, s.Name, r.field, err)
+		}
+		*r.dst = types.HardwareRequestRange{Min: min, Max: max}
+	}
+	return spec, nil
+}
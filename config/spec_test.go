@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestRange(t *testing.T) {
+	cases := []struct {
+		in       Range
+		min, max int
+		err      bool
+	}{
+		{in: "", min: 0, max: 0},
+		{in: "4", min: 4, max: 4},
+		{in: "1-4", min: 1, max: 4},
+		{in: " 1 - 4 ", min: 1, max: 4},
+		{in: ">=24", min: 24, max: 0},
+		{in: "<=8", min: 0, max: 8},
+		{in: "4-1", err: true},
+		{in: "-1", err: true},
+		{in: ">24", err: true},
+		{in: "lots", err: true},
+		{in: "1-", err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.in), func(t *testing.T) {
+			min, max, err := c.in.Parse()
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.min, min)
+			assert.Equal(t, c.max, max)
+		})
+	}
+}
+
+func TestResolveSpec(t *testing.T) {
+	fixture := `
+[[specs]]
+name = "base"
+ttl = "4h"
+[specs.cpu]
+type = "x86_64"
+count = 4
+memory = ">=16"
+[specs.hdd]
+size = 50
+
+[[specs]]
+name = "gpu"
+extends = "base"
+[specs.gpu]
+type = "a100"
+count = "1-4"
+memory = ">=24"
+
+[[specs]]
+name = "big-gpu"
+extends = "GPU"
+idle_timeout = "30m"
+[specs.gpu]
+count = 8
+
+[[specs]]
+name = "broken"
+extends = "gpu"
+[specs.gpu]
+memory = "24-"
+
+[[specs]]
+name = "orphan"
+extends = "missing"
+
+[[specs]]
+name = "loop-a"
+extends = "loop-b"
+
+[[specs]]
+name = "loop-b"
+extends = "loop-a"
+`
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0600))
+	var p Project
+	require.NoError(t, readAndUnmarshal(path, &p))
+
+	t.Run("should apply the specs a spec extends", func(t *testing.T) {
+		spec, err := ResolveSpec("big-gpu", p.Specs)
+		require.NoError(t, err)
+		assert.Equal(t, Spec{
+			Name:        "big-gpu",
+			CPU:         specResources{Type: "x86_64", Count: "4", Memory: ">=16"},
+			GPU:         specResources{Type: "a100", Count: "8", Memory: ">=24"},
+			HDD:         specHDD{Size: "50"},
+			TTL:         "4h",
+			IdleTimeout: "30m",
+		}, spec)
+
+		hw, err := spec.HardwareSpec()
+		require.NoError(t, err)
+		assert.Equal(t, types.HardwareSpec{
+			GPU: types.GPU{Type: "a100", Count: types.HardwareRequestRange{Min: 8, Max: 8}, RAM: types.HardwareRequestRange{Min: 24}},
+			CPU: types.CPU{Type: "x86_64", HardwareRequestRange: types.HardwareRequestRange{Min: 4, Max: 4}},
+			RAM: types.HardwareRequestRange{Min: 16},
+			HDD: types.HardwareRequestRange{Min: 50, Max: 50},
+		}, hw)
+	})
+
+	t.Run("should read ranges", func(t *testing.T) {
+		spec, err := ResolveSpec("gpu", p.Specs)
+		require.NoError(t, err)
+		hw, err := spec.HardwareSpec()
+		require.NoError(t, err)
+		assert.Equal(t, types.HardwareRequestRange{Min: 1, Max: 4}, hw.GPU.Count)
+	})
+
+	errorCases := map[string]string{
+		"missing": `spec not found: "missing"`,
+		"broken":  `invalid spec "broken": gpu.memory: "" isn't a whole number like 4, a range like 1-4, >=24 or <=8`,
+		"orphan":  `invalid spec "orphan": extends: no spec named "missing"`,
+		"loop-a":  `invalid spec "loop-b": extends: loop-a -> loop-b -> loop-a is a cycle`,
+	}
+	for name, want := range errorCases {
+		t.Run("should fail for "+name, func(t *testing.T) {
+			_, err := ResolveSpec(name, p.Specs)
+			assert.EqualError(t, err, want)
+			assert.True(t, errors.Is(err, ErrSpecNotFound) || errors.Is(err, ErrInvalidSpec))
+		})
+	}
+}
@@ -1,24 +1,23 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/unweave/cli/ui"
 )
 
-func GetUnweaveSSHKeysFolder() string {
+// GetUnweaveSSHKeysFolder returns the directory Unweave SSH keys are stored in, creating
+// it if it doesn't exist yet.
+func GetUnweaveSSHKeysFolder() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		ui.Errorf("Unable to find home directory: %s", err)
-		os.Exit(1)
+		return "", fmt.Errorf("unable to find home directory: %w", err)
 	}
 	path := filepath.Join(home, UnweaveSSHKeysDir)
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		if err := os.MkdirAll(path, 0700); err != nil {
-			ui.Errorf("Unable to create %s directory: %s", UnweaveSSHKeysDir, err)
-			os.Exit(1)
+			return "", fmt.Errorf("unable to create %s directory: %w", UnweaveSSHKeysDir, err)
 		}
 	}
-	return path
+	return path, nil
 }
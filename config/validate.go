@@ -0,0 +1,419 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/unweave/cli/tools"
+	"github.com/unweave/unweave/api/types"
+)
+
+// Issue is a problem found in a config file. Line is 0 if the problem has no location
+// in the file, and File is empty if the problem is with the environment.
+type Issue struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	var parts []string
+	if i.File != "" {
+		file := i.File
+		if i.Line > 0 {
+			file += ":" + strconv.Itoa(i.Line)
+		}
+		parts = append(parts, file)
+	}
+	if i.Key != "" {
+		parts = append(parts, i.Key)
+	}
+	return strings.Join(append(parts, i.Message), ": ")
+}
+
+var providers = []types.Provider{types.UnweaveProvider, types.LambdaLabsProvider, types.AWSProvider}
+
+// schema describes what a config file may contain.
+type schema struct {
+	// newTarget returns a pointer to the value the file is decoded into.
+	newTarget func() any
+	// check returns the issues with the values of a decoded file.
+	check func(target any, c *checker)
+}
+
+var projectSchema = schema{
+	newTarget: func() any { return &Project{} },
+	check:     func(target any, c *checker) { checkProject(target.(*Project), c) },
+}
+
+var userSchema = schema{
+	newTarget: func() any { return &unweave{} },
+	check:     func(target any, c *checker) { checkUser(target.(*unweave), c) },
+}
+
+// validate returns the issues with a config file, sorted by line.
+func (s schema) validate(file string, data []byte) []Issue {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return []Issue{syntaxIssue(file, err)}
+	}
+
+	c := &checker{file: file, doc: doc}
+	c.checkTypes(nil, doc.values, reflect.TypeOf(s.newTarget()))
+	if len(c.issues) == 0 {
+		target := s.newTarget()
+		if err := toml.Unmarshal(data, target); err != nil {
+			c.add(nil, err.Error())
+		} else {
+			s.check(target, c)
+		}
+	}
+
+	sort.Slice(c.issues, func(i, j int) bool {
+		a, b := c.issues[i], c.issues[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Key < b.Key
+	})
+	return c.issues
+}
+
+func syntaxIssue(file string, err error) Issue {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		line, _ := decodeErr.Position()
+		return Issue{File: file, Line: line, Message: strings.TrimPrefix(decodeErr.Error(), "toml: ")}
+	}
+	return Issue{File: file, Message: err.Error()}
+}
+
+// checker collects the issues of a config file.
+type checker struct {
+	file   string
+	doc    *document
+	issues []Issue
+}
+
+func (c *checker) add(path []string, format string, args ...any) {
+	c.issues = append(c.issues, Issue{
+		File:    c.file,
+		Line:    c.doc.line(path...),
+		Key:     c.key(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// key returns the path as users write it, with the elements of arrays of tables named
+// by their name if they have one.
+func (c *checker) key(path []string) string {
+	parts := make([]string, len(path))
+	for i, seg := range path {
+		parts[i] = seg
+		if _, err := strconv.Atoi(seg); err != nil || i == 0 {
+			continue
+		}
+		element, _ := c.doc.lookup(path[:i+1])
+		if m, ok := element.(map[string]any); ok {
+			if name, ok := m["name"].(string); ok && name != "" && !strings.Contains(name, ".") {
+				parts[i] = name
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// checkTypes reports the keys of v that t has no field for, and the values of the
+// wrong type.
+func (c *checker) checkTypes(path []string, v any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		switch v.(type) {
+		case string, int64:
+		default:
+			c.add(path, "expected a string or an integer, got %s", tomlType(v))
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		table, ok := v.(map[string]any)
+		if !ok {
+			c.add(path, "expected a table, got %s", tomlType(v))
+			return
+		}
+		for key, value := range table {
+			field, ok := fieldByKey(t, key)
+			if !ok {
+				c.add(append(path[:len(path):len(path)], key), "unknown key")
+				continue
+			}
+			c.checkTypes(append(path[:len(path):len(path)], key), value, field.Type)
+		}
+	case reflect.Map:
+		table, ok := v.(map[string]any)
+		if !ok {
+			c.add(path, "expected a table, got %s", tomlType(v))
+			return
+		}
+		for key, value := range table {
+			c.checkTypes(append(path[:len(path):len(path)], key), value, t.Elem())
+		}
+	case reflect.Slice:
+		array, ok := v.([]any)
+		if !ok {
+			c.add(path, "expected an array, got %s", tomlType(v))
+			return
+		}
+		for i, value := range array {
+			c.checkTypes(append(path[:len(path):len(path)], strconv.Itoa(i)), value, t.Elem())
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			c.add(path, "expected a string, got %s", tomlType(v))
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			c.add(path, "expected a boolean, got %s", tomlType(v))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, ok := v.(int64); !ok {
+			c.add(path, "expected an integer, got %s", tomlType(v))
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v.(type) {
+		case float64, int64:
+		default:
+			c.add(path, "expected a number, got %s", tomlType(v))
+		}
+	}
+}
+
+// fieldByKey returns the field of the struct type t that the TOML key decodes into.
+// Like the decoder, it falls back to matching field names case-insensitively.
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	var byName reflect.StructField
+	found := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == key {
+			return f, true
+		}
+		if name == "" && !found && strings.EqualFold(f.Name, key) {
+			byName, found = f, true
+		}
+	}
+	return byName, found
+}
+
+func tomlType(v any) string {
+	switch v.(type) {
+	case string:
+		return "a string"
+	case int64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "a table"
+	case time.Time, toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		return "a date"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func checkProject(p *Project, c *checker) {
+	if p.URI != "" {
+		if parts := strings.Split(p.URI, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			c.add([]string{"project_uri"}, "%q should be of the form <owner>/<project>", p.URI)
+		}
+	}
+	if p.DefaultProvider != "" && !isProvider(p.DefaultProvider) {
+		c.add([]string{"default_provider"}, "unknown provider %q, should be one of %s", p.DefaultProvider, providerList())
+	}
+	for name := range p.Providers {
+		if !isProvider(name) {
+			c.add([]string{"provider", name}, "unknown provider, should be one of %s", providerList())
+		}
+	}
+
+	for key, value := range p.SessionEnv {
+		if !dotEnvKeyRegex.MatchString(key) {
+			c.add([]string{"env", key}, "invalid environment variable name, names are letters, digits and underscores")
+		}
+		noLookup := func(string) (string, bool) { return "", false }
+		if _, err := ExpandEnvValue(value, noLookup); err != nil {
+			c.add([]string{"env", key}, "%s", err)
+		}
+	}
+
+	for i, spec := range p.Specs {
+		path := []string{"specs", strconv.Itoa(i)}
+		at := func(key ...string) []string { return append(path[:2:2], key...) }
+
+		if spec.Name == "" {
+			c.add(path, "name is required")
+		}
+		for _, other := range p.Specs[:i] {
+			if spec.Name != "" && strings.EqualFold(other.Name, spec.Name) {
+				c.add(at("name"), "another spec is named %q", other.Name)
+				break
+			}
+		}
+		if spec.Extends != "" {
+			if _, ok := lookupSpec(spec.Extends, p.Specs); !ok {
+				c.add(at("extends"), "no spec named %q", spec.Extends)
+			} else if cycle := extendsCycle(spec, p.Specs); cycle != "" {
+				c.add(at("extends"), "%s is a cycle", cycle)
+			}
+		}
+
+		ranges := []struct {
+			key   []string
+			value Range
+		}{
+			{at("cpu", "count"), spec.CPU.Count},
+			{at("cpu", "memory"), spec.CPU.Memory},
+			{at("gpu", "count"), spec.GPU.Count},
+			{at("gpu", "memory"), spec.GPU.Memory},
+			{at("hdd", "size"), spec.HDD.Size},
+		}
+		for _, r := range ranges {
+			if _, _, err := r.value.Parse(); err != nil {
+				c.add(r.key, "%v", err)
+			}
+		}
+
+		durations := []struct {
+			key   string
+			value string
+		}{
+			{"ttl", spec.TTL},
+			{"idle_timeout", spec.IdleTimeout},
+		}
+		for _, d := range durations {
+			if d.value == "" {
+				continue
+			}
+			if _, err := tools.ParseDuration(d.value); err != nil {
+				c.add(at(d.key), "%v, should be like 30m, 4h or 2d", err)
+			}
+		}
+
+		if fb := spec.Fallback; fb != nil {
+			for _, name := range fb.Providers {
+				if !isProvider(name) {
+					c.add(at("fallback", "providers"), "unknown provider %q, should be one of %s", name, providerList())
+				}
+			}
+			if fb.MaxHourlyPrice < 0 {
+				c.add(at("fallback", "max_hourly_price"), "can't be negative")
+			}
+		}
+	}
+
+	if p.Budget.Monthly < 0 {
+		c.add([]string{"budget", "monthly"}, "can't be negative")
+	}
+	if p.Budget.MaxHourlyPrice < 0 {
+		c.add([]string{"budget", "max_hourly_price"}, "can't be negative")
+	}
+	switch strings.ToLower(p.Budget.OnExceed) {
+	case "", "prompt", "block":
+	default:
+		c.add([]string{"budget", "on_exceed"}, "%q should be prompt or block", p.Budget.OnExceed)
+	}
+
+	if size := p.Build.MaxContextSize; size != "" {
+		if _, err := ParseSize(size); err != nil {
+			c.add([]string{"build", "max_context_size"}, "%v, should be like 500MB or 2GiB", err)
+		}
+	}
+}
+
+// extendsCycle returns the chain of specs spec extends, e.g. "a -> b -> a", if it leads
+// back to spec.
+func extendsCycle(spec Spec, specs []Spec) string {
+	names := []string{spec.Name}
+	seen := map[string]bool{}
+	for current := spec.Extends; current != ""; {
+		next, ok := lookupSpec(current, specs)
+		if !ok || seen[strings.ToLower(next.Name)] {
+			return ""
+		}
+		names = append(names, next.Name)
+		if strings.EqualFold(next.Name, spec.Name) {
+			return strings.Join(names, " -> ")
+		}
+		seen[strings.ToLower(next.Name)] = true
+		current = next.Extends
+	}
+	return ""
+}
+
+func checkUser(u *unweave, c *checker) {
+	urls := []struct {
+		key   string
+		value string
+	}{
+		{"api_url", u.ApiURL},
+		{"app_url", u.AppURL},
+	}
+	for _, u := range urls {
+		if u.value == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u.value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			c.add([]string{u.key}, "%q should be an http or https URL", u.value)
+		}
+	}
+	// These are the transports of the ssh package, which imports this one.
+	switch strings.ToLower(u.SSHTransport) {
+	case "", "native", "binary":
+	default:
+		c.add([]string{"ssh_transport"}, "%q should be native or binary", u.SSHTransport)
+	}
+}
+
+func isProvider(name string) bool {
+	for _, p := range providers {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func providerList() string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
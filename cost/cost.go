@@ -0,0 +1,151 @@
+// Package cost estimates what sessions cost from the prices of the node types of their
+// provider, and checks new sessions against a budget. Prices are in USD.
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+var (
+	// ErrNoPrice is returned if no node type with a price matches a spec.
+	ErrNoPrice = errors.New("no price available")
+	// ErrOverBudget is returned if a new session would exceed the budget.
+	ErrOverBudget = errors.New("over budget")
+)
+
+// Rate is what a session costs per hour.
+type Rate struct {
+	// NodeType is the node type the price is taken from.
+	NodeType string  `json:"node_type"`
+	Hourly   float64 `json:"hourly"`
+}
+
+// Estimate returns the hourly rate of a session with spec, using the cheapest of
+// nodeTypes that fits it. The price of a node type is for as many GPUs as its spec has,
+// or for one GPU if its spec doesn't say, and sessions with more GPUs pay for more
+// nodes.
+func Estimate(spec types.HardwareSpec, nodeTypes []types.NodeType) (Rate, error) {
+	instanceType := spec.GPU.Type
+	if instanceType == "" {
+		instanceType = spec.CPU.Type
+	}
+	if instanceType == "" {
+		return Rate{}, fmt.Errorf("%w for a session without a CPU or GPU type", ErrNoPrice)
+	}
+
+	var best Rate
+	found := false
+	for _, nt := range nodeTypes {
+		if nt.Price == nil || (nt.ID != instanceType && nt.Specs.GPU.Type != instanceType) {
+			continue
+		}
+
+		nodes := 1
+		if gpus := spec.GPU.Count.Min; gpus > 1 {
+			perNode := nt.Specs.GPU.Count.Min
+			if perNode < 1 {
+				perNode = 1
+			}
+			nodes = (gpus + perNode - 1) / perNode
+		}
+
+		hourly := float64(*nt.Price) / 100 * float64(nodes)
+		if !found || hourly < best.Hourly {
+			best = Rate{NodeType: nt.ID, Hourly: hourly}
+			found = true
+		}
+	}
+	if !found {
+		return Rate{}, fmt.Errorf("%w for %q", ErrNoPrice, instanceType)
+	}
+	return best, nil
+}
+
+// Session is a session that costs money while it runs.
+type Session struct {
+	CreatedAt time.Time
+	Hourly    float64
+	// TTL, if set, is how long after it's created the session is terminated.
+	TTL time.Duration
+	// EndedAt, if set, is when the session was terminated.
+	EndedAt time.Time
+}
+
+// Spent returns what the session has cost from when it was created until now.
+func (s Session) Spent(now time.Time) float64 {
+	return s.between(s.CreatedAt, now)
+}
+
+// between returns what the session costs from start to end.
+func (s Session) between(start, end time.Time) float64 {
+	if start.Before(s.CreatedAt) {
+		start = s.CreatedAt
+	}
+	if s.TTL > 0 && end.After(s.CreatedAt.Add(s.TTL)) {
+		end = s.CreatedAt.Add(s.TTL)
+	}
+	if !s.EndedAt.IsZero() && end.After(s.EndedAt) {
+		end = s.EndedAt
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return s.Hourly * end.Sub(start).Hours()
+}
+
+// Month returns the start and end of the calendar month of now.
+func Month(now time.Time) (time.Time, time.Time) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Forecast returns what sessions have cost this month so far, and what they will have
+// cost by the end of the month if the ones still running run until their TTL or the end
+// of the month.
+func Forecast(sessions []Session, now time.Time) (spent, projected float64) {
+	start, end := Month(now)
+	for _, s := range sessions {
+		spent += s.between(start, now)
+		projected += s.between(start, end)
+	}
+	return spent, projected
+}
+
+// Budget limits what a project spends. Zero values are unlimited.
+type Budget struct {
+	// Monthly caps what the sessions of the project cost in the current calendar month.
+	Monthly        float64
+	MaxHourlyPrice float64
+}
+
+// IsZero returns whether the budget doesn't limit anything.
+func (b Budget) IsZero() bool {
+	return b.Monthly <= 0 && b.MaxHourlyPrice <= 0
+}
+
+// Check returns an error wrapping ErrOverBudget if starting session would go over the
+// budget. sessions are the other sessions of the project this month, running or ended.
+func (b Budget) Check(session Session, sessions []Session, now time.Time) error {
+	if b.MaxHourlyPrice > 0 && session.Hourly > b.MaxHourlyPrice {
+		return fmt.Errorf("%w: the session costs %s/hour, more than the maximum of %s/hour",
+			ErrOverBudget, FormatUSD(session.Hourly), FormatUSD(b.MaxHourlyPrice))
+	}
+	if b.Monthly > 0 {
+		_, projected := Forecast(append(append([]Session{}, sessions...), session), now)
+		if projected > b.Monthly {
+			return fmt.Errorf("%w: the sessions of the project would cost up to %s this month, more than the monthly budget of %s",
+				ErrOverBudget, FormatUSD(projected), FormatUSD(b.Monthly))
+		}
+	}
+	return nil
+}
+
+// FormatUSD formats an amount in USD, e.g. $12.30.
+func FormatUSD(v float64) string {
+	return fmt.Sprintf("$%.2f", math.Round(v*100)/100)
+}
@@ -0,0 +1,132 @@
+package cost
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+func price(cents int) *int {
+	return &cents
+}
+
+func TestEstimate(t *testing.T) {
+	nodeTypes := []types.NodeType{
+		{ID: "gpu_1x_a100", Price: price(110), Specs: types.HardwareSpec{GPU: types.GPU{Type: "a100", Count: types.HardwareRequestRange{Min: 1}}}},
+		{ID: "gpu_8x_a100", Price: price(880), Specs: types.HardwareSpec{GPU: types.GPU{Type: "a100", Count: types.HardwareRequestRange{Min: 8}}}},
+		{ID: "gpu_1x_a100_cheap", Price: price(99), Specs: types.HardwareSpec{GPU: types.GPU{Type: "a100", Count: types.HardwareRequestRange{Min: 1}}}},
+		{ID: "rtx_4000", Price: price(56), Specs: types.HardwareSpec{GPU: types.GPU{Type: "rtx_4000"}}},
+		{ID: "h100", Specs: types.HardwareSpec{GPU: types.GPU{Type: "h100"}}},
+		{ID: "x86_64", Price: price(10)},
+	}
+
+	cases := []struct {
+		name string
+		spec types.HardwareSpec
+		want Rate
+		err  error
+	}{
+		{
+			name: "cheapest node type with the GPU type",
+			spec: types.HardwareSpec{GPU: types.GPU{Type: "a100"}},
+			want: Rate{NodeType: "gpu_1x_a100_cheap", Hourly: 0.99},
+		},
+		{
+			name: "node type by ID",
+			spec: types.HardwareSpec{GPU: types.GPU{Type: "rtx_4000"}},
+			want: Rate{NodeType: "rtx_4000", Hourly: 0.56},
+		},
+		{
+			name: "more GPUs than a node has",
+			spec: types.HardwareSpec{GPU: types.GPU{Type: "rtx_4000", Count: types.HardwareRequestRange{Min: 3}}},
+			want: Rate{NodeType: "rtx_4000", Hourly: 1.68},
+		},
+		{
+			name: "CPU only",
+			spec: types.HardwareSpec{CPU: types.CPU{Type: "x86_64"}},
+			want: Rate{NodeType: "x86_64", Hourly: 0.10},
+		},
+		{
+			name: "node type without a price",
+			spec: types.HardwareSpec{GPU: types.GPU{Type: "h100"}},
+			err:  ErrNoPrice,
+		},
+		{
+			name: "no instance type",
+			spec: types.HardwareSpec{},
+			err:  ErrNoPrice,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Estimate(c.spec, nodeTypes)
+			if c.err != nil {
+				assert.True(t, errors.Is(err, c.err), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.want.NodeType, got.NodeType)
+			assert.InDelta(t, c.want.Hourly, got.Hourly, 1e-9)
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2023, 6, 11, 0, 0, 0, 0, time.UTC)
+
+	sessions := []Session{
+		// Running since the previous month, only June counts.
+		{CreatedAt: time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC), Hourly: 1},
+		// Terminated by its TTL before the end of the month.
+		{CreatedAt: now.Add(-2 * time.Hour), Hourly: 2, TTL: 12 * time.Hour},
+	}
+	assert.InDelta(t, 24.0, sessions[0].Spent(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)), 1e-9)
+
+	spent, projected := Forecast(sessions, now)
+	assert.InDelta(t, 10*24+2*2, spent, 1e-9)
+	assert.InDelta(t, 30*24+12*2, projected, 1e-9)
+
+	// Terminated a day ago.
+	ended := Session{CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Hourly: 1, EndedAt: now.Add(-24 * time.Hour)}
+	spent, projected = Forecast([]Session{ended}, now)
+	assert.InDelta(t, 9*24, spent, 1e-9)
+	assert.InDelta(t, 9*24, projected, 1e-9)
+}
+
+func TestBudget(t *testing.T) {
+	now := time.Date(2023, 6, 16, 0, 0, 0, 0, time.UTC)
+	running := []Session{{CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Hourly: 1}}
+
+	assert.True(t, Budget{}.IsZero())
+	assert.NoError(t, Budget{}.Check(Session{CreatedAt: now, Hourly: 100}, running, now))
+
+	err := Budget{MaxHourlyPrice: 0.5}.Check(Session{CreatedAt: now, Hourly: 0.56}, nil, now)
+	assert.True(t, errors.Is(err, ErrOverBudget))
+	assert.Contains(t, err.Error(), "$0.56/hour")
+
+	// The running session costs $720 in June, the new one $360 until the end of the month
+	// or $24 with a TTL of a day.
+	assert.NoError(t, Budget{Monthly: 1000}.Check(Session{CreatedAt: now, Hourly: 1, TTL: 24 * time.Hour}, running, now))
+	err = Budget{Monthly: 1000}.Check(Session{CreatedAt: now, Hourly: 1}, running, now)
+	assert.True(t, errors.Is(err, ErrOverBudget))
+	assert.Contains(t, err.Error(), "$1080.00")
+
+	// Sessions that ended this month count too, so cycling sessions doesn't get around
+	// the budget. The ended one cost $240 over 10 days.
+	ended := Session{CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Hourly: 1, EndedAt: time.Date(2023, 6, 11, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, Budget{Monthly: 500}.Check(Session{CreatedAt: now, Hourly: 1}, nil, now))
+	err = Budget{Monthly: 500}.Check(Session{CreatedAt: now, Hourly: 1}, []Session{ended}, now)
+	assert.True(t, errors.Is(err, ErrOverBudget))
+	assert.Contains(t, err.Error(), "$600.00")
+}
+
+func TestFormatUSD(t *testing.T) {
+	assert.Equal(t, "$12.30", FormatUSD(12.3))
+	assert.Equal(t, "$0.01", FormatUSD(0.005))
+	assert.Equal(t, "$0.00", FormatUSD(0))
+}
@@ -0,0 +1,50 @@
+// Package credentials stores secrets such as auth tokens outside of the config files.
+// Secrets go into the OS keychain when one is available and into an encrypted file
+// otherwise, e.g. on headless Linux machines.
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/unweave/cli/ui"
+)
+
+// Service is the name secrets are stored under in the OS keychain.
+const Service = "unweave"
+
+// ErrNotFound is returned when a store holds no secret for a key.
+var ErrNotFound = errors.New("credential not found")
+
+// Store stores secrets by key.
+type Store interface {
+	// Get returns the secret for key or ErrNotFound.
+	Get(key string) (string, error)
+	// Set stores the secret for key, replacing any previous one.
+	Set(key, secret string) error
+	// Delete removes the secret for key. Deleting a missing secret isn't an error.
+	Delete(key string) error
+	// Name describes the store to users.
+	Name() string
+}
+
+// Default returns the OS keychain if it's available, and an encrypted file in dir
+// otherwise. Setting UNWEAVE_CREDENTIAL_STORE to "keyring" or "file" picks one
+// explicitly.
+func Default(dir string) Store {
+	file := NewFileStore(filepath.Join(dir, "credentials"), os.Getenv("UNWEAVE_CREDENTIALS_PASSPHRASE"))
+
+	switch os.Getenv("UNWEAVE_CREDENTIAL_STORE") {
+	case "file":
+		return file
+	case "keyring":
+		return NewKeyring(Service)
+	}
+
+	if k := NewKeyring(Service); k.Available() {
+		return k
+	}
+	ui.Debugf("No OS keychain available, storing credentials in %s", file.path)
+	return file
+}
@@ -0,0 +1,171 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// FileStore stores secrets encrypted with AES-256-GCM in a file that only the user can
+// read. The key is derived from a passphrase with scrypt. Without a passphrase, the key
+// is derived from the machine ID and user name instead, which keeps the secrets from
+// being read in plain text or used on another machine, but not from someone with access
+// to the account on this one.
+type FileStore struct {
+	path       string
+	passphrase string
+}
+
+type secretsFile struct {
+	Salt    []byte            `json:"salt"`
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+// NewFileStore returns a FileStore backed by the file at path. If passphrase is empty, a
+// passphrase tied to this machine and user is used.
+func NewFileStore(path, passphrase string) *FileStore {
+	if passphrase == "" {
+		passphrase = machinePassphrase()
+	}
+	return &FileStore{path: path, passphrase: passphrase}
+}
+
+func (f *FileStore) Name() string {
+	return "encrypted file " + f.path
+}
+
+func (f *FileStore) Get(key string) (string, error) {
+	sf, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := sf.Secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	aead, err := f.cipher(sf.Salt)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("credential %q is corrupt", key)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential %q, was UNWEAVE_CREDENTIALS_PASSPHRASE changed? %w", key, err)
+	}
+	return string(secret), nil
+}
+
+func (f *FileStore) Set(key, secret string) error {
+	sf, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	aead, err := f.cipher(sf.Salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	sf.Secrets[key] = aead.Seal(nonce, nonce, []byte(secret), []byte(key))
+	return f.save(sf)
+}
+
+func (f *FileStore) Delete(key string) error {
+	sf, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := sf.Secrets[key]; !ok {
+		return nil
+	}
+	delete(sf.Secrets, key)
+	return f.save(sf)
+}
+
+func (f *FileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(f.passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (f *FileStore) load() (*secretsFile, error) {
+	sf := &secretsFile{}
+	buf, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(buf, sf); err != nil {
+			return nil, fmt.Errorf("failed to read credentials file %s: %w", f.path, err)
+		}
+	}
+
+	if len(sf.Salt) == 0 {
+		sf.Salt = make([]byte, 16)
+		if _, err = rand.Read(sf.Salt); err != nil {
+			return nil, err
+		}
+	}
+	if sf.Secrets == nil {
+		sf.Secrets = map[string][]byte{}
+	}
+	return sf, nil
+}
+
+func (f *FileStore) save(sf *secretsFile) error {
+	buf, err := json.Marshal(sf)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted write can't lose secrets
+	tmp := f.path + ".tmp"
+	if err = os.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// machinePassphrase returns a passphrase that's stable for the current user on this
+// machine.
+func machinePassphrase() string {
+	var parts []string
+	for _, p := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if id, err := os.ReadFile(p); err == nil {
+			parts = append(parts, strings.TrimSpace(string(id)))
+			break
+		}
+	}
+	if host, err := os.Hostname(); err == nil {
+		parts = append(parts, host)
+	}
+	if u, err := user.Current(); err == nil {
+		parts = append(parts, u.Uid, u.Username)
+	}
+	return "unweave:" + strings.Join(parts, ":")
+}
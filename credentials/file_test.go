@@ -0,0 +1,53 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	store := NewFileStore(path, "passphrase")
+
+	_, err := store.Get("production")
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, store.Delete("production"))
+
+	require.NoError(t, store.Set("production", "uw:secret-token"))
+	require.NoError(t, store.Set("staging", "uw:other-token"))
+
+	got, err := store.Get("production")
+	require.NoError(t, err)
+	assert.Equal(t, "uw:secret-token", got)
+
+	t.Run("should keep the file private and encrypted", func(t *testing.T) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		buf, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.False(t, strings.Contains(string(buf), "secret-token"))
+	})
+
+	t.Run("should not decrypt with another passphrase", func(t *testing.T) {
+		_, err := NewFileStore(path, "wrong").Get("production")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("should delete a secret", func(t *testing.T) {
+		require.NoError(t, store.Delete("production"))
+		_, err := store.Get("production")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		got, err := store.Get("staging")
+		require.NoError(t, err)
+		assert.Equal(t, "uw:other-token", got)
+	})
+}
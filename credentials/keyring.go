@@ -0,0 +1,136 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Keyring stores secrets in the OS keychain: the login keychain on macOS and the
+// Secret Service (GNOME Keyring, KWallet) on Linux. It talks to them through the
+// `security` and `secret-tool` commands.
+type Keyring struct {
+	service string
+}
+
+// NewKeyring returns a Keyring that stores secrets under service.
+func NewKeyring(service string) *Keyring {
+	return &Keyring{service: service}
+}
+
+// Available returns whether the keychain can be used on this machine.
+func (k *Keyring) Available() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux":
+		// The Secret Service runs on the session bus, which headless machines don't have
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return false
+		}
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	default:
+		return false
+	}
+}
+
+func (k *Keyring) Name() string {
+	if runtime.GOOS == "darwin" {
+		return "macOS keychain"
+	}
+	return "Secret Service keyring"
+}
+
+func (k *Keyring) Get(key string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", k.service, "-a", key, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", k.service, "account", key)
+	}
+
+	out, err := k.run(cmd, nil)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Both tools exit with a non-zero code if there's no matching item
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	secret := strings.TrimSuffix(string(out), "\n")
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *Keyring) Set(key, secret string) error {
+	if runtime.GOOS == "darwin" {
+		if strings.ContainsAny(key+secret, "\r\n") {
+			return errors.New("secrets stored in the macOS keychain can't span lines")
+		}
+		// The command is read from stdin so the secret doesn't show up in the arguments of
+		// the process, which other users can list. -U updates the item if it exists.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(k.service), securityQuote(key), securityQuote(secret))
+		if _, err := k.run(exec.Command("security", "-i"), strings.NewReader(command)); err != nil {
+			return err
+		}
+		// security -i doesn't exit with an error if the command fails
+		if stored, err := k.Get(key); err != nil || stored != secret {
+			return fmt.Errorf("failed to store the secret in the %s", k.Name())
+		}
+		return nil
+	}
+
+	label := fmt.Sprintf("%s (%s)", k.service, key)
+	_, err := k.run(exec.Command("secret-tool", "store", "--label", label, "service", k.service, "account", key), strings.NewReader(secret))
+	return err
+}
+
+func (k *Keyring) Delete(key string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "delete-generic-password", "-s", k.service, "-a", key)
+	} else {
+		cmd = exec.Command("secret-tool", "clear", "service", k.service, "account", key)
+	}
+
+	_, err := k.run(cmd, nil)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && runtime.GOOS == "darwin" {
+		// security fails if there's nothing to delete
+		return nil
+	}
+	return err
+}
+
+func (k *Keyring) run(cmd *exec.Cmd, stdin *strings.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s: %w: %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("failed to access the %s: %w", k.Name(), err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// securityQuote quotes s as an argument of a command read by `security -i`.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,19 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityQuote(t *testing.T) {
+	tests := map[string]string{
+		"uw_token":   `"uw_token"`,
+		"with space": `"with space"`,
+		`a"b`:        `"a\"b"`,
+		`back\slash`: `"back\\slash"`,
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, securityQuote(in), in)
+	}
+}
@@ -0,0 +1,190 @@
+package devapi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/unweave/unweave/api/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// routeAccount serves the account and pairing routes. Pairing codes are approved
+// straight away so that `unweave login` completes without a browser.
+func (s *Server) routeAccount(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 1 && parts[0] == "pair" && r.Method == http.MethodPost:
+		code := strings.ToUpper(newID("pair")[5:])
+
+		s.mu.Lock()
+		s.pairings[code] = true
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusCreated, types.PairingTokenCreateResponse{Code: code})
+
+	case len(parts) == 2 && parts[0] == "pair" && r.Method == http.MethodPut:
+		s.mu.Lock()
+		ok := s.pairings[parts[1]]
+		delete(s.pairings, parts[1])
+		s.mu.Unlock()
+
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("pairing code %q not found", parts[1]))
+			return
+		}
+		writeJSON(w, http.StatusOK, types.PairingTokenExchangeResponse{Token: s.cfg.Token, Account: s.cfg.Account})
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, types.AccountGetResponse{Account: s.cfg.Account})
+
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+func (s *Server) routeSSHKeys(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		keys := append([]types.SSHKey{}, s.sshKeys[parts[0]]...)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, types.SSHKeyListResponse{Keys: keys})
+
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		var params types.SSHKeyAddParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(params.PublicKey)); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid public key: "+err.Error())
+			return
+		}
+
+		name := ""
+		if params.Name != nil {
+			name = *params.Name
+		}
+
+		s.mu.Lock()
+		key := s.addSSHKey(parts[0], name, params.PublicKey)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusCreated, types.SSHKeyResponse{Name: key.Name, PublicKey: params.PublicKey})
+
+	case len(parts) == 2 && parts[1] == "generate" && r.Method == http.MethodPost:
+		var params types.SSHKeyGenerateParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+
+		publicKey, privateKey, err := generateSSHKey()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		name := ""
+		if params.Name != nil {
+			name = *params.Name
+		}
+
+		s.mu.Lock()
+		key := s.addSSHKey(parts[0], name, publicKey)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusCreated, types.SSHKeyResponse{Name: key.Name, PublicKey: publicKey, PrivateKey: privateKey})
+
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+// addSSHKey registers a public key for owner and returns it. Keys that are already
+// registered are returned as is. The caller must hold the lock.
+func (s *Server) addSSHKey(owner, name, publicKey string) types.SSHKey {
+	publicKey = strings.TrimSpace(publicKey)
+	for _, key := range s.sshKeys[owner] {
+		if key.PublicKey != nil && *key.PublicKey == publicKey {
+			return key
+		}
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("uw:dev-key-%d", len(s.sshKeys[owner])+1)
+	}
+	now := s.now()
+	key := types.SSHKey{Name: name, PublicKey: &publicKey, CreatedAt: &now}
+	s.sshKeys[owner] = append(s.sshKeys[owner], key)
+	return key
+}
+
+func (s *Server) routeProviders(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) != 2 || parts[1] != "node-types" || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "route not found")
+		return
+	}
+	provider := types.Provider(parts[0])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nodeTypes []types.NodeType
+	if r.URL.Query().Get("available") == "true" {
+		nodeTypes = s.availableNodeTypes(provider)
+	} else {
+		nodeTypes = s.nodeTypes(provider)
+	}
+	writeJSON(w, http.StatusOK, types.NodeTypesListResponse{NodeTypes: nodeTypes})
+}
+
+// nodeTypes returns the configured node types as offered by provider.
+func (s *Server) nodeTypes(provider types.Provider) []types.NodeType {
+	nodeTypes := make([]types.NodeType, 0, len(s.cfg.NodeTypes))
+	for _, nt := range s.cfg.NodeTypes {
+		nt.Provider = provider
+		nodeTypes = append(nodeTypes, nt)
+	}
+	return nodeTypes
+}
+
+// availableNodeTypes returns the node types of provider that have capacity in at least
+// one region. The caller must hold the lock.
+func (s *Server) availableNodeTypes(provider types.Provider) []types.NodeType {
+	nodeTypes := []types.NodeType{}
+	for _, nt := range s.nodeTypes(provider) {
+		if len(nt.Regions) == 0 || s.noCapacity[nt.ID] {
+			continue
+		}
+		nodeTypes = append(nodeTypes, nt)
+	}
+	return nodeTypes
+}
+
+// generateSSHKey returns a new ed25519 key pair as an authorized_keys line and a PEM
+// encoded private key.
+func generateSSHKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", "", err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))), string(privPEM), nil
+}
@@ -0,0 +1,258 @@
+package devapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+func (s *Server) routeVolumes(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(owner, name)
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		volumes := []types.Volume{}
+		for _, v := range p.volumes {
+			volumes = append(volumes, *v)
+		}
+		sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+		writeJSON(w, http.StatusOK, types.VolumesListResponse{Volumes: volumes})
+
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req types.VolumeCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if _, exists := s.findVolume(owner, name, req.Name); exists {
+			writeError(w, http.StatusConflict, fmt.Sprintf("volume %q already exists", req.Name))
+			return
+		}
+		now := s.now()
+		vol := &types.Volume{
+			ID:       newID("vol"),
+			Name:     req.Name,
+			Size:     req.Size,
+			Provider: req.Provider,
+			State:    types.VolumeState{CreatedAt: now, UpdatedAt: now},
+		}
+		p.volumes[vol.ID] = vol
+		writeJSON(w, http.StatusCreated, vol)
+
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		vol, ok := s.findVolume(owner, name, parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("volume %q not found", parts[0]))
+			return
+		}
+		var req types.VolumeResizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if req.Size < vol.Size {
+			writeError(w, http.StatusBadRequest, "volumes can't be shrunk")
+			return
+		}
+		vol.Size = req.Size
+		vol.State.UpdatedAt = s.now()
+		writeJSON(w, http.StatusOK, nil)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		vol, ok := s.findVolume(owner, name, parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("volume %q not found", parts[0]))
+			return
+		}
+		delete(p.volumes, vol.ID)
+		writeJSON(w, http.StatusOK, nil)
+
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+// findVolume looks up a volume of a project by ID or name. The caller must hold the
+// lock.
+func (s *Server) findVolume(owner, name, ref string) (*types.Volume, bool) {
+	for _, v := range s.project(owner, name).volumes {
+		if v.ID == ref || v.Name == ref {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) routeBuilds(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	if len(parts) != 0 || r.Method != http.MethodPost {
+		writeError(w, http.StatusNotFound, "route not found")
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid multipart body: "+err.Error())
+		return
+	}
+	if _, _, err := r.FormFile("context"); err != nil {
+		writeError(w, http.StatusBadRequest, "missing build context")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(owner, name)
+	id := newID("bld")
+	p.builds = append(p.builds, id)
+
+	writeJSON(w, http.StatusCreated, types.BuildsCreateResponse{BuildID: id})
+}
+
+func (s *Server) routeEndpoints(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(owner, name)
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		endpoints := []types.EndpointListItem{}
+		for _, e := range p.endpoints {
+			endpoints = append(endpoints, types.EndpointListItem{
+				ID:          e.ID,
+				Name:        e.Name,
+				ProjectID:   e.ProjectID,
+				HTTPAddress: e.HTTPAddress,
+				CreatedAt:   e.CreatedAt,
+			})
+		}
+		sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
+		writeJSON(w, http.StatusOK, types.EndpointList{Endpoints: endpoints})
+
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req types.EndpointCreate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if _, ok := s.findSession(owner, name, req.ExecID); !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", req.ExecID))
+			return
+		}
+		id := newID("end")
+		e := &types.Endpoint{
+			ID:          id,
+			Name:        req.Name,
+			ProjectID:   owner + "/" + name,
+			HTTPAddress: id + ".dev.unweave.local",
+			CreatedAt:   s.now(),
+		}
+		p.endpoints[id] = e
+		writeJSON(w, http.StatusCreated, e)
+
+	case len(parts) == 2 && parts[1] == "eval" && r.Method == http.MethodPut:
+		var req types.EndpointEvalAttach
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if _, ok := p.endpoints[parts[0]]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("endpoint %q not found", parts[0]))
+			return
+		}
+		if _, ok := p.evals[req.EvalID]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("eval %q not found", req.EvalID))
+			return
+		}
+		writeJSON(w, http.StatusOK, nil)
+
+	case len(parts) == 2 && parts[1] == "check" && r.Method == http.MethodPost:
+		if _, ok := p.endpoints[parts[0]]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("endpoint %q not found", parts[0]))
+			return
+		}
+		check := &types.EndpointCheck{CheckID: newID("chk")}
+		p.checks[check.CheckID] = check
+		writeJSON(w, http.StatusCreated, types.EndpointCheckRun{CheckID: check.CheckID})
+
+	case len(parts) == 2 && parts[1] == "version" && r.Method == http.MethodPost:
+		var req types.EndpointVersionCreate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		e, ok := p.endpoints[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("endpoint %q not found", parts[0]))
+			return
+		}
+		id := newID("ver")
+		writeJSON(w, http.StatusCreated, types.EndpointVersion{
+			ID:          id,
+			ExecID:      req.ExecID,
+			HTTPAddress: id + "." + e.HTTPAddress,
+			CreatedAt:   s.now(),
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+func (s *Server) routeChecks(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	if len(parts) != 1 || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "route not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	check, ok := s.project(owner, name).checks[parts[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("check %q not found", parts[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, check)
+}
+
+func (s *Server) routeEvals(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(owner, name)
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		evals := []types.Eval{}
+		for _, e := range p.evals {
+			evals = append(evals, *e)
+		}
+		sort.Slice(evals, func(i, j int) bool { return evals[i].ID < evals[j].ID })
+		writeJSON(w, http.StatusOK, types.EvalList{Evals: evals})
+
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req types.EvalCreate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		sess, ok := s.findSession(owner, name, req.ExecID)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", req.ExecID))
+			return
+		}
+		id := newID("evl")
+		e := &types.Eval{ID: id, ExecID: sess.exec.ID, HTTPEndpoint: "https://" + id + ".dev.unweave.local"}
+		p.evals[id] = e
+		writeJSON(w, http.StatusCreated, e)
+
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
@@ -0,0 +1,279 @@
+// Package devapi implements an in-memory fake of the Unweave API. It's used to run the
+// CLI end-to-end without network access and to test the HTTP paths of the client.
+package devapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+const (
+	DefaultAddr  = "localhost:4000"
+	DefaultToken = "dev-token"
+)
+
+// Transition moves a session to Status once After has elapsed since it was created.
+type Transition struct {
+	Status types.Status
+	After  time.Duration
+}
+
+// DefaultTransitions walks new sessions through pending and initializing and has them
+// running after a few seconds.
+var DefaultTransitions = []Transition{
+	{Status: types.StatusPending, After: 0},
+	{Status: types.StatusInitializing, After: 2 * time.Second},
+	{Status: types.StatusRunning, After: 5 * time.Second},
+}
+
+// DefaultNodeTypes are listed by the providers/node-types route if Config.NodeTypes is
+// empty.
+var DefaultNodeTypes = []types.NodeType{
+	nodeType("rtx_4000", "RTX 4000", 56, "us_east_1", "us_west_2"),
+	nodeType("rtx_5000", "RTX 5000", 78, "us_east_1"),
+	nodeType("a100", "A100 40GB", 220, "us_west_2"),
+	nodeType("h100", "H100 80GB", 499, "us_west_2"),
+}
+
+// Config configures a fake API Server.
+type Config struct {
+	// Account is returned by the account and pairing routes.
+	Account types.Account
+	// Token is handed out by the pairing flow. Requests are not authenticated.
+	Token string
+	// Transitions is the status schedule of new sessions. Defaults to DefaultTransitions.
+	Transitions []Transition
+	// NodeTypes are the node types every provider offers. Defaults to DefaultNodeTypes.
+	NodeTypes []types.NodeType
+	// SessionNetwork is the connection info given to new sessions. Point it at a local
+	// SSH server to exercise the ssh, exec and copy flows.
+	SessionNetwork types.ExecNetwork
+}
+
+type session struct {
+	exec      types.Exec
+	owner     string
+	project   string
+	createdAt time.Time
+	// pinned is set once the status has been set explicitly. Pinned sessions don't
+	// follow the transition schedule anymore.
+	pinned bool
+}
+
+type project struct {
+	volumes   map[string]*types.Volume
+	builds    []string
+	endpoints map[string]*types.Endpoint
+	evals     map[string]*types.Eval
+	checks    map[string]*types.EndpointCheck
+}
+
+// Server is an http.Handler serving the Unweave API routes from memory.
+type Server struct {
+	cfg Config
+	now func() time.Time
+
+	mu         sync.Mutex
+	sessions   map[string]*session
+	projects   map[string]*project
+	sshKeys    map[string][]types.SSHKey
+	pairings   map[string]bool
+	noCapacity map[string]bool
+	// idempotencyKeys maps the idempotency key of a create request to the session it
+	// created so that retried requests don't create a second session.
+	idempotencyKeys map[string]string
+}
+
+func NewServer(cfg Config) *Server {
+	if cfg.Token == "" {
+		cfg.Token = DefaultToken
+	}
+	if cfg.Account.UserID == "" {
+		cfg.Account = types.Account{UserID: "dev", Email: "dev@unweave.local", Providers: []string{"unweave"}}
+	}
+	if len(cfg.Transitions) == 0 {
+		cfg.Transitions = DefaultTransitions
+	}
+	if len(cfg.NodeTypes) == 0 {
+		cfg.NodeTypes = DefaultNodeTypes
+	}
+	if cfg.SessionNetwork.Host == "" {
+		cfg.SessionNetwork = types.ExecNetwork{Host: "localhost", Port: 2222, User: "root"}
+	}
+
+	return &Server{
+		cfg:        cfg,
+		now:        time.Now,
+		sessions:   map[string]*session{},
+		projects:   map[string]*project{},
+		sshKeys:    map[string][]types.SSHKey{},
+		pairings:   map[string]bool{},
+		noCapacity: map[string]bool{},
+
+		idempotencyKeys: map[string]string{},
+	}
+}
+
+// SetStatus pins the status of a session, overriding the transition schedule.
+func (s *Server) SetStatus(sessionID string, status types.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("session %q not found", sessionID)
+	}
+	sess.exec.Status = status
+	sess.pinned = true
+	return nil
+}
+
+// SetCapacity marks a node type as in or out of capacity. Creating a session on a node
+// type without capacity fails with a 503 like the real API.
+func (s *Server) SetCapacity(nodeTypeID string, available bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.noCapacity[nodeTypeID] = !available
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch parts[0] {
+	case "account":
+		s.routeAccount(w, r, parts[1:])
+	case "ssh-keys":
+		s.routeSSHKeys(w, r, parts[1:])
+	case "providers":
+		s.routeProviders(w, r, parts[1:])
+	case "projects":
+		s.routeProjects(w, r, parts[1:])
+	case "_dev":
+		s.routeDev(w, r, parts[1:])
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+func (s *Server) routeProjects(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) < 2 {
+		writeError(w, http.StatusNotFound, "route not found")
+		return
+	}
+	owner, name := parts[0], parts[1]
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, types.ProjectGetResponse{Project: types.Project{ID: owner + "/" + name, Name: name}})
+		return
+	}
+
+	switch parts[2] {
+	case "sessions":
+		s.routeSessions(w, r, owner, name, parts[3:])
+	case "volumes":
+		s.routeVolumes(w, r, owner, name, parts[3:])
+	case "builds":
+		s.routeBuilds(w, r, owner, name, parts[3:])
+	case "endpoints":
+		s.routeEndpoints(w, r, owner, name, parts[3:])
+	case "checks":
+		s.routeChecks(w, r, owner, name, parts[3:])
+	case "evals":
+		s.routeEvals(w, r, owner, name, parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+// routeDev serves routes that only exist on the fake server to script its state, e.g.
+// PUT /_dev/sessions/<id>/status {"status": "running"}
+func (s *Server) routeDev(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 3 && parts[0] == "sessions" && parts[2] == "status" && r.Method == http.MethodPut:
+		var body struct {
+			Status types.Status `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if err := s.SetStatus(parts[1], body.Status); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nil)
+	case len(parts) == 2 && parts[0] == "capacity" && r.Method == http.MethodPut:
+		var body struct {
+			Available bool `json:"available"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		s.SetCapacity(parts[1], body.Available)
+		writeJSON(w, http.StatusOK, nil)
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+// project returns the state of a project, creating it if it doesn't exist yet. The
+// caller must hold the lock.
+func (s *Server) project(owner, name string) *project {
+	key := owner + "/" + name
+	p, ok := s.projects[key]
+	if !ok {
+		p = &project{
+			volumes:   map[string]*types.Volume{},
+			endpoints: map[string]*types.Endpoint{},
+			evals:     map[string]*types.Eval{},
+			checks:    map[string]*types.EndpointCheck{},
+		}
+		s.projects[key] = p
+	}
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v == nil {
+		w.Write([]byte("{}"))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &types.Error{Code: status, Message: message})
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
+
+func nodeType(id, name string, price int, regions ...string) types.NodeType {
+	return types.NodeType{
+		ID:       id,
+		Name:     &name,
+		Price:    &price,
+		Regions:  regions,
+		Provider: types.UnweaveProvider,
+		Type:     "GPU",
+	}
+}
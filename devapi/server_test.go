@@ -0,0 +1,265 @@
+package devapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/unweave/api/types"
+)
+
+const (
+	owner       = "dev"
+	projectName = "project"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestServer(t *testing.T) (*Server, *client.Client, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)}
+
+	srv := NewServer(Config{})
+	srv.now = clock.Now
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	uwc := client.NewClient(client.Config{
+		ApiURL: httpSrv.URL,
+		Token:  DefaultToken,
+		Retry:  &client.RetryPolicy{MaxAttempts: 1},
+	})
+	return srv, uwc, clock
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should walk sessions through the transition schedule", func(t *testing.T) {
+		_, uwc, clock := newTestServer(t)
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{Provider: types.UnweaveProvider})
+		require.NoError(t, err)
+		assert.Equal(t, types.StatusPending, exec.Status)
+
+		clock.Advance(3 * time.Second)
+		exec, err = uwc.Exec.Get(ctx, owner, projectName, exec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.StatusInitializing, exec.Status)
+
+		clock.Advance(3 * time.Second)
+		exec, err = uwc.Exec.Get(ctx, owner, projectName, exec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.StatusRunning, exec.Status)
+		assert.Equal(t, "localhost", exec.Network.Host)
+	})
+
+	t.Run("should stream status changes", func(t *testing.T) {
+		_, uwc, clock := newTestServer(t)
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		watcher, ok := uwc.Exec.(interface {
+			Watch(ctx context.Context, owner, project, sessionID string) (<-chan types.Exec, error)
+		})
+		require.True(t, ok)
+		stream, err := watcher.Watch(ctx, owner, projectName, exec.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.StatusPending, (<-stream).Status)
+		clock.Advance(time.Minute)
+		assert.Equal(t, types.StatusRunning, (<-stream).Status)
+
+		require.NoError(t, uwc.Exec.Terminate(ctx, owner, projectName, exec.ID))
+		assert.Equal(t, types.StatusTerminated, (<-stream).Status)
+		_, open := <-stream
+		assert.False(t, open)
+	})
+
+	t.Run("should hide terminated sessions unless asked for", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{Name: "keep"})
+		require.NoError(t, err)
+		_, err = uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{Name: "other"})
+		require.NoError(t, err)
+
+		require.NoError(t, uwc.Exec.Terminate(ctx, owner, projectName, exec.ID))
+
+		active, err := uwc.Exec.List(ctx, owner, projectName, false)
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		assert.Equal(t, "other", active[0].Name)
+
+		all, err := uwc.Exec.List(ctx, owner, projectName, true)
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+
+	t.Run("should pin a scripted status", func(t *testing.T) {
+		srv, uwc, clock := newTestServer(t)
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPut, "/_dev/sessions/"+exec.ID+"/status", strings.NewReader(`{"status": "error"}`))
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		clock.Advance(time.Minute)
+		exec, err = uwc.Exec.Get(ctx, owner, projectName, exec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.StatusError, exec.Status)
+	})
+
+	t.Run("should fail with the available node types when out of capacity", func(t *testing.T) {
+		srv, uwc, _ := newTestServer(t)
+		srv.SetCapacity("a100", false)
+
+		params := types.ExecCreateParams{Provider: types.UnweaveProvider}
+		params.Spec.GPU.Type = "a100"
+		_, err := uwc.Exec.Create(ctx, owner, projectName, params)
+
+		var e *types.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusServiceUnavailable, e.Code)
+		assert.Contains(t, e.Suggestion, "rtx_4000")
+		assert.NotContains(t, e.Suggestion, "a100")
+	})
+
+	t.Run("should attach volumes and registered keys", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		vol, err := uwc.Volume.Create(ctx, owner, projectName, types.VolumeCreateRequest{Name: "data", Size: 10})
+		require.NoError(t, err)
+
+		key, err := uwc.SSHKey.Generate(ctx, owner, types.SSHKeyGenerateParams{})
+		require.NoError(t, err)
+		assert.Contains(t, key.PrivateKey, "PRIVATE KEY")
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{
+			SSHKeyName: key.Name,
+			Volumes:    []types.VolumeAttachParams{{VolumeRef: "data", MountPath: "/data"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, exec.Keys, 1)
+		assert.Equal(t, key.Name, exec.Keys[0].Name)
+		require.Len(t, exec.Volumes, 1)
+		assert.Equal(t, vol.ID, exec.Volumes[0].VolumeID)
+	})
+}
+
+func TestResources(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should create, resize and delete volumes", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		_, err := uwc.Volume.Create(ctx, owner, projectName, types.VolumeCreateRequest{Name: "data", Size: 10})
+		require.NoError(t, err)
+		require.NoError(t, uwc.Volume.Update(ctx, owner, projectName, "data", types.VolumeResizeRequest{IDOrName: "data", Size: 20}))
+
+		vols, err := uwc.Volume.List(ctx, owner, projectName)
+		require.NoError(t, err)
+		require.Len(t, vols, 1)
+		assert.Equal(t, 20, vols[0].Size)
+
+		require.NoError(t, uwc.Volume.Delete(ctx, owner, projectName, "data"))
+		vols, err = uwc.Volume.List(ctx, owner, projectName)
+		require.NoError(t, err)
+		assert.Empty(t, vols)
+	})
+
+	t.Run("should create builds", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		id, err := uwc.Build.Create(ctx, owner, projectName, types.BuildsCreateParams{
+			Builder:      "docker",
+			BuildContext: io.NopCloser(strings.NewReader("context")),
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, id)
+	})
+
+	t.Run("should deploy endpoints with evals", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		exec, err := uwc.Exec.Create(ctx, owner, projectName, types.ExecCreateParams{})
+		require.NoError(t, err)
+
+		eval, err := uwc.Evals.Create(ctx, owner, projectName, exec.ID)
+		require.NoError(t, err)
+		end, err := uwc.Endpoints.Create(ctx, owner, projectName, exec.ID, "api")
+		require.NoError(t, err)
+		require.NoError(t, uwc.Endpoints.EvalAttach(ctx, owner, projectName, end.ID, eval.ID))
+
+		_, err = uwc.Endpoints.CreateVersion(ctx, owner, projectName, end.ID, exec.ID)
+		require.NoError(t, err)
+
+		endpoints, err := uwc.Endpoints.List(ctx, owner, projectName)
+		require.NoError(t, err)
+		require.Len(t, endpoints, 1)
+		assert.Equal(t, "api", endpoints[0].Name)
+	})
+}
+
+func TestAccount(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should exchange a pairing code for the dev token", func(t *testing.T) {
+		_, uwc, _ := newTestServer(t)
+
+		code, err := uwc.Account.PairingTokenCreate(ctx)
+		require.NoError(t, err)
+
+		token, account, err := uwc.Account.PairingTokenExchange(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultToken, token)
+		assert.Equal(t, "dev", account.UserID)
+
+		_, _, err = uwc.Account.PairingTokenExchange(ctx, code)
+		assert.Error(t, err)
+	})
+
+	t.Run("should filter node types without capacity", func(t *testing.T) {
+		srv, uwc, _ := newTestServer(t)
+		srv.SetCapacity("h100", false)
+
+		all, err := uwc.Provider.ListNodeTypes(ctx, types.UnweaveProvider, false)
+		require.NoError(t, err)
+		assert.Len(t, all, len(DefaultNodeTypes))
+
+		available, err := uwc.Provider.ListNodeTypes(ctx, types.UnweaveProvider, true)
+		require.NoError(t, err)
+		assert.Len(t, available, len(DefaultNodeTypes)-1)
+	})
+}
@@ -0,0 +1,288 @@
+package devapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/unweave/cli/client"
+	"github.com/unweave/unweave/api/types"
+)
+
+// watchInterval is how often watch streams check for status changes.
+const watchInterval = 100 * time.Millisecond
+
+func (s *Server) routeSessions(w http.ResponseWriter, r *http.Request, owner, name string, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		s.listSessions(w, r, owner, name)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		s.createSession(w, r, owner, name)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.getSession(w, owner, name, parts[0])
+	case len(parts) == 2 && parts[1] == "watch" && r.Method == http.MethodGet:
+		s.watchSession(w, r, owner, name, parts[0])
+	case len(parts) == 2 && parts[1] == "terminate" && r.Method == http.MethodPut:
+		s.terminateSession(w, owner, name, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request, owner, name string) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid multipart body: "+err.Error())
+		return
+	}
+
+	var params types.ExecCreateParams
+	if err := json.Unmarshal([]byte(r.FormValue("params")), &params); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid params: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get(client.IdempotencyKeyHeader)
+	if sess, ok := s.sessions[s.idempotencyKeys[key]]; ok && key != "" {
+		writeJSON(w, http.StatusCreated, sess.exec)
+		return
+	}
+
+	if gpu := params.Spec.GPU.Type; gpu != "" && s.noCapacity[gpu] {
+		available, _ := json.Marshal(s.availableNodeTypes(params.Provider))
+		writeJSON(w, http.StatusServiceUnavailable, &types.Error{
+			Code:       http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("No capacity available for node type %q", gpu),
+			Suggestion: string(available),
+			Provider:   params.Provider,
+		})
+		return
+	}
+
+	keys, err := s.sessionKeys(owner, params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	volumes, err := s.sessionVolumes(owner, name, params.Volumes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	region := ""
+	if params.Region != nil {
+		region = *params.Region
+	}
+
+	now := s.now()
+	exec := types.Exec{
+		ID:        newID("sess"),
+		Name:      params.Name,
+		CreatedAt: now,
+		Status:    s.cfg.Transitions[0].Status,
+		Command:   params.Command,
+		Keys:      keys,
+		Volumes:   volumes,
+		Network:   s.cfg.SessionNetwork,
+		Spec:      params.Spec,
+		CommitID:  params.CommitID,
+		GitURL:    params.GitURL,
+		Region:    region,
+		Provider:  params.Provider,
+	}
+	if params.Image != nil {
+		exec.Image = *params.Image
+	}
+	if exec.Name == "" {
+		exec.Name = fmt.Sprintf("dev-session-%d", len(s.sessions)+1)
+	}
+	if params.InternalPort != 0 {
+		exec.Network.HTTPService = &types.HTTPService{
+			Hostname:     exec.ID + ".dev.unweave.local",
+			InternalPort: params.InternalPort,
+		}
+	}
+
+	sess := &session{exec: exec, owner: owner, project: name, createdAt: now}
+	s.sessions[exec.ID] = sess
+	if key != "" {
+		s.idempotencyKeys[key] = exec.ID
+	}
+	s.advance(sess)
+
+	writeJSON(w, http.StatusCreated, sess.exec)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, owner, name, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.findSession(owner, name, id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", id))
+		return
+	}
+	s.advance(sess)
+	writeJSON(w, http.StatusOK, sess.exec)
+}
+
+// watchSession streams the session as a server-sent event every time its status
+// changes until it ends or the client disconnects.
+func (s *Server) watchSession(w http.ResponseWriter, r *http.Request, owner, name, id string) {
+	s.mu.Lock()
+	_, ok := s.findSession(owner, name, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", id))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var last types.Status
+	for {
+		s.mu.Lock()
+		sess, ok := s.findSession(owner, name, id)
+		var exec types.Exec
+		if ok {
+			s.advance(sess)
+			exec = sess.exec
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			return
+		}
+		if exec.Status != last {
+			data, _ := json.Marshal(exec)
+			fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+			flusher.Flush()
+			last = exec.Status
+		}
+		if exec.Status == types.StatusTerminated || exec.Status == types.StatusError {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request, owner, name string) {
+	listTerminated := r.URL.Query().Get("terminated") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execs := []types.Exec{}
+	for _, sess := range s.sessions {
+		if sess.owner != owner || sess.project != name {
+			continue
+		}
+		s.advance(sess)
+		if sess.exec.Status == types.StatusTerminated && !listTerminated {
+			continue
+		}
+		execs = append(execs, sess.exec)
+	}
+	sort.Slice(execs, func(i, j int) bool {
+		return execs[i].CreatedAt.Before(execs[j].CreatedAt)
+	})
+
+	writeJSON(w, http.StatusOK, types.ExecsListResponse{Execs: execs})
+}
+
+func (s *Server) terminateSession(w http.ResponseWriter, owner, name, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.findSession(owner, name, id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", id))
+		return
+	}
+	sess.exec.Status = types.StatusTerminated
+	sess.pinned = true
+
+	writeJSON(w, http.StatusOK, types.ExecTerminateResponse{Success: true})
+}
+
+// findSession looks up a session of a project by ID or name. The caller must hold the
+// lock.
+func (s *Server) findSession(owner, name, ref string) (*session, bool) {
+	if sess, ok := s.sessions[ref]; ok && sess.owner == owner && sess.project == name {
+		return sess, true
+	}
+	for _, sess := range s.sessions {
+		if sess.owner == owner && sess.project == name && sess.exec.Name == ref {
+			return sess, true
+		}
+	}
+	return nil, false
+}
+
+// advance moves a session along the transition schedule. The caller must hold the lock.
+func (s *Server) advance(sess *session) {
+	if sess.pinned {
+		return
+	}
+
+	elapsed := s.now().Sub(sess.createdAt)
+	for _, t := range s.cfg.Transitions {
+		if elapsed >= t.After {
+			sess.exec.Status = t.Status
+		}
+	}
+}
+
+// sessionKeys resolves the SSH key of a new session, registering its public key if one
+// was sent. The caller must hold the lock.
+func (s *Server) sessionKeys(owner string, params types.ExecCreateParams) ([]types.SSHKey, error) {
+	if params.SSHPublicKey != "" {
+		key := s.addSSHKey(owner, params.SSHKeyName, params.SSHPublicKey)
+		return []types.SSHKey{key}, nil
+	}
+	if params.SSHKeyName == "" {
+		return nil, nil
+	}
+	for _, key := range s.sshKeys[owner] {
+		if key.Name == params.SSHKeyName {
+			return []types.SSHKey{key}, nil
+		}
+	}
+	return nil, fmt.Errorf("ssh key %q not found", params.SSHKeyName)
+}
+
+// sessionVolumes resolves volume attachments of a new session. The caller must hold
+// the lock.
+func (s *Server) sessionVolumes(owner, name string, attach []types.VolumeAttachParams) ([]types.ExecVolume, error) {
+	var volumes []types.ExecVolume
+	for _, a := range attach {
+		vol, ok := s.findVolume(owner, name, a.VolumeRef)
+		if !ok {
+			return nil, fmt.Errorf("volume %q not found", a.VolumeRef)
+		}
+		volumes = append(volumes, types.ExecVolume{VolumeID: vol.ID, MountPath: a.MountPath})
+	}
+	return volumes, nil
+}
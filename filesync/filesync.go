@@ -0,0 +1,519 @@
+// Package filesync keeps a local directory and a directory on a session in sync over an
+// ssh.Transport. Changes are found by comparing snapshots of both trees and only the
+// files that changed are copied.
+package filesync
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/ssh"
+	"github.com/unweave/cli/tools"
+)
+
+// DefaultInterval is the interval between checks for changes.
+const DefaultInterval = time.Second
+
+// maxBatch caps the number of paths passed to a single remote command.
+const maxBatch = 200
+
+// Matcher reports whether a slash separated path relative to the synced directory is
+// ignored. *ignore.GitIgnore implements it.
+type Matcher interface {
+	MatchesPath(path string) bool
+}
+
+// File is the state of a synced file.
+type File struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Snapshot maps the slash separated paths of the regular files in a directory to their
+// state.
+type Snapshot map[string]File
+
+// Scan returns a snapshot of the regular files below root that aren't ignored. Ignored
+// directories aren't walked.
+func Scan(root string, ignore Matcher) (Snapshot, error) {
+	snap := Snapshot{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if isIgnored(ignore, rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snap[rel] = File{Size: fi.Size(), ModTime: fi.ModTime()}
+		return nil
+	})
+	return snap, err
+}
+
+func isIgnored(ignore Matcher, rel string, isDir bool) bool {
+	if ignore == nil {
+		return false
+	}
+	// Patterns with a trailing slash only match directories when the path has one too.
+	return ignore.MatchesPath(rel) || (isDir && ignore.MatchesPath(rel+"/"))
+}
+
+// Changes lists the paths that were created, modified or removed between two snapshots.
+type Changes struct {
+	Updated []string
+	Removed []string
+}
+
+// Empty returns whether there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// union returns the paths in a or b, sorted.
+func union(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	seen := map[string]bool{}
+	var res []string
+	for _, p := range append(append([]string{}, a...), b...) {
+		if !seen[p] {
+			seen[p] = true
+			res = append(res, p)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// without returns the changes that don't touch any of the paths in other.
+func (c Changes) without(other Changes) Changes {
+	skip := map[string]bool{}
+	for _, p := range other.Updated {
+		skip[p] = true
+	}
+	for _, p := range other.Removed {
+		skip[p] = true
+	}
+
+	var res Changes
+	for _, p := range c.Updated {
+		if !skip[p] {
+			res.Updated = append(res.Updated, p)
+		}
+	}
+	for _, p := range c.Removed {
+		if !skip[p] {
+			res.Removed = append(res.Removed, p)
+		}
+	}
+	return res
+}
+
+// Diff returns the changes that turn prev into next.
+func Diff(prev, next Snapshot) Changes {
+	var c Changes
+	for p, f := range next {
+		if old, ok := prev[p]; !ok || old.Size != f.Size || !old.ModTime.Equal(f.ModTime) {
+			c.Updated = append(c.Updated, p)
+		}
+	}
+	for p := range prev {
+		if _, ok := next[p]; !ok {
+			c.Removed = append(c.Removed, p)
+		}
+	}
+	sort.Strings(c.Updated)
+	sort.Strings(c.Removed)
+	return c
+}
+
+// newer returns the paths in src that are missing from dst or were modified after their
+// counterpart in dst. Modification times are compared in whole seconds since that's all
+// tar archives keep. If sizeWins is set, files of a different size with the same
+// modification time count as newer too.
+func newer(src, dst Snapshot, sizeWins bool) []string {
+	var paths []string
+	for p, f := range src {
+		d, ok := dst[p]
+		switch {
+		case !ok, f.ModTime.Unix() > d.ModTime.Unix():
+			paths = append(paths, p)
+		case sizeWins && f.ModTime.Unix() == d.ModTime.Unix() && f.Size != d.Size:
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Direction is the direction changes were copied in.
+type Direction string
+
+const (
+	// Push copies local changes to the session.
+	Push Direction = "push"
+	// Pull copies changes on the session to the local directory.
+	Pull Direction = "pull"
+)
+
+// Event reports changes that were copied.
+type Event struct {
+	Direction Direction
+	Changes   Changes
+}
+
+// Syncer syncs LocalDir to RemoteDir on a session. Local changes always win: if a file
+// changed on both ends, the local copy overwrites the remote one.
+type Syncer struct {
+	Transport ssh.Transport
+	LocalDir  string
+	RemoteDir string
+	// Ignore, if not nil, excludes paths from syncing on both ends.
+	Ignore Matcher
+	// Interval is the interval between checks for changes. It defaults to
+	// DefaultInterval.
+	Interval time.Duration
+	// Pull enables copying changes made on the session back to LocalDir.
+	Pull bool
+	// OnSync, if not nil, is called after changes were copied.
+	OnSync func(Event)
+
+	local  Snapshot
+	remote Snapshot
+	// retry are the local files that changed while they were pushed, so they're pushed
+	// again by the next step.
+	retry []string
+}
+
+// Run syncs both directories once and then keeps them in sync until ctx is done.
+func (s *Syncer) Run(ctx context.Context) error {
+	if err := s.Init(ctx); err != nil {
+		return err
+	}
+
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := s.Step(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Init copies the local files that are missing or older on the session and, if Pull is
+// set, the remote files that are missing or older locally. Files that only exist on
+// the session are left alone unless Pull is set.
+func (s *Syncer) Init(ctx context.Context) error {
+	local, err := Scan(s.LocalDir, s.Ignore)
+	if err != nil {
+		return err
+	}
+	remote, err := s.scanRemote(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = s.push(ctx, Changes{Updated: newer(local, remote, true)}); err != nil {
+		return err
+	}
+	if s.Pull {
+		if err = s.pull(ctx, Changes{Updated: newer(remote, local, false)}); err != nil {
+			return err
+		}
+	}
+	return s.rescan(ctx, true, true)
+}
+
+// Step copies the changes made since the last call to Init or Step.
+func (s *Syncer) Step(ctx context.Context) error {
+	if s.local == nil {
+		return s.Init(ctx)
+	}
+
+	local, err := Scan(s.LocalDir, s.Ignore)
+	if err != nil {
+		return err
+	}
+	push := Diff(s.local, local)
+	push.Updated = union(push.Updated, s.retry)
+	s.local = local
+
+	var pull Changes
+	if s.Pull {
+		remote, err := s.scanRemote(ctx)
+		if err != nil {
+			return err
+		}
+		pull = Diff(s.remote, remote).without(push)
+		s.remote = remote
+	}
+
+	if err = s.push(ctx, push); err != nil {
+		return err
+	}
+	if err = s.pull(ctx, pull); err != nil {
+		return err
+	}
+	return s.rescan(ctx, !pull.Empty(), s.Pull && !push.Empty())
+}
+
+// rescan updates the snapshots after copying so that copied files aren't reported as
+// changes on the other end.
+func (s *Syncer) rescan(ctx context.Context, local, remote bool) error {
+	var err error
+	if local {
+		if s.local, err = Scan(s.LocalDir, s.Ignore); err != nil {
+			return err
+		}
+	}
+	if remote {
+		if s.remote, err = s.scanRemote(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) push(ctx context.Context, c Changes) error {
+	if len(c.Updated) > 0 {
+		f, err := os.CreateTemp("", "uw-sync-*.tar.gz")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+
+		changed, err := tools.TarFiles(s.LocalDir, c.Updated, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to archive changes: %w", err)
+		}
+		s.retry = changed
+		c.Updated = Changes{Updated: c.Updated}.without(Changes{Updated: changed}).Updated
+
+		archive := remoteArchive()
+		if err = s.Transport.Upload(ctx, f.Name(), archive); err != nil {
+			return fmt.Errorf("failed to upload changes: %w", err)
+		}
+		command := fmt.Sprintf("mkdir -p %s && tar -xzf %s -C %s; status=$?; rm -f %s; exit $status",
+			ssh.Quote(s.RemoteDir), ssh.Quote(archive), ssh.Quote(s.RemoteDir), ssh.Quote(archive))
+		if _, err = s.Transport.Run(ctx, command); err != nil {
+			return fmt.Errorf("failed to extract changes: %w", err)
+		}
+	}
+
+	for _, batch := range batches(c.Removed) {
+		command := fmt.Sprintf("cd %s && rm -f -- %s", ssh.Quote(s.RemoteDir), quoteAll(batch))
+		if _, err := s.Transport.Run(ctx, command); err != nil {
+			return fmt.Errorf("failed to remove files: %w", err)
+		}
+	}
+
+	if !c.Empty() && s.OnSync != nil {
+		s.OnSync(Event{Direction: Push, Changes: c})
+	}
+	return nil
+}
+
+func (s *Syncer) pull(ctx context.Context, c Changes) error {
+	for _, batch := range batches(c.Updated) {
+		if err := s.download(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range c.Removed {
+		target, err := localPath(s.LocalDir, p)
+		if err != nil {
+			return err
+		}
+		if err = os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if !c.Empty() && s.OnSync != nil {
+		s.OnSync(Event{Direction: Pull, Changes: c})
+	}
+	return nil
+}
+
+// download copies the remote files at paths to the local directory as a single archive.
+func (s *Syncer) download(ctx context.Context, paths []string) error {
+	archive := remoteArchive()
+	command := fmt.Sprintf("cd %s && tar -czf %s -- %s", ssh.Quote(s.RemoteDir), ssh.Quote(archive), quoteAll(paths))
+	if _, err := s.Transport.Run(ctx, command); err != nil {
+		return fmt.Errorf("failed to archive remote changes: %w", err)
+	}
+	defer s.Transport.Run(ctx, "rm -f "+ssh.Quote(archive))
+
+	f, err := os.CreateTemp("", "uw-sync-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if err = s.Transport.Download(ctx, archive, f.Name()); err != nil {
+		return fmt.Errorf("failed to download remote changes: %w", err)
+	}
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return tools.Untar(f, s.LocalDir, 0)
+}
+
+// scanRemote returns a snapshot of the remote directory. It's created if it doesn't
+// exist yet.
+func (s *Syncer) scanRemote(ctx context.Context) (Snapshot, error) {
+	command := fmt.Sprintf(`mkdir -p %s && cd %s && find . -type f -printf '%%P\t%%s\t%%T@\n'`,
+		ssh.Quote(s.RemoteDir), ssh.Quote(s.RemoteDir))
+	res, err := s.Transport.Run(ctx, command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote files: %w", err)
+	}
+	return parseFind(string(res.Stdout), s.Ignore)
+}
+
+// parseFind parses the output of find -printf '%P\t%s\t%T@\n'.
+func parseFind(out string, ignore Matcher) (Snapshot, error) {
+	snap := Snapshot{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected remote file listing %q", line)
+		}
+		p := fields[0]
+		if isIgnoredPath(ignore, p) {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size for %q: %w", p, err)
+		}
+		modTime, err := parseUnixTime(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid modification time for %q: %w", p, err)
+		}
+		snap[p] = File{Size: size, ModTime: modTime}
+	}
+	return snap, nil
+}
+
+// isIgnoredPath returns whether p or any of its parent directories is ignored.
+func isIgnoredPath(ignore Matcher, p string) bool {
+	if ignore == nil {
+		return false
+	}
+	parts := strings.Split(p, "/")
+	for i := 1; i < len(parts); i++ {
+		if isIgnored(ignore, strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return isIgnored(ignore, p, false)
+}
+
+// parseUnixTime parses seconds since the epoch with an optional fraction.
+func parseUnixTime(s string) (time.Time, error) {
+	secs, frac, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		frac += strings.Repeat("0", 9-len(frac))
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// localPath resolves the slash separated path p below root and makes sure it doesn't
+// escape it.
+func localPath(root, p string) (string, error) {
+	target := filepath.Join(root, filepath.FromSlash(p))
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes the sync directory", p)
+	}
+	return target, nil
+}
+
+// remoteArchive returns a path on the session for a temporary archive.
+func remoteArchive() string {
+	return fmt.Sprintf("/tmp/uw-sync-%d.tar.gz", time.Now().UnixNano())
+}
+
+func batches(paths []string) [][]string {
+	var res [][]string
+	for len(paths) > maxBatch {
+		res = append(res, paths[:maxBatch])
+		paths = paths[maxBatch:]
+	}
+	if len(paths) > 0 {
+		res = append(res, paths)
+	}
+	return res
+}
+
+func quoteAll(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = ssh.Quote(p)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,198 @@
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/ssh"
+)
+
+// localTransport runs commands with the local shell and copies files on the local
+// filesystem, standing in for a session.
+type localTransport struct{}
+
+func (localTransport) Shell(ctx context.Context, command []string) error {
+	return errors.New("not supported")
+}
+
+func (localTransport) Run(ctx context.Context, command string) (*ssh.Result, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	res := &ssh.Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitStatus = exitErr.ExitCode()
+		return res, &ssh.ExitError{Command: command, ExitStatus: res.ExitStatus, Stderr: stderr.String()}
+	}
+	return res, err
+}
+
+func (localTransport) Upload(ctx context.Context, src, dst string) error {
+	return copyFile(src, dst)
+}
+
+func (localTransport) Download(ctx context.Context, src, dst string) error {
+	return copyFile(src, dst)
+}
+
+func (localTransport) Close() error { return nil }
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func writeFile(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(p, modTime, modTime))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func newTestSyncer(t *testing.T, pull bool) (*Syncer, *[]Event) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("the remote file listing needs GNU find")
+	}
+
+	var events []Event
+	return &Syncer{
+		Transport: localTransport{},
+		LocalDir:  t.TempDir(),
+		RemoteDir: filepath.Join(t.TempDir(), "project"),
+		Ignore:    ignore.CompileIgnoreLines(".git", "build/"),
+		Pull:      pull,
+		OnSync:    func(e Event) { events = append(events, e) },
+	}, &events
+}
+
+func TestSyncerInit(t *testing.T) {
+	s, events := newTestSyncer(t, false)
+	old := time.Now().Add(-time.Hour)
+
+	writeFile(t, s.LocalDir, "main.py", "print('hi')", old)
+	writeFile(t, s.LocalDir, "pkg/util.py", "x = 1", old)
+	writeFile(t, s.LocalDir, ".git/HEAD", "ref", old)
+	writeFile(t, s.LocalDir, "build/out.bin", "bin", old)
+	writeFile(t, s.RemoteDir, "outputs/model.pt", "weights", old)
+
+	require.NoError(t, s.Init(context.Background()))
+
+	assert.Equal(t, "print('hi')", readFile(t, s.RemoteDir, "main.py"))
+	assert.Equal(t, "x = 1", readFile(t, s.RemoteDir, "pkg/util.py"))
+	assert.NoFileExists(t, filepath.Join(s.RemoteDir, ".git", "HEAD"))
+	assert.NoFileExists(t, filepath.Join(s.RemoteDir, "build", "out.bin"))
+	assert.FileExists(t, filepath.Join(s.RemoteDir, "outputs", "model.pt"))
+	assert.NoFileExists(t, filepath.Join(s.LocalDir, "outputs", "model.pt"))
+
+	require.Len(t, *events, 1)
+	assert.Equal(t, Push, (*events)[0].Direction)
+	assert.Equal(t, []string{"main.py", "pkg/util.py"}, (*events)[0].Changes.Updated)
+
+	// A second sync finds nothing to copy.
+	*events = nil
+	require.NoError(t, s.Init(context.Background()))
+	assert.Empty(t, *events)
+}
+
+func TestSyncerStepPush(t *testing.T) {
+	s, events := newTestSyncer(t, false)
+	old := time.Now().Add(-time.Hour)
+
+	writeFile(t, s.LocalDir, "main.py", "v1", old)
+	writeFile(t, s.LocalDir, "stale.py", "gone soon", old)
+	require.NoError(t, s.Init(context.Background()))
+	*events = nil
+
+	writeFile(t, s.LocalDir, "main.py", "v2", time.Now())
+	require.NoError(t, os.Remove(filepath.Join(s.LocalDir, "stale.py")))
+	require.NoError(t, s.Step(context.Background()))
+
+	assert.Equal(t, "v2", readFile(t, s.RemoteDir, "main.py"))
+	assert.NoFileExists(t, filepath.Join(s.RemoteDir, "stale.py"))
+	require.Len(t, *events, 1)
+	assert.Equal(t, Changes{Updated: []string{"main.py"}, Removed: []string{"stale.py"}}, (*events)[0].Changes)
+
+	*events = nil
+	require.NoError(t, s.Step(context.Background()))
+	assert.Empty(t, *events)
+}
+
+func TestSyncerStepPull(t *testing.T) {
+	s, events := newTestSyncer(t, true)
+	old := time.Now().Add(-time.Hour)
+
+	writeFile(t, s.LocalDir, "main.py", "v1", old)
+	writeFile(t, s.RemoteDir, "outputs/log.txt", "epoch 1", old)
+	require.NoError(t, s.Init(context.Background()))
+	assert.Equal(t, "epoch 1", readFile(t, s.LocalDir, "outputs/log.txt"))
+	*events = nil
+
+	writeFile(t, s.RemoteDir, "outputs/log.txt", "epoch 2", time.Now())
+	writeFile(t, s.RemoteDir, "build/cache", "ignored", time.Now())
+	require.NoError(t, s.Step(context.Background()))
+
+	assert.Equal(t, "epoch 2", readFile(t, s.LocalDir, "outputs/log.txt"))
+	assert.NoFileExists(t, filepath.Join(s.LocalDir, "build", "cache"))
+	require.Len(t, *events, 1)
+	assert.Equal(t, Pull, (*events)[0].Direction)
+
+	// Pulled files aren't pushed back and local changes win over remote ones.
+	*events = nil
+	writeFile(t, s.LocalDir, "main.py", "local", time.Now())
+	writeFile(t, s.RemoteDir, "main.py", "remote", time.Now())
+	require.NoError(t, s.Step(context.Background()))
+
+	assert.Equal(t, "local", readFile(t, s.RemoteDir, "main.py"))
+	require.Len(t, *events, 1)
+	assert.Equal(t, Changes{Updated: []string{"main.py"}}, (*events)[0].Changes)
+
+	*events = nil
+	require.NoError(t, s.Step(context.Background()))
+	assert.Empty(t, *events)
+}
+
+func TestParseUnixTime(t *testing.T) {
+	ts, err := parseUnixTime("1697040000.25")
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1697040000, 250000000), ts)
+
+	ts, err = parseUnixTime("1697040000")
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1697040000, 0), ts)
+}
@@ -0,0 +1,263 @@
+// Package jobs tracks commands that run detached on a session. Every job gets its own
+// directory on the session holding its PID, output and, once it finishes, its exit
+// code. A local registry remembers which session a job runs on.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/ssh"
+)
+
+// RemoteDir is the directory on the session that holds the job directories.
+const RemoteDir = "/logs/jobs"
+
+// LatestLogFile always links to the output of the latest job on a session.
+const LatestLogFile = "/logs/exec.log"
+
+// DefaultPollInterval is the interval between status checks while waiting for a job.
+const DefaultPollInterval = 2 * time.Second
+
+// MaxWaitRetries is how many times in a row Wait retries getting the state of a job
+// before it gives up.
+const MaxWaitRetries = 5
+
+// maxRetryBackoff caps the time Wait waits between retries.
+const maxRetryBackoff = 30 * time.Second
+
+// killedExitCode is recorded for jobs stopped with Kill, like a shell reports SIGTERM.
+const killedExitCode = 143
+
+var (
+	// ErrJobNotFound is returned if no job matches a reference.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrAmbiguousJob is returned if a reference matches more than one job.
+	ErrAmbiguousJob = errors.New("job reference is ambiguous")
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusUnknown is used if the job isn't running but didn't record an exit code,
+	// e.g. because the session was restarted, or if the session can't be reached.
+	StatusUnknown Status = "unknown"
+)
+
+// Job is a command that runs detached on a session.
+type Job struct {
+	ID        string    `json:"id"`
+	Project   string    `json:"project"`
+	SessionID string    `json:"session_id"`
+	Command   []string  `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	PID       int       `json:"pid,omitempty"`
+	// Dir is the directory of the job on the session.
+	Dir string `json:"dir"`
+
+	// State is the last known state of the job.
+	State State `json:"state"`
+}
+
+// State is the state of a job at a point in time.
+type State struct {
+	Status Status `json:"status"`
+	// ExitCode is set once the job has finished.
+	ExitCode *int `json:"exit_code,omitempty"`
+	PID      int  `json:"pid,omitempty"`
+}
+
+// Done returns whether the job has finished.
+func (s State) Done() bool {
+	return s.Status == StatusSucceeded || s.Status == StatusFailed
+}
+
+// New returns a job for command with a new ID.
+func New(project string, command []string) Job {
+	b := make([]byte, 4)
+	rand.Read(b)
+	id := "job-" + hex.EncodeToString(b)
+
+	return Job{
+		ID:        id,
+		Project:   project,
+		Command:   command,
+		StartedAt: time.Now().UTC(),
+		Dir:       path.Join(RemoteDir, id),
+		State:     State{Status: StatusRunning},
+	}
+}
+
+// LogFile returns the file on the session the output of the job is written to.
+func (j Job) LogFile() string {
+	return path.Join(j.Dir, "output.log")
+}
+
+func (j Job) exitCodeFile() string {
+	return path.Join(j.Dir, "exit_code")
+}
+
+func (j Job) pidFile() string {
+	return path.Join(j.Dir, "pid")
+}
+
+// StartCommand returns the shell command that starts the job on the session. The job
+// runs in its own process group so that it survives the connection closing and can be
+// killed along with its children. Its exit code is written to the job directory when it
+// finishes.
+func (j Job) StartCommand() string {
+	exitCode := j.exitCodeFile()
+	// The command runs in a subshell so that an explicit exit still records the code.
+	script := "(\n" + strings.Join(j.Command, " ") + "\n)\n" +
+		fmt.Sprintf("code=$?; echo $code > %s.tmp && mv %s.tmp %s", ssh.Quote(exitCode), ssh.Quote(exitCode), ssh.Quote(exitCode))
+
+	return fmt.Sprintf("mkdir -p %s && { setsid nohup bash -c %s > %s 2>&1 < /dev/null & echo $! > %s; } && { ln -sf %s %s 2>/dev/null || true; }",
+		ssh.Quote(j.Dir), ssh.Quote(script), ssh.Quote(j.LogFile()), ssh.Quote(j.pidFile()), ssh.Quote(j.LogFile()), ssh.Quote(LatestLogFile))
+}
+
+// Inspect returns the current state of a job.
+func Inspect(ctx context.Context, t ssh.Transport, j Job) (State, error) {
+	command := fmt.Sprintf(`pid=$(cat %s 2>/dev/null); `+
+		`if [ -f %s ]; then echo "exited $pid $(cat %s)"; `+
+		`elif [ -n "$pid" ] && kill -0 "$pid" 2>/dev/null; then echo "running $pid"; `+
+		`else echo "lost $pid"; fi`,
+		ssh.Quote(j.pidFile()), ssh.Quote(j.exitCodeFile()), ssh.Quote(j.exitCodeFile()))
+
+	res, err := t.Run(ctx, command)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to get status of job %s: %w", j.ID, err)
+	}
+	return parseState(string(res.Stdout))
+}
+
+func parseState(out string) (State, error) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return State{}, fmt.Errorf("unexpected job status %q", out)
+	}
+
+	var state State
+	if len(fields) > 1 {
+		state.PID, _ = strconv.Atoi(fields[1])
+	}
+
+	switch fields[0] {
+	case "running":
+		state.Status = StatusRunning
+	case "lost":
+		state.Status = StatusUnknown
+	case "exited":
+		if len(fields) != 3 {
+			return State{}, fmt.Errorf("unexpected job status %q", out)
+		}
+		code, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return State{}, fmt.Errorf("invalid exit code %q: %w", fields[2], err)
+		}
+		state.ExitCode = &code
+		state.Status = StatusSucceeded
+		if code != 0 {
+			state.Status = StatusFailed
+		}
+	default:
+		return State{}, fmt.Errorf("unexpected job status %q", out)
+	}
+	return state, nil
+}
+
+// Dialer connects to the session a job runs on.
+type Dialer func(ctx context.Context) (ssh.Transport, error)
+
+// Wait polls a job until it has finished or its state is unknown and returns its last
+// state. interval defaults to DefaultPollInterval.
+//
+// Failing to get the state of the job, e.g. because the connection dropped, is retried
+// with backoff up to MaxWaitRetries times in a row. If redial isn't nil, it's used to
+// reconnect before every retry. Wait closes the transports it dials, but not t.
+func Wait(ctx context.Context, t ssh.Transport, redial Dialer, j Job, interval time.Duration) (State, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	current := t
+	defer func() {
+		if current != t {
+			current.Close()
+		}
+	}()
+
+	var state State
+	failures := 0
+	for {
+		next := interval
+		s, err := Inspect(ctx, current, j)
+		switch {
+		case err == nil:
+			failures = 0
+			state = s
+			if state.Status != StatusRunning {
+				return state, nil
+			}
+		case failures == MaxWaitRetries:
+			return state, err
+		default:
+			failures++
+			next = retryBackoff(interval, failures)
+		}
+
+		timer := time.NewTimer(next)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return state, ctx.Err()
+		}
+
+		if failures > 0 && redial != nil {
+			dialed, err := redial(ctx)
+			if err != nil {
+				continue
+			}
+			if current != t {
+				current.Close()
+			}
+			current = dialed
+		}
+	}
+}
+
+// retryBackoff returns how long to wait before retrying after the given number of
+// failures in a row.
+func retryBackoff(interval time.Duration, failures int) time.Duration {
+	backoff := interval
+	for i := 1; i < failures && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// Kill stops a running job and all of its child processes and records it as failed.
+func Kill(ctx context.Context, t ssh.Transport, j Job) error {
+	command := fmt.Sprintf(`pid=$(cat %s 2>/dev/null) && [ -n "$pid" ] && kill -TERM -- -"$pid" 2>/dev/null; `+
+		`[ -f %s ] || echo %d > %s`,
+		ssh.Quote(j.pidFile()), ssh.Quote(j.exitCodeFile()), killedExitCode, ssh.Quote(j.exitCodeFile()))
+
+	if _, err := t.Run(ctx, command); err != nil {
+		return fmt.Errorf("failed to kill job %s: %w", j.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/ssh"
+)
+
+// shellTransport runs commands with the local shell, standing in for a session.
+type shellTransport struct {
+	ssh.Transport
+}
+
+func (shellTransport) Run(ctx context.Context, command string) (*ssh.Result, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	res := &ssh.Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitStatus = exitErr.ExitCode()
+		return res, &ssh.ExitError{Command: command, ExitStatus: res.ExitStatus, Stderr: stderr.String()}
+	}
+	return res, err
+}
+
+// droppedTransport fails every command, like a connection that dropped.
+type droppedTransport struct {
+	ssh.Transport
+}
+
+func (droppedTransport) Run(context.Context, string) (*ssh.Result, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+func (droppedTransport) Close() error { return nil }
+
+func (shellTransport) Close() error { return nil }
+
+func startTestJob(t *testing.T, command ...string) (ssh.Transport, Job) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("jobs need setsid")
+	}
+
+	tr := shellTransport{}
+	job := New("owner/project", command)
+	job.Dir = filepath.Join(t.TempDir(), job.ID)
+
+	_, err := tr.Run(context.Background(), job.StartCommand())
+	require.NoError(t, err)
+	return tr, job
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should report the exit code of a finished job", func(t *testing.T) {
+		tr, job := startTestJob(t, "echo", "training", "&&", "exit", "3")
+
+		state, err := Wait(ctx, tr, nil, job, 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, state.Status)
+		require.NotNil(t, state.ExitCode)
+		assert.Equal(t, 3, *state.ExitCode)
+		assert.NotZero(t, state.PID)
+
+		out, err := tr.Run(ctx, "cat "+ssh.Quote(job.LogFile()))
+		require.NoError(t, err)
+		assert.Equal(t, "training\n", string(out.Stdout))
+	})
+
+	t.Run("should report a successful job", func(t *testing.T) {
+		tr, job := startTestJob(t, "true")
+
+		state, err := Wait(ctx, tr, nil, job, 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSucceeded, state.Status)
+		assert.True(t, state.Done())
+	})
+
+	t.Run("should reconnect if the connection drops", func(t *testing.T) {
+		tr, job := startTestJob(t, "sleep", "0.2", "&&", "exit", "4")
+
+		redials := 0
+		redial := func(context.Context) (ssh.Transport, error) {
+			redials++
+			if redials == 1 {
+				return nil, errors.New("no route to host")
+			}
+			return tr, nil
+		}
+		state, err := Wait(ctx, droppedTransport{}, redial, job, 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, state.Status)
+		assert.Equal(t, 4, *state.ExitCode)
+		assert.Equal(t, 2, redials)
+	})
+
+	t.Run("should give up once the retries are used up", func(t *testing.T) {
+		job := New("owner/project", []string{"true"})
+
+		_, err := Wait(ctx, droppedTransport{}, nil, job, time.Millisecond)
+		assert.ErrorContains(t, err, "connection reset by peer")
+	})
+
+	t.Run("should kill a running job", func(t *testing.T) {
+		tr, job := startTestJob(t, "sleep", "30")
+
+		state, err := Inspect(ctx, tr, job)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRunning, state.Status)
+
+		require.NoError(t, Kill(ctx, tr, job))
+
+		state, err = Inspect(ctx, tr, job)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, state.Status)
+		assert.Equal(t, killedExitCode, *state.ExitCode)
+	})
+
+	t.Run("should report jobs without a PID or exit code as unknown", func(t *testing.T) {
+		job := New("owner/project", []string{"true"})
+		job.Dir = t.TempDir()
+
+		state, err := Inspect(ctx, shellTransport{}, job)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUnknown, state.Status)
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(filepath.Join(t.TempDir(), "jobs.json"))
+
+	jobs, err := r.List("")
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	older := Job{ID: "job-aaaa1111", Project: "owner/one", StartedAt: time.Now().Add(-time.Hour)}
+	newer := Job{ID: "job-aaaa2222", Project: "owner/one", StartedAt: time.Now()}
+	other := Job{ID: "job-bbbb3333", Project: "owner/two", StartedAt: time.Now()}
+	for _, j := range []Job{older, newer, other} {
+		require.NoError(t, r.Save(j))
+	}
+
+	jobs, err = r.List("owner/one")
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, newer.ID, jobs[0].ID)
+	assert.Equal(t, older.ID, jobs[1].ID)
+
+	got, err := r.Get("owner/one", "aaaa2")
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, got.ID)
+
+	_, err = r.Get("owner/one", "job-aaaa")
+	assert.ErrorIs(t, err, ErrAmbiguousJob)
+
+	_, err = r.Get("owner/one", "bbbb")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	code := 0
+	newer.State = State{Status: StatusSucceeded, ExitCode: &code}
+	require.NoError(t, r.Save(newer))
+
+	got, err = r.Get("owner/one", newer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, got.State.Status)
+
+	jobs, err = r.List("")
+	require.NoError(t, err)
+	assert.Len(t, jobs, 3)
+
+	t.Run("should keep the jobs saved at the same time", func(t *testing.T) {
+		r := NewRegistry(filepath.Join(t.TempDir(), "jobs.json"))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, r.Save(Job{ID: fmt.Sprintf("job-%08d", i)}))
+			}(i)
+		}
+		wg.Wait()
+
+		jobs, err := r.List("")
+		require.NoError(t, err)
+		assert.Len(t, jobs, 20)
+	})
+}
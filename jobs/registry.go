@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/unweave/cli/tools"
+)
+
+// Registry stores the jobs started from this machine in a JSON file.
+type Registry struct {
+	path string
+}
+
+// NewRegistry returns a Registry backed by the file at path. The file is created when
+// the first job is saved.
+func NewRegistry(path string) *Registry {
+	return &Registry{path: path}
+}
+
+// List returns the jobs of project, most recent first. If project is empty, the jobs
+// of all projects are returned.
+func (r *Registry) List(project string) ([]Job, error) {
+	all, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(all))
+	for _, j := range all {
+		if project == "" || j.Project == project {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].StartedAt.After(jobs[k].StartedAt) })
+	return jobs, nil
+}
+
+// Get returns the job of project with the ID ref. A unique prefix of the ID, with or
+// without the "job-" prefix, matches too.
+func (r *Registry) Get(project, ref string) (Job, error) {
+	jobs, err := r.List(project)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var matches []Job
+	for _, j := range jobs {
+		if j.ID == ref {
+			return j, nil
+		}
+		if strings.HasPrefix(j.ID, ref) || strings.HasPrefix(strings.TrimPrefix(j.ID, "job-"), ref) {
+			matches = append(matches, j)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return Job{}, fmt.Errorf("%w: %s matches %d jobs", ErrAmbiguousJob, ref, len(matches))
+	}
+}
+
+// Save adds a job to the registry or updates it if it exists. The registry is locked
+// while it's updated, so that CLIs running at the same time don't lose each other's jobs.
+func (r *Registry) Save(job Job) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+	unlock, err := tools.LockFile(r.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	jobs, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, j := range jobs {
+		if j.ID == job.ID {
+			jobs[i] = job
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		jobs = append(jobs, job)
+	}
+
+	buf, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return tools.WriteFileAtomic(r.path, buf, 0600)
+}
+
+func (r *Registry) load() ([]Job, error) {
+	buf, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	if err = json.Unmarshal(buf, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to read job registry %s: %w", r.path, err)
+	}
+	return jobs, nil
+}
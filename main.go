@@ -2,17 +2,21 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/muesli/reflow/wordwrap"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
 	"github.com/unweave/cli/cmd"
 	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/devapi"
+	"github.com/unweave/cli/session"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/cli/vars"
 	"github.com/unweave/unweave/api/types"
@@ -29,12 +33,23 @@ var (
 		Short:         "Create serverless sessions to train your ML models",
 		Args:          cobra.MinimumNArgs(0),
 		SilenceUsage:  false,
-		SilenceErrors: false,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		SilenceErrors: true,
+		PersistentPreRun: func(c *cobra.Command, args []string) {
 			if config.OutputJSON {
 				ui.Output = os.Stderr
 				ui.OutputJSON = true
 			}
+			// The config depends on the --context flag, so it's loaded once flags are parsed
+			if err := config.Init(); err != nil {
+				ui.Errorf("%s", err)
+				if errors.Is(err, config.ErrContextNotFound) {
+					os.Exit(1)
+				}
+			}
+			// The config commands report the issues themselves
+			if c.Name() != "config" && !(c.HasParent() && c.Parent().Name() == "config") {
+				cmd.WarnConfigIssues(config.Issues())
+			}
 		},
 	}
 )
@@ -103,21 +118,24 @@ func init() {
 
 	flags := rootCmd.PersistentFlags()
 	flags.StringVar(&config.ProjectURI, "project", "", "Use a specific project ID - overrides config")
-	flags.StringVarP(&config.AuthToken, "token", "t", "", "Use a specific token to authenticate - overrides login token")
+	flags.StringVarP(&config.AuthToken, "token", "t", "", "Use a specific token to authenticate - overrides project and login tokens")
 	flags.BoolVar(&vars.Debug, "debug", false, "Enable debug mode")
+	flags.StringVar(&config.ContextName, "context", "", "Use a specific context - overrides the default context and UNWEAVE_ENV")
 
 	flags.StringVarP(&config.SSHKeyName, "key", "k", "", "Name of the SSH key to use")
 	flags.StringVar(&config.SSHPublicKeyPath, "pub", "", "Path to the SSH public key to use")
 	flags.BoolVar(&config.OutputJSON, "json", false, "Output JSON instead of human-readable text")
 
-	rootCmd.AddCommand(&cobra.Command{
+	buildCmd := &cobra.Command{
 		Use:     "build [path]",
 		Short:   "Build a project into a container image",
 		GroupID: groupDev,
 		Args:    cobra.RangeArgs(0, 1),
 		RunE:    withValidProjectURI(cmd.Build),
 		Hidden:  true,
-	})
+	}
+	buildCmd.Flags().StringVar(&config.MaxContextSize, "max-context-size", "", "Largest build context to upload, e.g. 500MB, or 0 for no limit (default "+config.DefaultMaxContextSize+")")
+	rootCmd.AddCommand(buildCmd)
 
 	boxCmd := &cobra.Command{
 		Use:     "box [box-name]",
@@ -152,7 +170,16 @@ func init() {
 	codeCmd.Flags().IntVar(&config.HDD, "hdd", 0, "Amount of hard-disk space to allocate in GB")
 	codeCmd.Flags().StringVar(&config.SpecName, "spec", "default", "Spec from config to use")
 	codeCmd.Flags().StringSliceVarP(&config.Volumes, "volume", "v", []string{}, "Mount a volume to the exec. e.g., -v <volume-name>:/data")
+	codeCmd.Flags().StringArrayVar(&config.Labels, "label", []string{}, "Label to attach to a new session as key=value, e.g., --label team=vision")
+	codeCmd.Flags().StringVar(&config.TTL, "ttl", "", "Power the machine of the new session off this long after it's created, e.g., 4h. Overrides the ttl of the spec")
+	codeCmd.Flags().StringVar(&config.IdleTimeout, "idle-timeout", "", "Power the machine of the new session off once it's idle for this long, e.g., 30m. Overrides the idle_timeout of the spec")
+	codeCmd.Flags().StringArrayVar(&config.EnvVars, "env", nil, "Environment variable to set on the session as KEY=VALUE, or KEY to use its local value. Can be repeated")
+	codeCmd.Flags().StringArrayVar(&config.EnvFiles, "env-file", nil, "Read environment variables to set on the session from a .env file. Can be repeated")
+	codeCmd.Flags().BoolVar(&config.WaitForCapacity, "wait-for-capacity", false, "Wait until the provider has capacity instead of failing when it's out of capacity")
+	codeCmd.Flags().DurationVar(&config.MaxWait, "max-wait", 0, "Stop waiting for capacity after this long, e.g., 2h. Waits indefinitely by default")
+	codeCmd.Flags().StringArrayVar(&config.Notify, "notify", nil, "Send a notification once the session is created after waiting for capacity: `desktop` or a webhook URL. Can be repeated")
 	codeCmd.Flags().Int32VarP(&config.InternalPort, "port", "p", 0, "Port on the exec to expose as an https interface e.g. -p 8080")
+	codeCmd.Flags().DurationVar(&config.WaitTimeout, "timeout", 0, "How long to wait for the session to be running, e.g. 10m. Waits indefinitely by default")
 
 	rootCmd.AddCommand(codeCmd)
 
@@ -169,6 +196,8 @@ func init() {
 			"unweave cp sess:<session-name><remote-path> <local-path> \n\n"+
 			"Current directory to remote:\n"+
 			"unweave cp . sess:<session-name><remote-path>\n\n"+
+			"Files are verified with SHA-256 after copying. If a copy is interrupted, run the "+
+			"same command again to resume it.\n\n"+
 			"Example: \n"+
 			"\tunweave cp /home/data sess:session-name/home/ml-data\n"+
 			"\tunweave cp sess:session-name/home/ml-data /home/data\n", ui.MaxOutputLineLength),
@@ -179,14 +208,54 @@ func init() {
 	}
 	rootCmd.AddCommand(cpCmd)
 
-	rootCmd.AddCommand(&cobra.Command{
-		Use:     "config",
-		Short:   "Show the current config",
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the current config: validate | get | set",
+		Long: wordwrap.String("Show the current config.\n\n"+
+			"The get and set commands read and edit the project config.toml, or the user "+
+			"config of the active context with --global. Keys are paths like "+
+			"specs.default.gpu.type, in which specs are selected by name. Comments in the "+
+			"file are kept.\n",
+			ui.MaxOutputLineLength),
 		GroupID: groupDev,
+		Args:    cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(config.Config.String())
+			s, err := config.Config.String()
+			if err != nil {
+				ui.HandleError(err)
+				os.Exit(1)
+			}
+			fmt.Println(s)
 		},
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the project and user config for unknown keys and invalid values",
+		Args:  cobra.NoArgs,
+		RunE:  cmd.ConfigValidate,
 	})
+	configGetCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a value of the config, e.g. specs.default.gpu.type",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmd.ConfigGet,
+	}
+	configGetCmd.Flags().BoolVar(&config.GlobalConfig, "global", false, "Use the user config of the active context instead of the project config")
+	configCmd.AddCommand(configGetCmd)
+	configSetCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value of the config, e.g. specs.default.gpu.type a100",
+		Long: wordwrap.String("Set a value of the config.\n\n"+
+			"Eg. unweave config set specs.default.gpu.type a100\n\n"+
+			"Arrays are set from comma-separated values. Setting a key of a spec that doesn't "+
+			"exist adds the spec. Values that would make the config invalid are refused.\n",
+			ui.MaxOutputLineLength),
+		Args: cobra.ExactArgs(2),
+		RunE: cmd.ConfigSet,
+	}
+	configSetCmd.Flags().BoolVar(&config.GlobalConfig, "global", false, "Use the user config of the active context instead of the project config")
+	configCmd.AddCommand(configSetCmd)
+	rootCmd.AddCommand(configCmd)
 	execCmd := &cobra.Command{
 		GroupID: groupDev,
 		Short:   "Execute a command serverlessly",
@@ -204,13 +273,58 @@ func init() {
 	execCmd.Flags().IntVar(&config.HDD, "hdd", 0, "Amount of hard-disk space to allocate in GB")
 	execCmd.Flags().StringVar(&config.SpecName, "spec", "default", "Spec from config to use")
 	execCmd.Flags().StringSliceVarP(&config.Volumes, "volume", "v", []string{}, "Mount a volume to the exec. e.g., -v <volume-name>:/data")
+	execCmd.Flags().StringArrayVar(&config.Labels, "label", []string{}, "Label to attach to a new session as key=value, e.g., --label team=vision")
+	execCmd.Flags().StringVar(&config.TTL, "ttl", "", "Power the machine of the new session off this long after it's created, e.g., 4h. Overrides the ttl of the spec")
+	execCmd.Flags().StringVar(&config.IdleTimeout, "idle-timeout", "", "Power the machine of the new session off once it's idle for this long, e.g., 30m. Overrides the idle_timeout of the spec")
+	execCmd.Flags().StringArrayVar(&config.EnvVars, "env", nil, "Environment variable to set on the session as KEY=VALUE, or KEY to use its local value. Can be repeated")
+	execCmd.Flags().StringArrayVar(&config.EnvFiles, "env-file", nil, "Read environment variables to set on the session from a .env file. Can be repeated")
+	execCmd.Flags().BoolVar(&config.WaitForCapacity, "wait-for-capacity", false, "Wait until the provider has capacity instead of failing when it's out of capacity")
+	execCmd.Flags().DurationVar(&config.MaxWait, "max-wait", 0, "Stop waiting for capacity after this long, e.g., 2h. Waits indefinitely by default")
+	execCmd.Flags().StringArrayVar(&config.Notify, "notify", nil, "Send a notification once the session is created after waiting for capacity: `desktop` or a webhook URL. Can be repeated")
 	execCmd.Flags().Int32VarP(&config.InternalPort, "port", "p", 0, "Port on the exec to expose as an https interface e.g. -p 8080")
 	execCmd.Flags().BoolVar(&config.ExecAttach, "interactive", false, "Stay attached in an interactive terminal session to the exec after starting the command")
+	execCmd.Flags().BoolVar(&config.ExecWait, "wait", false, "Wait for the command to finish and exit with its exit code")
 	execCmd.Flags().StringSliceVar(&config.SSHConnectionOptions, "connection-option", []string{}, "SSH connection config to include e.g StrictHostKeyChecking=yes")
 	execCmd.Flags().BoolVar(&config.NoCopySource, "no-copy", false, "Do not copy source code to the session")
+	execCmd.Flags().DurationVar(&config.WaitTimeout, "timeout", 0, "How long to wait for the session to be running, e.g. 10m. Waits indefinitely by default")
 
 	rootCmd.AddCommand(execCmd)
 
+	// Job commands
+	jobsCmd := &cobra.Command{
+		Use:     "jobs",
+		Aliases: []string{"job"},
+		Short:   "Manage commands started with exec: ls | status | wait | kill",
+		GroupID: groupDev,
+		Args:    cobra.NoArgs,
+	}
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List the jobs of the project",
+		Args:  cobra.NoArgs,
+		RunE:  withValidProjectURI(cmd.JobsList),
+	})
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "status <job-id>",
+		Short: "Show the status and exit code of a job",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withValidProjectURI(cmd.JobStatus),
+	})
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "wait <job-id>",
+		Short: "Wait for a job to finish and exit with its exit code",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withValidProjectURI(cmd.JobWait),
+	})
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "kill <job-id>",
+		Short: "Stop a running job",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withValidProjectURI(cmd.JobKill),
+	})
+	jobsCmd.PersistentFlags().StringVar(&config.SSHPrivateKeyPath, "prv", "", "Absolute Path to the private key to use")
+	rootCmd.AddCommand(jobsCmd)
+
 	logsCmd := &cobra.Command{
 		GroupID: groupDev,
 		Short:   "Print logs from an exec",
@@ -235,13 +349,43 @@ func init() {
 
 	// Auth
 	loginCmd := &cobra.Command{
-		Use:     "login",
-		Short:   "Login to Unweave",
+		Use:   "login",
+		Short: "Login to Unweave",
+		Long: wordwrap.String("Login to Unweave.\n\n"+
+			"Opens the browser to confirm a pairing code. On remote machines use --no-browser "+
+			"to print the URL, and --qr to also show it as a QR code you can scan with your "+
+			"phone. In scripts, pipe a token in with --with-token instead.\n\n"+
+			"Eg. echo $UNWEAVE_TOKEN | unweave login --with-token\n",
+			ui.MaxOutputLineLength),
 		GroupID: groupManagement,
+		Args:    cobra.NoArgs,
 		RunE:    cmd.Login,
 	}
+	loginCmd.Flags().BoolVar(&config.LoginNoBrowser, "no-browser", false, "Print the login URL instead of opening a browser")
+	loginCmd.Flags().BoolVar(&config.LoginQR, "qr", false, "Also print the login URL as a QR code")
+	loginCmd.Flags().BoolVar(&config.LoginWithToken, "with-token", false, "Read a token from stdin instead of logging in with the browser")
 	rootCmd.AddCommand(loginCmd)
 
+	authCmd := &cobra.Command{
+		Use:     "auth",
+		Short:   "Inspect how the CLI authenticates: whoami",
+		GroupID: groupManagement,
+		Args:    cobra.NoArgs,
+	}
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "whoami",
+		Short: "Show the identity and scope of the active token",
+		Long: wordwrap.String("Show the identity and scope of the active token.\n\n"+
+			"The token passed with --token is used first, then a project token from "+
+			"UNWEAVE_PROJECT_TOKEN or the project's .unweave/.env file, and then the token "+
+			"of the user logged in with `unweave login`. Project tokens let CI pipelines run "+
+			"exec, build and deploy without logging in.\n",
+			ui.MaxOutputLineLength),
+		Args: cobra.NoArgs,
+		RunE: cmd.Whoami,
+	})
+	rootCmd.AddCommand(authCmd)
+
 	rootCmd.AddCommand(&cobra.Command{
 		Use:    "logout",
 		Short:  "Logout of Unweave",
@@ -249,6 +393,23 @@ func init() {
 		Hidden: true,
 	})
 
+	devAPICmd := &cobra.Command{
+		Use:   "dev-api",
+		Short: "Run a fake Unweave API server with in-memory state",
+		Long: wordwrap.String("Run a fake Unweave API server with in-memory state for offline development "+
+			"and testing. Run the CLI with UNWEAVE_ENV=dev to point it at the server.\n\n"+
+			"Sessions move from pending to initializing to running over a few seconds. Their "+
+			"status can be scripted with PUT /_dev/sessions/<id>/status {\"status\": \"terminated\"} "+
+			"and a GPU type taken out of capacity with PUT /_dev/capacity/<gpu-type> {\"available\": false}.",
+			ui.MaxOutputLineLength),
+		Args:   cobra.NoArgs,
+		Hidden: true,
+		RunE:   cmd.DevAPI,
+	}
+	devAPICmd.Flags().StringVar(&config.DevAPIAddr, "addr", devapi.DefaultAddr, "Address to listen on")
+	devAPICmd.Flags().StringVar(&config.DevAPISSHAddr, "ssh-addr", "", "host:port of an SSH server for sessions to connect to, e.g. localhost:2222")
+	rootCmd.AddCommand(devAPICmd)
+
 	// Provider commands
 	lsNodeType := &cobra.Command{
 		Use:   "ls-gpu-types <provider>",
@@ -293,38 +454,97 @@ func init() {
 	newCmd.Flags().IntVar(&config.HDD, "hdd", 0, "Amount of hard-disk space to allocate in GB")
 	newCmd.Flags().StringVar(&config.SpecName, "spec", "default", "Spec from config to use")
 	newCmd.Flags().StringSliceVarP(&config.Volumes, "volume", "v", []string{}, "Mount a volume to the exec. e.g., -v <volume-name>:/data")
+	newCmd.Flags().StringArrayVar(&config.Labels, "label", []string{}, "Label to attach to a new session as key=value, e.g., --label team=vision")
+	newCmd.Flags().StringVar(&config.TTL, "ttl", "", "Power the machine of the new session off this long after it's created, e.g., 4h. Overrides the ttl of the spec")
+	newCmd.Flags().StringVar(&config.IdleTimeout, "idle-timeout", "", "Power the machine of the new session off once it's idle for this long, e.g., 30m. Overrides the idle_timeout of the spec")
+	newCmd.Flags().StringArrayVar(&config.EnvVars, "env", nil, "Environment variable to set on the session as KEY=VALUE, or KEY to use its local value. Can be repeated")
+	newCmd.Flags().StringArrayVar(&config.EnvFiles, "env-file", nil, "Read environment variables to set on the session from a .env file. Can be repeated")
+	newCmd.Flags().BoolVar(&config.WaitForCapacity, "wait-for-capacity", false, "Wait until the provider has capacity instead of failing when it's out of capacity")
+	newCmd.Flags().DurationVar(&config.MaxWait, "max-wait", 0, "Stop waiting for capacity after this long, e.g., 2h. Waits indefinitely by default")
+	newCmd.Flags().StringArrayVar(&config.Notify, "notify", nil, "Send a notification once the session is created after waiting for capacity: `desktop` or a webhook URL. Can be repeated")
 	newCmd.Flags().Int32VarP(&config.InternalPort, "port", "p", 0, "Port on the exec to expose as an https interface e.g. -p 8080")
 
 	rootCmd.AddCommand(newCmd)
 
+	sessionCmd := &cobra.Command{
+		Use:     "session",
+		Short:   "Inspect Unweave sessions: get",
+		Aliases: []string{"sess"},
+		GroupID: groupDev,
+		Args:    cobra.NoArgs,
+	}
+	sessionCmd.AddCommand(&cobra.Command{
+		Use:     "get <session-name|id>",
+		Short:   "Show the details of a session",
+		Long:    "Show the details of a session, including how to connect to it, its hardware, volumes and SSH keys.",
+		Aliases: []string{"describe"},
+		Args:    cobra.ExactArgs(1),
+		RunE:    withValidProjectURI(cmd.SessionGet),
+	})
+	rootCmd.AddCommand(sessionCmd)
+
 	lsCmd := &cobra.Command{
-		Use:     "ls",
-		Short:   "List active Unweave sessions",
-		Long:    "List active Unweave sessions. To list all sessions, use the --all flag.",
+		Use:   "ls",
+		Short: "List active Unweave sessions",
+		Long: wordwrap.String("List active Unweave sessions. To list all sessions, use the --all flag.\n\n"+
+			"Sessions can be filtered by label, status, provider, GPU type and age, eg. "+
+			"unweave ls -l team=vision --status running --older-than 1d -c name,age,label:team --sort -age", ui.MaxOutputLineLength),
 		Args:    cobra.NoArgs,
 		Aliases: []string{"list"},
 		GroupID: groupDev,
 		RunE:    withValidProjectURI(cmd.SessionList),
 	}
 	lsCmd.Flags().BoolVarP(&config.All, "all", "a", false, "List all sessions")
+	lsCmd.Flags().StringArrayVarP(&config.ListLabelSelectors, "label", "l", []string{}, "Only list sessions with a label, as key=value, key!=value or key")
+	lsCmd.Flags().StringSliceVar(&config.ListStatuses, "status", []string{}, "Only list sessions with a status, e.g., running,pending")
+	lsCmd.Flags().StringSliceVar(&config.ListProviders, "provider", []string{}, "Only list sessions on a provider")
+	lsCmd.Flags().StringSliceVar(&config.ListGPUTypes, "gpu-type", []string{}, "Only list sessions with a GPU type, e.g., rtx_5000")
+	lsCmd.Flags().StringVar(&config.ListNewerThan, "newer-than", "", "Only list sessions created less than this long ago, e.g., 2h or 3d")
+	lsCmd.Flags().StringVar(&config.ListOlderThan, "older-than", "", "Only list sessions created more than this long ago, e.g., 2h or 3d")
+	lsCmd.Flags().StringSliceVarP(&config.ListColumns, "columns", "c", []string{}, "Columns to show: name, id, status, provider, region, instance-type, gpu-type, created, age, remaining, labels or label:<key>")
+	lsCmd.Flags().StringVar(&config.ListSort, "sort", "name", "Column to sort by, prefix with - to sort in descending order, e.g., -created")
+	lsCmd.Flags().BoolVarP(&config.Watch, "watch", "w", false, "Re-render the list as sessions change until interrupted")
+	lsCmd.Flags().DurationVar(&config.WatchInterval, "interval", 2*time.Second, "How often to refresh the list with --watch")
 	rootCmd.AddCommand(lsCmd)
 
 	rootCmd.AddCommand(&cobra.Command{
-		Use:     "terminate [session-id]",
-		Short:   "Terminate an Unweave session",
-		Args:    cobra.RangeArgs(0, 1),
+		Use:   "cost",
+		Short: "Show what the active sessions cost",
+		Long: wordwrap.String("Show what the active sessions of the project cost per hour, what they have "+
+			"cost so far and what they will cost by the end of the month, estimated from the prices of "+
+			"their providers. Sessions that ended this month count for as long as this machine saw them "+
+			"running. Set a [budget] in .unweave/config.toml to check new sessions against it.", ui.MaxOutputLineLength),
+		Args:    cobra.NoArgs,
+		GroupID: groupDev,
+		RunE:    withValidProjectURI(cmd.Cost),
+	})
+
+	terminateCmd := &cobra.Command{
+		Use:   "terminate [session-name|id]...",
+		Short: "Terminate Unweave sessions",
+		Long: wordwrap.String("Terminate Unweave sessions. Without arguments, pick the session to terminate. "+
+			"To terminate many sessions at once, name them or select them with --selector, --all-idle and "+
+			"--older-than. The matching sessions are shown and terminated after one confirmation.\n\n"+
+			"Eg. unweave terminate --selector sweep=12 --older-than 6h --yes", ui.MaxOutputLineLength),
+		Args:    cobra.ArbitraryArgs,
 		Aliases: []string{"delete", "del"},
 		GroupID: groupDev,
 		RunE:    withValidProjectURI(cmd.SessionTerminate),
-	})
+	}
+	terminateCmd.Flags().StringArrayVarP(&config.TerminateSelectors, "selector", "l", []string{}, "Terminate sessions with a label, as key=value, key!=value or key")
+	terminateCmd.Flags().BoolVar(&config.TerminateAllIdle, "all-idle", false, "Terminate running sessions without SSH connections, running jobs or GPU load")
+	terminateCmd.Flags().StringVar(&config.TerminateOlderThan, "older-than", "", "Terminate sessions created more than this long ago, e.g., 6h or 2d")
+	terminateCmd.Flags().IntVar(&config.Parallelism, "parallel", session.DefaultParallelism, "Number of sessions to terminate at once")
+	terminateCmd.Flags().BoolVarP(&config.Yes, "yes", "y", false, "Terminate without asking for confirmation")
+	rootCmd.AddCommand(terminateCmd)
 
 	sshCmd := &cobra.Command{
 		Use:   "ssh [session-name|id]",
 		Short: "SSH into existing session or create a new one",
-		//Long: "You can specify arguments for the ssh command after a double dash (--). \n" +
-		//	"For example: \n" +
-		//	"	`unweave ssh -- -L 8080:localhost:8080`\n" +
-		//	"   `unweave ssh <session-name|id> -- -L 8080:localhost:8080`\n",
+		Long: "SSH into existing session or create a new one.\n\n" +
+			"Arguments after a double dash (--) are passed to the ssh command. For example:\n" +
+			"  unweave ssh -- -L 8080:localhost:8080\n" +
+			"  unweave ssh <session-name|id> -- -L 8080:localhost:8080\n",
 		GroupID: groupDev,
 		RunE:    withValidProjectURI(cmd.SSH),
 	}
@@ -343,10 +563,83 @@ func init() {
 	sshCmd.Flags().IntVar(&config.HDD, "hdd", 0, "Amount of hard-disk space to allocate in GB")
 	sshCmd.Flags().StringVar(&config.SpecName, "spec", "default", "Spec from config to use")
 	sshCmd.Flags().StringSliceVarP(&config.Volumes, "volume", "v", []string{}, "Mount a volume to newly created execs. e.g., -v <volume-name>:/data")
+	sshCmd.Flags().StringArrayVar(&config.Labels, "label", []string{}, "Label to attach to a new session as key=value, e.g., --label team=vision")
+	sshCmd.Flags().StringVar(&config.TTL, "ttl", "", "Power the machine of the new session off this long after it's created, e.g., 4h. Overrides the ttl of the spec")
+	sshCmd.Flags().StringVar(&config.IdleTimeout, "idle-timeout", "", "Power the machine of the new session off once it's idle for this long, e.g., 30m. Overrides the idle_timeout of the spec")
+	sshCmd.Flags().StringArrayVar(&config.EnvVars, "env", nil, "Environment variable to set on the session as KEY=VALUE, or KEY to use its local value. Can be repeated")
+	sshCmd.Flags().StringArrayVar(&config.EnvFiles, "env-file", nil, "Read environment variables to set on the session from a .env file. Can be repeated")
+	sshCmd.Flags().BoolVar(&config.WaitForCapacity, "wait-for-capacity", false, "Wait until the provider has capacity instead of failing when it's out of capacity")
+	sshCmd.Flags().DurationVar(&config.MaxWait, "max-wait", 0, "Stop waiting for capacity after this long, e.g., 2h. Waits indefinitely by default")
+	sshCmd.Flags().StringArrayVar(&config.Notify, "notify", nil, "Send a notification once the session is created after waiting for capacity: `desktop` or a webhook URL. Can be repeated")
 	sshCmd.Flags().Int32VarP(&config.InternalPort, "port", "p", 0, "Port on the exec to expose as an https interface e.g. -p 8080")
+	sshCmd.Flags().DurationVar(&config.WaitTimeout, "timeout", 0, "How long to wait for the session to be running, e.g. 10m. Waits indefinitely by default")
 
 	rootCmd.AddCommand(sshCmd)
 
+	syncCmd := &cobra.Command{
+		Use:   "sync [session-name|id]",
+		Short: "Continuously sync the project directory to a running session",
+		Long: "Watches the project directory and copies changed files to the session until interrupted.\n" +
+			"Files ignored by the project's .gitignore aren't synced. Set `sync = true` in the\n" +
+			"[sessions] section of the project config to sync automatically with `unweave ssh` and `unweave code`.",
+		Args:    cobra.RangeArgs(0, 1),
+		GroupID: groupDev,
+		RunE:    withValidProjectURI(cmd.Sync),
+	}
+	syncCmd.Flags().BoolVar(&config.SyncPull, "pull", false, "Also copy changes made on the session back to the project directory")
+	syncCmd.Flags().StringVar(&config.SSHPrivateKeyPath, "prv", "", "Absolute Path to the private key to use")
+	syncCmd.Flags().DurationVar(&config.WaitTimeout, "timeout", 0, "How long to wait for the session to be running, e.g. 10m. Waits indefinitely by default")
+	rootCmd.AddCommand(syncCmd)
+
+	// Context commands
+	contextCmd := &cobra.Command{
+		Use:     "context",
+		Aliases: []string{"ctx"},
+		Short:   "Manage API environments and accounts: ls | use | add | rm",
+		Long: wordwrap.String("Manage API environments and accounts.\n\n"+
+			"A context is an Unweave API with its own login, token and default project. "+
+			"The production, staging and development contexts are built in and can also be "+
+			"selected with UNWEAVE_ENV.\n",
+			ui.MaxOutputLineLength),
+		GroupID: groupManagement,
+		Args:    cobra.NoArgs,
+	}
+	contextCmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List contexts",
+		Args:  cobra.NoArgs,
+		RunE:  cmd.ContextList,
+	})
+	contextCmd.AddCommand(&cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the default context",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmd.ContextUse,
+	})
+	contextAddCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a context",
+		Long: wordwrap.String("Add a context.\n\n"+
+			"Eg. unweave context add team --api-url https://api.example.com --project team/models\n\n"+
+			"Use --token to authenticate with a token instead of logging in, and --project to "+
+			"set the project used when no project is linked.\n",
+			ui.MaxOutputLineLength),
+		Args: cobra.ExactArgs(1),
+		RunE: cmd.ContextAdd,
+	}
+	contextAddCmd.Flags().String("api-url", "", "URL of the Unweave API")
+	contextAddCmd.Flags().String("app-url", "", "URL of the Unweave app, defaults to the API URL")
+	contextAddCmd.MarkFlagRequired("api-url")
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(&cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a context and its login",
+		Args:    cobra.ExactArgs(1),
+		RunE:    cmd.ContextRemove,
+	})
+	rootCmd.AddCommand(contextCmd)
+
 	// SSH Key commands
 	sshKeyCmd := &cobra.Command{
 		Use:     "ssh-keys",
@@ -533,9 +826,56 @@ func init() {
 	deployCmd.Flags().StringSliceVarP(&config.Volumes, "volume", "v", []string{}, "Mount a volume to the exec. e.g., -v <volume-name>:/data")
 	deployCmd.Flags().Int32VarP(&config.InternalPort, "port", "p", 8080, "Port on the exec to expose as an https interface e.g. -p 8080")
 	deployCmd.Flags().StringVar(&config.EndpointName, "endpoint", "", "name of the endpoint to deploy")
+	deployCmd.Flags().StringArrayVar(&config.EnvVars, "env", nil, "Environment variable to set on the session as KEY=VALUE, or KEY to use its local value. Can be repeated")
+	deployCmd.Flags().StringArrayVar(&config.EnvFiles, "env-file", nil, "Read environment variables to set on the session from a .env file. Can be repeated")
 	deployCmd.Flags().StringSliceVar(&config.SSHConnectionOptions, "connection-option", []string{}, "SSH connection config to include e.g StrictHostKeyChecking=yes")
 
 	rootCmd.AddCommand(deployCmd)
+
+	resourcesLong := "The resources manifest is .unweave/resources.toml or unweave.toml in the " +
+		"project directory. It declares volumes and endpoints:\n\n" +
+		"  [[volumes]]\n" +
+		"  name = \"data\"\n" +
+		"  size = 100\n\n" +
+		"  [[endpoints]]\n" +
+		"  name = \"api\"\n" +
+		"  command = \"python serve.py\"\n" +
+		"  spec = \"default\"\n" +
+		"  port = 8080\n" +
+		"  evals = [\"<eval-id>\"]\n\n" +
+		"Volumes default to 4 GB on the default provider of the project. Endpoints are " +
+		"deployed like unweave deploy does. Existing endpoints are left as they are, since " +
+		"their command, spec and port can't be read back.\n"
+
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show how apply would change the resources of the project",
+		Long: wordwrap.String("Show how apply would change the volumes, endpoints and eval "+
+			"attachments of the project to match the resources manifest.\n\n"+resourcesLong,
+			ui.MaxOutputLineLength),
+		GroupID: groupDev,
+		Args:    cobra.NoArgs,
+		RunE:    cmd.Plan,
+	}
+	planCmd.Flags().StringVarP(&config.ResourcesFile, "file", "f", "", "Path of the resources manifest")
+	planCmd.Flags().BoolVar(&config.Prune, "prune", false, "Delete the volumes that aren't in the manifest")
+	rootCmd.AddCommand(planCmd)
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Change the resources of the project to match the manifest",
+		Long: wordwrap.String("Create, resize and replace volumes, deploy endpoints and attach "+
+			"evals to match the resources manifest. The changes are shown before they're "+
+			"made, like unweave plan does.\n\n"+resourcesLong,
+			ui.MaxOutputLineLength),
+		GroupID: groupDev,
+		Args:    cobra.NoArgs,
+		RunE:    cmd.Apply,
+	}
+	applyCmd.Flags().StringVarP(&config.ResourcesFile, "file", "f", "", "Path of the resources manifest")
+	applyCmd.Flags().BoolVar(&config.Prune, "prune", false, "Delete the volumes that aren't in the manifest")
+	applyCmd.Flags().BoolVarP(&config.Yes, "yes", "y", false, "Apply without asking for confirmation")
+	rootCmd.AddCommand(applyCmd)
 }
 
 func main() {
@@ -548,8 +888,6 @@ func main() {
 		}
 	}()
 
-	config.Init()
-
 	currentVersion := config.Version
 	latestVersion, err := getLatestReleaseVersion(repoOwner, repoName)
 	if err != nil {
@@ -558,7 +896,10 @@ func main() {
 		verifyCLIVersion(currentVersion, latestVersion)
 	}
 
+	// Errors are printed here rather than by Cobra, so that the ones commands return get
+	// the same formatting as the ones they handle themselves.
 	if err := rootCmd.Execute(); err != nil {
+		ui.HandleError(err)
 		os.Exit(1)
 	}
 }
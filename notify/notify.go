@@ -0,0 +1,104 @@
+// Package notify tells users that something they were waiting for happened, with a
+// desktop notification or by calling a webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Desktop is the target for desktop notifications. Any other target is a webhook URL.
+const Desktop = "desktop"
+
+// ErrUnsupported is returned if desktop notifications aren't supported on the platform.
+var ErrUnsupported = errors.New("desktop notifications aren't supported on " + runtime.GOOS)
+
+// webhookTimeout bounds how long a webhook may take to respond.
+const webhookTimeout = 10 * time.Second
+
+// Notification is sent to desktops as a title and a message, and to webhooks as JSON.
+// Text is also the field Slack and Discord compatible webhooks display.
+type Notification struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	// Data is passed to webhooks as is.
+	Data any `json:"data,omitempty"`
+}
+
+// Validate returns an error if target is neither Desktop nor an http or https URL.
+func Validate(target string) error {
+	if target == Desktop {
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid notification target %q: use %q or a webhook URL", target, Desktop)
+	}
+	return nil
+}
+
+// Send sends n to target, which is Desktop or a webhook URL.
+func Send(ctx context.Context, target string, n Notification) error {
+	if err := Validate(target); err != nil {
+		return err
+	}
+	if target == Desktop {
+		return desktop(ctx, n)
+	}
+	return webhook(ctx, target, n)
+}
+
+func desktop(ctx context.Context, n Notification) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(n.Text), appleScriptQuote(n.Title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", n.Title, n.Text)
+	default:
+		return ErrUnsupported
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func webhook(ctx context.Context, target string, n Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+	return nil
+}
+
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("desktop"))
+	assert.NoError(t, Validate("https://hooks.example.com/T000/B000"))
+	assert.NoError(t, Validate("http://localhost:8080/notify"))
+	assert.Error(t, Validate("email"))
+	assert.Error(t, Validate("ftp://example.com"))
+	assert.Error(t, Validate("https://"))
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if r.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	n := Notification{Title: "Session created", Text: "h100 is ready", Data: map[string]string{"id": "sess-1"}}
+	require.NoError(t, Send(context.Background(), srv.URL+"/ok", n))
+	assert.Equal(t, map[string]any{
+		"title": "Session created",
+		"text":  "h100 is ready",
+		"data":  map[string]any{"id": "sess-1"},
+	}, got)
+
+	assert.EqualError(t, Send(context.Background(), srv.URL+"/fail", n), "webhook responded with 400 Bad Request")
+}
+
+func TestAppleScriptQuote(t *testing.T) {
+	assert.Equal(t, `"say \"hi\" \\ <3"`, appleScriptQuote(`say "hi" \ <3`))
+}
@@ -0,0 +1,216 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/unweave/api/types"
+)
+
+// Kind is the kind of a resource.
+type Kind string
+
+const (
+	KindVolume   Kind = "volume"
+	KindEndpoint Kind = "endpoint"
+)
+
+// Action is what a change does to a resource.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionResize Action = "resize"
+	// ActionReplace deletes a volume and creates it again, e.g. on another provider.
+	ActionReplace Action = "replace"
+	ActionDelete  Action = "delete"
+	// ActionAttachEval attaches an eval to an endpoint.
+	ActionAttachEval Action = "attach-eval"
+)
+
+// Change is a step that brings the resources of a project in line with the manifest.
+type Change struct {
+	Kind   Kind   `json:"kind"`
+	Action Action `json:"action"`
+	Name   string `json:"name"`
+	// ID is the ID of the existing resource, if there is one.
+	ID string `json:"id,omitempty"`
+	// From is the existing volume for resize, replace and delete.
+	From *types.Volume `json:"from,omitempty"`
+	// Volume is the volume to create or resize to.
+	Volume *config.VolumeResource `json:"volume,omitempty"`
+	// Endpoint is the endpoint to create, or the one to attach EvalID to.
+	Endpoint *config.EndpointResource `json:"endpoint,omitempty"`
+	EvalID   string                   `json:"evalId,omitempty"`
+}
+
+// Destructive returns whether the change deletes data.
+func (c Change) Destructive() bool {
+	return c.Action == ActionReplace || c.Action == ActionDelete
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		if c.Kind == KindVolume {
+			return fmt.Sprintf("+ volume %s: %d GB on %s", c.Name, c.Volume.Size, c.Volume.Provider)
+		}
+		return fmt.Sprintf("+ endpoint %s: `%s` on spec %s, port %d", c.Name, c.Endpoint.Command, c.Endpoint.Spec, c.Endpoint.Port)
+	case ActionResize:
+		return fmt.Sprintf("~ volume %s: %d GB -> %d GB", c.Name, c.From.Size, c.Volume.Size)
+	case ActionReplace:
+		return fmt.Sprintf("-/+ volume %s: %d GB on %s -> %d GB on %s, deletes its data",
+			c.Name, c.From.Size, c.From.Provider, c.Volume.Size, c.Volume.Provider)
+	case ActionDelete:
+		return fmt.Sprintf("- volume %s: not in the manifest, deletes its data", c.Name)
+	case ActionAttachEval:
+		return fmt.Sprintf("+ eval %s -> endpoint %s", c.EvalID, c.Name)
+	}
+	return fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name)
+}
+
+// State is the resources a project has, as listed by the API.
+type State struct {
+	Volumes   []types.Volume
+	Endpoints []types.EndpointListItem
+	Evals     []types.Eval
+}
+
+// Resource is an existing resource of a project.
+type Resource struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Plan is the changes that bring the resources of a project in line with the manifest.
+type Plan struct {
+	Changes []Change `json:"changes"`
+	// Unchecked are the endpoints of the manifest that exist. The API doesn't return
+	// the command, spec and port of endpoints, so they're left as they are.
+	Unchecked []Resource `json:"unchecked"`
+	// Reattach are the evals of the manifest on endpoints that exist. The API has no way
+	// to list the evals attached to an endpoint, so they're attached again, which
+	// changes nothing if they already are. They aren't changes.
+	Reattach []Change `json:"reattach"`
+	// Unmanaged are the resources that exist but aren't in the manifest. Volumes are
+	// deleted instead if the plan prunes.
+	Unmanaged []Resource `json:"unmanaged"`
+}
+
+// Destructive returns whether any change deletes data.
+func (p *Plan) Destructive() bool {
+	for _, c := range p.Changes {
+		if c.Destructive() {
+			return true
+		}
+	}
+	return false
+}
+
+// Options are the options of NewPlan.
+type Options struct {
+	// Prune deletes the volumes that aren't in the manifest.
+	Prune bool
+}
+
+// NewPlan diffs the manifest against the state of the project. Volumes are created,
+// grown, or replaced if their provider changed; they can't be shrunk. Endpoints are
+// created along with their evals, and the evals of existing endpoints are reattached.
+func NewPlan(r *config.Resources, s State, opts Options) (*Plan, error) {
+	p := &Plan{Changes: []Change{}, Unchecked: []Resource{}, Reattach: []Change{}, Unmanaged: []Resource{}}
+
+	declared := map[string]bool{}
+	for i := range r.Volumes {
+		want := &r.Volumes[i]
+		declared[want.Name] = true
+
+		have, ok := findVolume(want.Name, s.Volumes)
+		switch {
+		case !ok:
+			p.Changes = append(p.Changes, Change{Kind: KindVolume, Action: ActionCreate, Name: want.Name, Volume: want})
+		case want.Provider != "" && !strings.EqualFold(want.Provider, string(have.Provider)):
+			p.Changes = append(p.Changes, Change{Kind: KindVolume, Action: ActionReplace, Name: want.Name, ID: have.ID, From: &have, Volume: want})
+		case want.Size < have.Size:
+			return nil, fmt.Errorf("volume %q is %d GB and volumes can't be shrunk to %d GB", want.Name, have.Size, want.Size)
+		case want.Size > have.Size:
+			p.Changes = append(p.Changes, Change{Kind: KindVolume, Action: ActionResize, Name: want.Name, ID: have.ID, From: &have, Volume: want})
+		}
+	}
+	for i := range s.Volumes {
+		have := s.Volumes[i]
+		if declared[have.Name] {
+			continue
+		}
+		if opts.Prune {
+			p.Changes = append(p.Changes, Change{Kind: KindVolume, Action: ActionDelete, Name: have.Name, ID: have.ID, From: &have})
+		} else {
+			p.Unmanaged = append(p.Unmanaged, Resource{Kind: KindVolume, Name: have.Name, ID: have.ID})
+		}
+	}
+
+	evals := map[string]bool{}
+	for _, e := range s.Evals {
+		evals[e.ID] = true
+	}
+	for i := range r.Endpoints {
+		want := &r.Endpoints[i]
+		for _, id := range want.Evals {
+			if !evals[id] {
+				return nil, fmt.Errorf("endpoint %q: eval %q not found", want.Name, id)
+			}
+		}
+
+		have, exists := findEndpoint(want.Name, s.Endpoints)
+		if exists {
+			p.Unchecked = append(p.Unchecked, Resource{Kind: KindEndpoint, Name: have.Name, ID: have.ID})
+		} else {
+			p.Changes = append(p.Changes, Change{Kind: KindEndpoint, Action: ActionCreate, Name: want.Name, Endpoint: want})
+		}
+		for _, evalID := range want.Evals {
+			attach := Change{Kind: KindEndpoint, Action: ActionAttachEval, Name: want.Name, ID: have.ID, Endpoint: want, EvalID: evalID}
+			if exists {
+				p.Reattach = append(p.Reattach, attach)
+			} else {
+				p.Changes = append(p.Changes, attach)
+			}
+		}
+	}
+	for _, have := range s.Endpoints {
+		if !declaresEndpoint(r, have) {
+			p.Unmanaged = append(p.Unmanaged, Resource{Kind: KindEndpoint, Name: have.Name, ID: have.ID})
+		}
+	}
+
+	return p, nil
+}
+
+func findVolume(name string, volumes []types.Volume) (types.Volume, bool) {
+	for _, v := range volumes {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return types.Volume{}, false
+}
+
+// findEndpoint matches endpoints like unweave deploy does, by name or ID regardless of
+// case.
+func findEndpoint(name string, endpoints []types.EndpointListItem) (types.EndpointListItem, bool) {
+	for _, e := range endpoints {
+		if strings.EqualFold(name, e.Name) || strings.EqualFold(name, e.ID) {
+			return e, true
+		}
+	}
+	return types.EndpointListItem{}, false
+}
+
+func declaresEndpoint(r *config.Resources, e types.EndpointListItem) bool {
+	for _, want := range r.Endpoints {
+		if strings.EqualFold(want.Name, e.Name) || strings.EqualFold(want.Name, e.ID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/config"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestNewPlan(t *testing.T) {
+	manifest := &config.Resources{
+		Volumes: []config.VolumeResource{
+			{Name: "data", Size: 100, Provider: "unweave"},
+			{Name: "cache", Size: 20, Provider: "unweave"},
+			{Name: "weights", Size: 50, Provider: "aws"},
+			{Name: "same", Size: 10, Provider: "unweave"},
+		},
+		Endpoints: []config.EndpointResource{
+			{Name: "api", Command: "python serve.py", Spec: "default", Port: 8080, Evals: []string{"evl_1"}},
+			{Name: "old", Command: "python old.py", Spec: "default", Port: 8080, Evals: []string{"evl_2"}},
+		},
+	}
+	state := State{
+		Volumes: []types.Volume{
+			{ID: "vol_cache", Name: "cache", Size: 10, Provider: "unweave"},
+			{ID: "vol_weights", Name: "weights", Size: 50, Provider: "unweave"},
+			{ID: "vol_same", Name: "same", Size: 10, Provider: "unweave"},
+			{ID: "vol_stale", Name: "stale", Size: 4, Provider: "unweave"},
+		},
+		Endpoints: []types.EndpointListItem{
+			{ID: "end_old", Name: "OLD"},
+			{ID: "end_other", Name: "other"},
+		},
+		Evals: []types.Eval{{ID: "evl_1"}, {ID: "evl_2"}},
+	}
+
+	t.Run("should diff the manifest against the state", func(t *testing.T) {
+		plan, err := NewPlan(manifest, state, Options{})
+		require.NoError(t, err)
+
+		lines := make([]string, len(plan.Changes))
+		for i, c := range plan.Changes {
+			lines[i] = c.String()
+		}
+		assert.Equal(t, []string{
+			"+ volume data: 100 GB on unweave",
+			"~ volume cache: 10 GB -> 20 GB",
+			"-/+ volume weights: 50 GB on unweave -> 50 GB on aws, deletes its data",
+			"+ endpoint api: `python serve.py` on spec default, port 8080",
+			"+ eval evl_1 -> endpoint api",
+		}, lines)
+
+		assert.Equal(t, "vol_cache", plan.Changes[1].ID)
+		assert.Equal(t, "", plan.Changes[4].ID, "the endpoint is created by apply")
+		assert.True(t, plan.Destructive())
+
+		assert.Equal(t, []Resource{{Kind: KindEndpoint, Name: "OLD", ID: "end_old"}}, plan.Unchecked)
+		require.Len(t, plan.Reattach, 1)
+		assert.Equal(t, "+ eval evl_2 -> endpoint old", plan.Reattach[0].String())
+		assert.Equal(t, "end_old", plan.Reattach[0].ID)
+		assert.Equal(t, []Resource{
+			{Kind: KindVolume, Name: "stale", ID: "vol_stale"},
+			{Kind: KindEndpoint, Name: "other", ID: "end_other"},
+		}, plan.Unmanaged)
+	})
+
+	t.Run("should delete volumes not in the manifest when pruning", func(t *testing.T) {
+		plan, err := NewPlan(manifest, state, Options{Prune: true})
+		require.NoError(t, err)
+
+		var deleted []string
+		for _, c := range plan.Changes {
+			if c.Action == ActionDelete {
+				deleted = append(deleted, c.ID)
+			}
+		}
+		assert.Equal(t, []string{"vol_stale"}, deleted)
+		assert.Equal(t, []Resource{{Kind: KindEndpoint, Name: "other", ID: "end_other"}}, plan.Unmanaged)
+	})
+
+	t.Run("should have no changes when the state matches", func(t *testing.T) {
+		plan, err := NewPlan(&config.Resources{Volumes: manifest.Volumes[3:]}, State{Volumes: state.Volumes[2:3]}, Options{})
+		require.NoError(t, err)
+		assert.Empty(t, plan.Changes)
+		assert.False(t, plan.Destructive())
+	})
+
+	t.Run("should have no changes when only evals of existing endpoints are declared", func(t *testing.T) {
+		plan, err := NewPlan(&config.Resources{Endpoints: manifest.Endpoints[1:]}, state, Options{})
+		require.NoError(t, err)
+		assert.Empty(t, plan.Changes)
+		assert.Len(t, plan.Reattach, 1)
+	})
+
+	t.Run("should fail to shrink a volume", func(t *testing.T) {
+		shrink := &config.Resources{Volumes: []config.VolumeResource{{Name: "cache", Size: 5, Provider: "unweave"}}}
+		_, err := NewPlan(shrink, state, Options{})
+		assert.EqualError(t, err, `volume "cache" is 10 GB and volumes can't be shrunk to 5 GB`)
+	})
+
+	t.Run("should fail for unknown evals", func(t *testing.T) {
+		unknown := &config.Resources{Endpoints: []config.EndpointResource{{Name: "api", Command: "x", Evals: []string{"evl_missing"}}}}
+		_, err := NewPlan(unknown, state, Options{})
+		assert.EqualError(t, err, `endpoint "api": eval "evl_missing" not found`)
+	})
+}
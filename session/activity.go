@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unweave/cli/jobs"
+	"github.com/unweave/cli/ssh"
+)
+
+// IdleGPUUtilization is the GPU utilization in percent below which a session counts as
+// idle.
+const IdleGPUUtilization = 10
+
+// ActivityScript prints the activity of the session it runs on as
+// "ssh=<connections> jobs=<running jobs> gpu=<utilization>". The GPU utilization is the
+// highest of all GPUs, or -1 if the session has none.
+var ActivityScript = `ssh=0
+for f in /proc/[0-9]*/cmdline; do
+  tr '\0' ' ' 2>/dev/null < "$f" | grep -Eq '^sshd(-session)?: [^ ]+@' && ssh=$((ssh+1))
+done
+jobs=0
+for d in ` + jobs.RemoteDir + `/*/; do
+  [ -f "$d/exit_code" ] && continue
+  pid=$(cat "$d/pid" 2>/dev/null) && [ -n "$pid" ] && kill -0 "$pid" 2>/dev/null && jobs=$((jobs+1))
+done
+gpu=$(nvidia-smi --query-gpu=utilization.gpu --format=csv,noheader,nounits 2>/dev/null | sort -n | tail -n 1)
+echo "ssh=$ssh jobs=$jobs gpu=${gpu:--1}"
+`
+
+// Activity is what's going on in a session.
+type Activity struct {
+	SSHConnections int `json:"ssh_connections"`
+	RunningJobs    int `json:"running_jobs"`
+	// GPUUtilization is the highest utilization of the GPUs of the session in percent, or
+	// -1 if it has none.
+	GPUUtilization int `json:"gpu_utilization"`
+}
+
+// Idle returns whether nobody is connected to the session and nothing is running on it.
+func (a Activity) Idle() bool {
+	return a.SSHConnections == 0 && a.RunningJobs == 0 && a.GPUUtilization < IdleGPUUtilization
+}
+
+// InspectActivity returns the activity of the session t is connected to. The connection
+// of t itself isn't counted.
+func InspectActivity(ctx context.Context, t ssh.Transport) (Activity, error) {
+	res, err := t.Run(ctx, ActivityScript)
+	if err != nil {
+		return Activity{}, fmt.Errorf("failed to inspect session activity: %w", err)
+	}
+	a, err := ParseActivity(string(res.Stdout))
+	if err != nil {
+		return Activity{}, err
+	}
+	if a.SSHConnections > 0 {
+		a.SSHConnections--
+	}
+	return a, nil
+}
+
+// ParseActivity parses the output of ActivityScript.
+func ParseActivity(out string) (Activity, error) {
+	a := Activity{GPUUtilization: -1}
+	fields := strings.Fields(out)
+	if len(fields) != 3 {
+		return Activity{}, fmt.Errorf("unexpected session activity %q", out)
+	}
+	for _, f := range fields {
+		key, value, _ := strings.Cut(f, "=")
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return Activity{}, fmt.Errorf("unexpected session activity %q", out)
+		}
+		switch key {
+		case "ssh":
+			a.SSHConnections = n
+		case "jobs":
+			a.RunningJobs = n
+		case "gpu":
+			a.GPUUtilization = n
+		default:
+			return Activity{}, fmt.Errorf("unexpected session activity %q", out)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseActivity(t *testing.T) {
+	a, err := ParseActivity("ssh=2 jobs=1 gpu=87\n")
+	require.NoError(t, err)
+	assert.Equal(t, Activity{SSHConnections: 2, RunningJobs: 1, GPUUtilization: 87}, a)
+	assert.False(t, a.Idle())
+
+	a, err = ParseActivity("ssh=0 jobs=0 gpu=-1")
+	require.NoError(t, err)
+	assert.True(t, a.Idle())
+
+	assert.False(t, Activity{GPUUtilization: IdleGPUUtilization}.Idle())
+	assert.False(t, Activity{RunningJobs: 1, GPUUtilization: -1}.Idle())
+
+	for _, out := range []string{"", "ssh=1 jobs=0", "ssh=a jobs=0 gpu=0", "ssh=1 jobs=0 cpu=3"} {
+		_, err = ParseActivity(out)
+		assert.Error(t, err, out)
+	}
+}
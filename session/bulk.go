@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+// DefaultParallelism is how many sessions bulk operations work on at once.
+const DefaultParallelism = 8
+
+// Result is the outcome of a bulk operation for one session.
+type Result struct {
+	Exec types.Exec
+	Err  error
+}
+
+// TerminateAll terminates sessions concurrently, at most parallelism at a time. onResult,
+// if set, is called as each session is done, never concurrently. The results are in the
+// order of execs.
+func (c *Client) TerminateAll(ctx context.Context, execs []types.Exec, parallelism int, onResult func(Result)) []Result {
+	results := make([]Result, len(execs))
+	var mu sync.Mutex
+
+	ForEach(len(execs), parallelism, func(i int) {
+		err := c.Terminate(ctx, execs[i].ID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = Result{Exec: execs[i], Err: err}
+		if onResult != nil {
+			onResult(results[i])
+		}
+	})
+	return results
+}
+
+// ForEach calls fn for every index below n, running at most parallelism calls at once,
+// and returns once all calls have returned.
+func ForEach(n, parallelism int, fn func(i int)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
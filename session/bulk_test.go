@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/client/clientfakes"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestTerminateAll(t *testing.T) {
+	var running, maxRunning int32
+	execer := new(clientfakes.FakeExecer)
+	execer.TerminateCalls(func(ctx context.Context, owner, project, id string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if id == "sess-3" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	c := NewClient(&client.Client{Exec: execer}, "owner", "project")
+
+	var execs []types.Exec
+	for _, id := range []string{"sess-1", "sess-2", "sess-3", "sess-4", "sess-5", "sess-6"} {
+		execs = append(execs, types.Exec{ID: id})
+	}
+
+	var reported []string
+	results := c.TerminateAll(context.Background(), execs, 2, func(r Result) {
+		reported = append(reported, r.Exec.ID)
+	})
+
+	require.Len(t, results, len(execs))
+	for i, r := range results {
+		assert.Equal(t, execs[i].ID, r.Exec.ID)
+		if r.Exec.ID == "sess-3" {
+			assert.EqualError(t, r.Err, "boom")
+		} else {
+			assert.NoError(t, r.Err)
+		}
+	}
+	assert.ElementsMatch(t, []string{"sess-1", "sess-2", "sess-3", "sess-4", "sess-5", "sess-6"}, reported)
+	assert.Equal(t, 6, execer.TerminateCallCount())
+	assert.LessOrEqual(t, maxRunning, int32(2))
+}
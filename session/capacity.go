@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/client"
+	"github.com/unweave/unweave/api/types"
+)
+
+// DefaultCapacityPollInterval is the interval between checks for capacity.
+const DefaultCapacityPollInterval = 30 * time.Second
+
+// ErrCapacityTimeout is returned when no capacity becomes available within the maximum
+// wait.
+var ErrCapacityTimeout = errors.New("timed out waiting for capacity")
+
+// CapacityWaiter waits for providers to have capacity for a session.
+type CapacityWaiter struct {
+	Provider client.Provider
+
+	// MaxWait is the overall time to wait for. Zero waits until the context is done.
+	MaxWait time.Duration
+	// Interval is the time between checks. Defaults to DefaultCapacityPollInterval.
+	Interval time.Duration
+}
+
+// CapacityPoll reports a check for capacity that found none.
+type CapacityPoll struct {
+	// Polls is how many checks were made so far, including this one.
+	Polls   int
+	Elapsed time.Duration
+	// Err is set if listing the node types of a provider failed. Waiting goes on.
+	Err error
+}
+
+// Until checks the available node types of the providers of placements until one of
+// them has capacity, and returns the first placement that has. onPoll, if not nil, is
+// called after every check that found no capacity.
+func (w *CapacityWaiter) Until(ctx context.Context, placements []Placement, onPoll func(CapacityPoll)) (Placement, error) {
+	if len(placements) == 0 {
+		return Placement{}, errors.New("no placement to wait for")
+	}
+
+	parent := ctx
+	if w.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, w.MaxWait)
+		defer cancel()
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultCapacityPollInterval
+	}
+
+	start := time.Now()
+	for polls := 1; ; polls++ {
+		p, err := w.check(ctx, placements)
+		if err == nil && p != nil {
+			return *p, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if onPoll != nil {
+			onPoll(CapacityPoll{Polls: polls, Elapsed: time.Since(start), Err: err})
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return Placement{}, fmt.Errorf("%w after %s", ErrCapacityTimeout, w.MaxWait)
+	}
+	return Placement{}, parent.Err()
+}
+
+// check returns the first of placements that has capacity, or nil if none has. Node
+// types are listed once per provider.
+func (w *CapacityWaiter) check(ctx context.Context, placements []Placement) (*Placement, error) {
+	available := map[types.Provider][]types.NodeType{}
+	var lastErr error
+	for i, p := range placements {
+		nodeTypes, ok := available[p.Provider]
+		if !ok {
+			var err error
+			if nodeTypes, err = w.Provider.ListNodeTypes(ctx, p.Provider, true); err != nil {
+				lastErr = err
+			}
+			available[p.Provider] = nodeTypes
+		}
+		if HasCapacity(p, nodeTypes) {
+			return &placements[i], nil
+		}
+	}
+	return nil, lastErr
+}
+
+// HasCapacity returns whether one of the available node types fits p. A placement
+// without a GPU type fits any node type that isn't a GPU node type.
+func HasCapacity(p Placement, available []types.NodeType) bool {
+	for _, nt := range available {
+		if p.GPUType == "" {
+			if strings.EqualFold(nt.Type, "GPU") || nt.Specs.GPU.Type != "" {
+				continue
+			}
+		} else if nt.ID != p.GPUType && nt.Specs.GPU.Type != p.GPUType {
+			continue
+		}
+		if p.Region == "" || len(nt.Regions) == 0 || contains(nt.Regions, p.Region) {
+			return true
+		}
+	}
+	return false
+}
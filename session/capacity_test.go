@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client/clientfakes"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestCapacityWaiter(t *testing.T) {
+	placements := []Placement{
+		{Provider: "unweave", GPUType: "h100", Region: "us_east_1"},
+		{Provider: "lambdalabs", GPUType: "h100"},
+	}
+
+	t.Run("should return the first placement with capacity", func(t *testing.T) {
+		provider := new(clientfakes.FakeProvider)
+		// The first check finds nothing, the second capacity in the wrong region and at
+		// the second provider.
+		provider.ListNodeTypesReturnsOnCall(0, nil, nil)
+		provider.ListNodeTypesReturnsOnCall(1, nil, errors.New("unavailable"))
+		provider.ListNodeTypesReturnsOnCall(2, []types.NodeType{{ID: "h100", Regions: []string{"us_west_2"}}}, nil)
+		provider.ListNodeTypesReturnsOnCall(3, []types.NodeType{{ID: "h100"}}, nil)
+
+		var polls []CapacityPoll
+		w := CapacityWaiter{Provider: provider, Interval: time.Millisecond}
+		p, err := w.Until(context.Background(), placements, func(p CapacityPoll) { polls = append(polls, p) })
+		require.NoError(t, err)
+		assert.Equal(t, placements[1], p)
+
+		require.Len(t, polls, 1)
+		assert.Equal(t, 1, polls[0].Polls)
+		assert.EqualError(t, polls[0].Err, "unavailable")
+
+		for i := 0; i < provider.ListNodeTypesCallCount(); i++ {
+			_, _, filterAvailable := provider.ListNodeTypesArgsForCall(i)
+			assert.True(t, filterAvailable)
+		}
+	})
+
+	t.Run("should time out after the max wait", func(t *testing.T) {
+		provider := new(clientfakes.FakeProvider)
+		provider.ListNodeTypesReturns(nil, nil)
+
+		w := CapacityWaiter{Provider: provider, Interval: time.Millisecond, MaxWait: 20 * time.Millisecond}
+		_, err := w.Until(context.Background(), placements, nil)
+		assert.True(t, errors.Is(err, ErrCapacityTimeout))
+	})
+
+	t.Run("should stop when the context is cancelled", func(t *testing.T) {
+		provider := new(clientfakes.FakeProvider)
+		provider.ListNodeTypesReturns(nil, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w := CapacityWaiter{Provider: provider, Interval: time.Millisecond}
+		_, err := w.Until(ctx, placements, func(CapacityPoll) { cancel() })
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestHasCapacity(t *testing.T) {
+	available := []types.NodeType{
+		{ID: "gpu_1x_a100", Type: "GPU", Regions: []string{"us_west_2"}, Specs: types.HardwareSpec{GPU: types.GPU{Type: "a100"}}},
+		{ID: "rtx_4000", Type: "GPU"},
+	}
+
+	assert.True(t, HasCapacity(Placement{GPUType: "a100"}, available))
+	assert.True(t, HasCapacity(Placement{GPUType: "gpu_1x_a100", Region: "us_west_2"}, available))
+	assert.False(t, HasCapacity(Placement{GPUType: "a100", Region: "us_east_1"}, available))
+	assert.True(t, HasCapacity(Placement{GPUType: "rtx_4000", Region: "us_east_1"}, available))
+	assert.False(t, HasCapacity(Placement{GPUType: "h100"}, available))
+	assert.False(t, HasCapacity(Placement{}, available))
+	assert.True(t, HasCapacity(Placement{}, append(available, types.NodeType{ID: "x86_64", Type: "CPU"})))
+}
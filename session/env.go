@@ -0,0 +1,96 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/unweave/cli/ssh"
+)
+
+// EnvFile is the file on a session that holds the environment variables injected into
+// it. Only its owner can read it.
+const EnvFile = "/root/.unweave/env"
+
+// LoadEnvCommand loads the variables in EnvFile into the shell it runs in. Login and
+// interactive shells run it on their own once the variables are installed; commands
+// run over SSH need to run it first.
+const LoadEnvCommand = "[ -f " + EnvFile + " ] && . " + EnvFile
+
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Env are environment variables to inject into a session.
+type Env map[string]string
+
+// Validate returns an error if a name isn't a valid environment variable name.
+func (e Env) Validate() error {
+	for _, key := range e.Names() {
+		if !envKeyRegex.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q, names are letters, digits and underscores", key)
+		}
+	}
+	return nil
+}
+
+// Script returns a shell script that exports the variables, sorted by name.
+func (e Env) Script() string {
+	var b strings.Builder
+	for _, key := range e.Names() {
+		fmt.Fprintf(&b, "export %s=%s\n", key, ssh.Quote(e[key]))
+	}
+	return b.String()
+}
+
+// Names returns the names of the variables, sorted.
+func (e Env) Names() []string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Install writes the variables to EnvFile on the session t is connected to, replacing
+// those installed before, and has login and interactive shells load them. The values are
+// uploaded as a file so that they never appear in a command line.
+func (e Env) Install(ctx context.Context, t ssh.Transport) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	local, err := os.CreateTemp("", "uw-env-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(local.Name())
+	if _, err = local.WriteString(e.Script()); err != nil {
+		local.Close()
+		return err
+	}
+	if err = local.Close(); err != nil {
+		return err
+	}
+
+	dir := path.Dir(EnvFile)
+	tmp := EnvFile + ".tmp"
+	if _, err = t.Run(ctx, fmt.Sprintf("mkdir -p %s && chmod 700 %s && rm -f %s && (umask 077 && touch %s)", dir, dir, tmp, tmp)); err != nil {
+		return fmt.Errorf("failed to install environment variables: %w", err)
+	}
+	if err = t.Upload(ctx, local.Name(), tmp); err != nil {
+		return fmt.Errorf("failed to upload environment variables: %w", err)
+	}
+
+	hook := ssh.Quote(LoadEnvCommand)
+	command := fmt.Sprintf("chmod 600 %s && mv %s %s && "+
+		"for f in /root/.bashrc /root/.profile; do grep -qsxF %s \"$f\" || echo %s >> \"$f\"; done",
+		tmp, tmp, EnvFile, hook, hook)
+	if _, err = t.Run(ctx, command); err != nil {
+		return fmt.Errorf("failed to install environment variables: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvScript(t *testing.T) {
+	env := Env{
+		"HF_TOKEN": "hf_abc=",
+		"QUOTES":   `it's "quoted"`,
+		"DOLLAR":   "$HOME `whoami`",
+		"MULTI":    "line 1\nline 2",
+		"EMPTY":    "",
+	}
+	script := env.Script()
+	assert.Equal(t, "export DOLLAR='$HOME `whoami`'\nexport EMPTY=''\n", script[:strings.Index(script, "export HF_TOKEN")])
+
+	// Loading the script must give back the values as they were.
+	path := filepath.Join(t.TempDir(), "env")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0600))
+	for key, want := range env {
+		out, err := exec.Command("sh", "-c", `. "$1" && printenv "$2"`, "sh", path, key).Output()
+		require.NoError(t, err)
+		assert.Equal(t, want, strings.TrimSuffix(string(out), "\n"), key)
+	}
+}
+
+func TestEnvValidate(t *testing.T) {
+	assert.NoError(t, Env{"WANDB_API_KEY": "x", "_A1": ""}.Validate())
+	assert.EqualError(t, Env{"1A": "x"}.Validate(), `invalid environment variable name "1A", names are letters, digits and underscores`)
+	assert.Error(t, Env{"A-B": "x"}.Validate())
+	assert.Error(t, Env{"": "x"}.Validate())
+}
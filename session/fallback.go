@@ -0,0 +1,129 @@
+package session
+
+import (
+	"context"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+// Placement is where a session is created: the provider, the region and the GPU type of
+// its spec. An empty region lets the provider choose.
+type Placement struct {
+	Provider types.Provider
+	Region   string
+	GPUType  string
+}
+
+func (p Placement) String() string {
+	s := string(p.Provider)
+	if p.GPUType != "" {
+		s = p.GPUType + " on " + s
+	}
+	if p.Region != "" {
+		s += " in " + p.Region
+	}
+	return s
+}
+
+// Placements returns first followed by every other combination of providers, GPU types
+// and regions, in order of preference. Regions vary first, then GPU types, so a session
+// stays with the provider and the hardware asked for as long as possible.
+func Placements(first Placement, providers []types.Provider, gpuTypes, regions []string) []Placement {
+	providers = dedupe(append([]types.Provider{first.Provider}, providers...))
+	gpuTypes = dedupe(append([]string{first.GPUType}, gpuTypes...))
+	regions = dedupe(append([]string{first.Region}, regions...))
+
+	placements := make([]Placement, 0, len(providers)*len(gpuTypes)*len(regions))
+	for _, p := range providers {
+		for _, g := range gpuTypes {
+			for _, r := range regions {
+				placements = append(placements, Placement{Provider: p, GPUType: g, Region: r})
+			}
+		}
+	}
+	return placements
+}
+
+// Fallback configures CreateWithFallback.
+type Fallback struct {
+	// Placements are tried in order until a session is created.
+	Placements []Placement
+	// Check, if set, is called before trying any placement but the first. The placement
+	// is skipped if it returns an error, e.g. because it's too expensive.
+	Check func(Placement) error
+	// OnFailure, if set, is called for every placement that is out of capacity or skipped.
+	OnFailure func(Placement, error)
+}
+
+// Allowed returns the placements of fb that pass Check. The first placement is always
+// allowed.
+func (fb Fallback) Allowed() []Placement {
+	var allowed []Placement
+	for i, p := range fb.Placements {
+		if i > 0 && fb.Check != nil && fb.Check(p) != nil {
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+	return allowed
+}
+
+// CreateWithFallback creates a session at the first of the placements of fb that has
+// capacity. It only moves on to the next placement if the provider is out of capacity,
+// and returns the error of the last placement tried if none has capacity.
+func (c *Client) CreateWithFallback(ctx context.Context, params types.ExecCreateParams, fb Fallback) (*types.Exec, Placement, error) {
+	if len(fb.Placements) == 0 {
+		exec, err := c.Create(ctx, params)
+		return exec, Placement{}, err
+	}
+
+	var lastErr error
+	for i, p := range fb.Placements {
+		if i > 0 && fb.Check != nil {
+			if err := fb.Check(p); err != nil {
+				if fb.OnFailure != nil {
+					fb.OnFailure(p, err)
+				}
+				continue
+			}
+		}
+
+		exec, err := c.Create(ctx, p.apply(params))
+		if err == nil {
+			return exec, p, nil
+		}
+		if !IsOutOfCapacity(err) {
+			return nil, p, err
+		}
+		lastErr = err
+		if fb.OnFailure != nil {
+			fb.OnFailure(p, err)
+		}
+	}
+	return nil, Placement{}, lastErr
+}
+
+// apply returns params with the provider, region and GPU type of p.
+func (p Placement) apply(params types.ExecCreateParams) types.ExecCreateParams {
+	params.Provider = p.Provider
+	params.Region = nil
+	if p.Region != "" {
+		region := p.Region
+		params.Region = &region
+	}
+	params.Spec.GPU.Type = p.GPUType
+	return params
+}
+
+func dedupe[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	out := values[:0:0]
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/client/clientfakes"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestPlacements(t *testing.T) {
+	first := Placement{Provider: "unweave", GPUType: "rtx_4000"}
+	got := Placements(first, []types.Provider{"lambdalabs", "unweave"}, []string{"a100", "rtx_4000"}, []string{"us_west_2"})
+
+	want := []Placement{
+		{Provider: "unweave", GPUType: "rtx_4000"},
+		{Provider: "unweave", GPUType: "rtx_4000", Region: "us_west_2"},
+		{Provider: "unweave", GPUType: "a100"},
+		{Provider: "unweave", GPUType: "a100", Region: "us_west_2"},
+		{Provider: "lambdalabs", GPUType: "rtx_4000"},
+		{Provider: "lambdalabs", GPUType: "rtx_4000", Region: "us_west_2"},
+		{Provider: "lambdalabs", GPUType: "a100"},
+		{Provider: "lambdalabs", GPUType: "a100", Region: "us_west_2"},
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, []Placement{first}, Placements(first, nil, nil, nil))
+
+	assert.Equal(t, "a100 on lambdalabs in us_west_2", want[7].String())
+	assert.Equal(t, "unweave", Placement{Provider: "unweave"}.String())
+}
+
+func TestCreateWithFallback(t *testing.T) {
+	outOfCapacity := &types.Error{Code: 503, Message: "No capacity available"}
+	params := types.ExecCreateParams{
+		Provider: "unweave",
+		Spec:     types.HardwareSpec{GPU: types.GPU{Type: "rtx_4000"}},
+	}
+	placements := []Placement{
+		{Provider: "unweave", GPUType: "rtx_4000"},
+		{Provider: "unweave", GPUType: "h100"},
+		{Provider: "unweave", GPUType: "a100", Region: "us_west_2"},
+		{Provider: "lambdalabs", GPUType: "a100"},
+	}
+
+	setup := func() (*Client, *clientfakes.FakeExecer) {
+		execer := new(clientfakes.FakeExecer)
+		return NewClient(&client.Client{Exec: execer}, "test", "testo"), execer
+	}
+
+	t.Run("should walk the placements until one has capacity", func(t *testing.T) {
+		c, execer := setup()
+		execer.CreateReturnsOnCall(0, nil, outOfCapacity)
+		execer.CreateReturnsOnCall(1, &types.Exec{ID: "sess-1"}, nil)
+
+		var failed []Placement
+		exec, placement, err := c.CreateWithFallback(context.Background(), params, Fallback{
+			Placements: placements,
+			Check: func(p Placement) error {
+				if p.GPUType == "h100" {
+					return errors.New("too expensive")
+				}
+				return nil
+			},
+			OnFailure: func(p Placement, err error) { failed = append(failed, p) },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "sess-1", exec.ID)
+		assert.Equal(t, placements[2], placement)
+		assert.Equal(t, placements[:2], failed)
+
+		require.Equal(t, 2, execer.CreateCallCount())
+		_, _, _, got := execer.CreateArgsForCall(1)
+		assert.Equal(t, "a100", got.Spec.GPU.Type)
+		require.NotNil(t, got.Region)
+		assert.Equal(t, "us_west_2", *got.Region)
+	})
+
+	t.Run("should stop on errors other than out of capacity", func(t *testing.T) {
+		c, execer := setup()
+		execer.CreateReturns(nil, &types.Error{Code: 400, Message: "bad request"})
+
+		_, _, err := c.CreateWithFallback(context.Background(), params, Fallback{Placements: placements})
+		assert.Error(t, err)
+		assert.False(t, IsOutOfCapacity(err))
+		assert.Equal(t, 1, execer.CreateCallCount())
+	})
+
+	t.Run("should return the last error if no placement has capacity", func(t *testing.T) {
+		c, execer := setup()
+		execer.CreateReturns(nil, outOfCapacity)
+
+		_, _, err := c.CreateWithFallback(context.Background(), params, Fallback{Placements: placements})
+		assert.True(t, IsOutOfCapacity(err))
+		assert.Equal(t, len(placements), execer.CreateCallCount())
+	})
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/tools"
+	"github.com/unweave/unweave/api/types"
+)
+
+// LabelSelector matches sessions by a label. It is written as key=value, key!=value, or
+// just key to match sessions that have the label at all.
+type LabelSelector struct {
+	Key    string
+	Value  string
+	Negate bool
+	Exists bool
+}
+
+// ParseLabelSelector parses a label selector.
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	sel := LabelSelector{}
+	if key, value, ok := strings.Cut(s, "!="); ok {
+		sel = LabelSelector{Key: key, Value: value, Negate: true}
+	} else if key, value, ok = strings.Cut(s, "="); ok {
+		sel = LabelSelector{Key: key, Value: value}
+	} else {
+		sel = LabelSelector{Key: s, Exists: true}
+	}
+	if !labelKeyRegex.MatchString(sel.Key) {
+		return LabelSelector{}, fmt.Errorf("%w: %q is not a valid selector", ErrInvalidLabel, s)
+	}
+	return sel, nil
+}
+
+// Matches returns whether labels match the selector.
+func (s LabelSelector) Matches(labels Labels) bool {
+	value, ok := labels[s.Key]
+	switch {
+	case s.Exists:
+		return ok
+	case s.Negate:
+		return !ok || value != s.Value
+	default:
+		return ok && value == s.Value
+	}
+}
+
+// Filter selects sessions. Empty fields match every session, and a session has to match
+// all the fields that are set.
+type Filter struct {
+	Labels    []LabelSelector
+	Statuses  []types.Status
+	Providers []types.Provider
+	GPUTypes  []string
+	// NewerThan and OlderThan select sessions by the time since they were created.
+	NewerThan time.Duration
+	OlderThan time.Duration
+}
+
+// IncludesTerminated returns whether the filter asks for terminated or failed sessions,
+// which the API only lists on request.
+func (f Filter) IncludesTerminated() bool {
+	for _, s := range f.Statuses {
+		if s == types.StatusTerminated || s == types.StatusError {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns whether a session with the labels provided matches the filter at the
+// time now.
+func (f Filter) Matches(exec types.Exec, labels Labels, now time.Time) bool {
+	for _, sel := range f.Labels {
+		if !sel.Matches(labels) {
+			return false
+		}
+	}
+	if len(f.Statuses) > 0 && !contains(f.Statuses, exec.Status) {
+		return false
+	}
+	if len(f.Providers) > 0 && !contains(f.Providers, exec.Provider) {
+		return false
+	}
+	if len(f.GPUTypes) > 0 && !contains(f.GPUTypes, exec.Spec.GPU.Type) {
+		return false
+	}
+
+	age := now.Sub(exec.CreatedAt)
+	if f.NewerThan > 0 && age > f.NewerThan {
+		return false
+	}
+	if f.OlderThan > 0 && age < f.OlderThan {
+		return false
+	}
+	return true
+}
+
+// ParseAge parses a duration like time.ParseDuration and also accepts days, e.g. 2d.
+func ParseAge(s string) (time.Duration, error) {
+	d, err := tools.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func contains[T comparable](list []T, v T) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/unweave/api/types"
+)
+
+func TestParseLabels(t *testing.T) {
+	t.Run("should parse key=value pairs", func(t *testing.T) {
+		labels, err := ParseLabels([]string{"team=vision", "owner=", "url=a=b"})
+		require.NoError(t, err)
+		assert.Equal(t, Labels{"team": "vision", "owner": "", "url": "a=b"}, labels)
+		assert.Equal(t, "owner=,team=vision,url=a=b", labels.String())
+	})
+
+	t.Run("should reject invalid labels", func(t *testing.T) {
+		for _, pair := range []string{"team", "=vision", "bad key=1", "-team=1"} {
+			_, err := ParseLabels([]string{pair})
+			assert.ErrorIs(t, err, ErrInvalidLabel, pair)
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	exec := types.Exec{
+		Status:    types.StatusRunning,
+		Provider:  types.Provider("lambdalabs"),
+		Spec:      types.HardwareSpec{GPU: types.GPU{Type: "rtx_5000"}},
+		CreatedAt: now.Add(-3 * time.Hour),
+	}
+	labels := Labels{"team": "vision"}
+
+	selector := func(s string) LabelSelector {
+		sel, err := ParseLabelSelector(s)
+		require.NoError(t, err)
+		return sel
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"label", Filter{Labels: []LabelSelector{selector("team=vision")}}, true},
+		{"other label value", Filter{Labels: []LabelSelector{selector("team=nlp")}}, false},
+		{"negated label", Filter{Labels: []LabelSelector{selector("team!=nlp")}}, true},
+		{"negated missing label", Filter{Labels: []LabelSelector{selector("owner!=ana")}}, true},
+		{"label exists", Filter{Labels: []LabelSelector{selector("team")}}, true},
+		{"missing label", Filter{Labels: []LabelSelector{selector("owner")}}, false},
+		{"status", Filter{Statuses: []types.Status{types.StatusPending, types.StatusRunning}}, true},
+		{"other status", Filter{Statuses: []types.Status{types.StatusPending}}, false},
+		{"provider", Filter{Providers: []types.Provider{"lambdalabs"}}, true},
+		{"other provider", Filter{Providers: []types.Provider{"unweave"}}, false},
+		{"gpu type", Filter{GPUTypes: []string{"rtx_5000"}}, true},
+		{"other gpu type", Filter{GPUTypes: []string{"a100"}}, false},
+		{"newer than", Filter{NewerThan: 4 * time.Hour}, true},
+		{"not newer than", Filter{NewerThan: 2 * time.Hour}, false},
+		{"older than", Filter{OlderThan: 2 * time.Hour}, true},
+		{"not older than", Filter{OlderThan: 4 * time.Hour}, false},
+		{"all fields have to match", Filter{Statuses: []types.Status{types.StatusRunning}, GPUTypes: []string{"a100"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Matches(exec, labels, now))
+		})
+	}
+
+	t.Run("should include terminated sessions when asked for their status", func(t *testing.T) {
+		assert.False(t, Filter{Statuses: []types.Status{types.StatusRunning}}.IncludesTerminated())
+		assert.True(t, Filter{Statuses: []types.Status{types.StatusError}}.IncludesTerminated())
+	})
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"2h":   2 * time.Hour,
+		"3d":   72 * time.Hour,
+		"0.5d": 12 * time.Hour,
+	} {
+		got, err := ParseAge(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "d", "-1h", "3w"} {
+		_, err := ParseAge(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestLabelStore(t *testing.T) {
+	store := NewLabelStore(filepath.Join(t.TempDir(), "global", "labels.json"))
+
+	labels, err := store.Get("sess-1")
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	require.NoError(t, store.Set("sess-1", Labels{"team": "vision"}))
+	require.NoError(t, store.Set("sess-2", Labels{"team": "nlp"}))
+
+	labels, err = store.Get("sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, Labels{"team": "vision"}, labels)
+
+	require.NoError(t, store.Set("sess-1", nil))
+	all, err := store.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Labels{"sess-2": {"team": "nlp"}}, all)
+}
+
+func TestLabelStoreConcurrentSet(t *testing.T) {
+	store := NewLabelStore(filepath.Join(t.TempDir(), "labels.json"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, store.Set(fmt.Sprintf("sess-%d", i), Labels{"sweep": strconv.Itoa(i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	all, err := store.All()
+	require.NoError(t, err)
+	assert.Len(t, all, 20, "no labels are lost when sessions are labeled at the same time")
+}
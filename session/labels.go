@@ -0,0 +1,81 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidLabel is returned for labels and label selectors that can't be parsed.
+var ErrInvalidLabel = errors.New("invalid label")
+
+var labelKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]{0,62}[a-zA-Z0-9])?$`)
+
+// Labels are the key=value pairs attached to a session.
+type Labels map[string]string
+
+// ParseLabels parses labels given as key=value.
+func ParseLabels(pairs []string) (Labels, error) {
+	labels := Labels{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q, expected key=value", ErrInvalidLabel, pair)
+		}
+		if !labelKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("%w: %q is not a valid key", ErrInvalidLabel, key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// String returns the labels as comma separated key=value pairs sorted by key.
+func (l Labels) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + l[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// LabelStore keeps the labels of the sessions created from this machine in a JSON file.
+// The API doesn't store labels, so they are only visible to the CLI that created the
+// session.
+type LabelStore struct {
+	file jsonFile[Labels]
+}
+
+// NewLabelStore returns a LabelStore backed by the file at path. The file is created
+// when labels are first saved.
+func NewLabelStore(path string) *LabelStore {
+	return &LabelStore{file: jsonFile[Labels]{path: path}}
+}
+
+// All returns the labels of all sessions by session ID.
+func (s *LabelStore) All() (map[string]Labels, error) {
+	return s.file.load()
+}
+
+// Get returns the labels of a session.
+func (s *LabelStore) Get(sessionID string) (Labels, error) {
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	return all[sessionID], nil
+}
+
+// Set replaces the labels of a session. Setting no labels removes the session from the
+// store.
+func (s *LabelStore) Set(sessionID string, labels Labels) error {
+	return s.file.set(sessionID, labels, len(labels) == 0)
+}
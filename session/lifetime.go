@@ -0,0 +1,92 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Lifetime limits how long a session runs.
+type Lifetime struct {
+	// TTL is how long after it was created the session is powered off.
+	TTL time.Duration
+	// IdleTimeout is how long the session may be idle before it's powered off. See
+	// Activity.Idle.
+	IdleTimeout time.Duration
+}
+
+// IsZero returns whether the lifetime of a session isn't limited.
+func (l Lifetime) IsZero() bool {
+	return l.TTL <= 0 && l.IdleTimeout <= 0
+}
+
+// Remaining returns the time left until a session created at createdAt reaches its TTL.
+// It returns false if the session has no TTL.
+func (l Lifetime) Remaining(createdAt, now time.Time) (time.Duration, bool) {
+	if l.TTL <= 0 {
+		return 0, false
+	}
+	remaining := createdAt.Add(l.TTL).Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+type lifetimeJSON struct {
+	TTL         string `json:"ttl,omitempty"`
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+}
+
+// MarshalJSON writes the durations as strings like 4h0m0s.
+func (l Lifetime) MarshalJSON() ([]byte, error) {
+	v := lifetimeJSON{}
+	if l.TTL > 0 {
+		v.TTL = l.TTL.String()
+	}
+	if l.IdleTimeout > 0 {
+		v.IdleTimeout = l.IdleTimeout.String()
+	}
+	return json.Marshal(v)
+}
+
+func (l *Lifetime) UnmarshalJSON(b []byte) error {
+	v := lifetimeJSON{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*l = Lifetime{}
+	var err error
+	if v.TTL != "" {
+		if l.TTL, err = time.ParseDuration(v.TTL); err != nil {
+			return err
+		}
+	}
+	if v.IdleTimeout != "" {
+		if l.IdleTimeout, err = time.ParseDuration(v.IdleTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LifetimeStore keeps the lifetimes of the sessions created from this machine in a JSON
+// file, so they can be shown along with the sessions.
+type LifetimeStore struct {
+	file jsonFile[Lifetime]
+}
+
+// NewLifetimeStore returns a LifetimeStore backed by the file at path.
+func NewLifetimeStore(path string) *LifetimeStore {
+	return &LifetimeStore{file: jsonFile[Lifetime]{path: path}}
+}
+
+// All returns the lifetimes of all sessions by session ID.
+func (s *LifetimeStore) All() (map[string]Lifetime, error) {
+	return s.file.load()
+}
+
+// Set saves the lifetime of a session. Saving an unlimited lifetime removes the session
+// from the store.
+func (s *LifetimeStore) Set(sessionID string, l Lifetime) error {
+	return s.file.set(sessionID, l, l.IsZero())
+}
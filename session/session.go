@@ -1,58 +1,45 @@
+// Package session creates, inspects and waits for Unweave sessions. It never prints or
+// exits the process, so it can be used from other Go programs along with the client
+// package.
 package session
 
 import (
 	"context"
 	"errors"
-	"fmt"
-	"strings"
 
 	"github.com/unweave/cli/client"
 	"github.com/unweave/cli/config"
-	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
-var uwc *client.Client
-
-// Create attempts to create a session using the Exec spec provided, uses GPUs in the config if not, returns a 503 out-of-capacity error.
-// Renders newly created sessions to the UI implicitly.
-func Create(ctx context.Context, params types.ExecCreateParams) (string, error) {
-	factory := newSessionFactory()
-
-	exec, err := factory.createSession(ctx, params)
-	if err != nil {
-		return "", fmt.Errorf("error creating session: %w", err)
-	}
-
-	renderSessionCreated(exec)
-
-	return exec.ID, nil
+// Client manages the sessions of a project.
+type Client struct {
+	uwc     *client.Client
+	owner   string
+	project string
 }
 
-type sessionFactory struct {
-	uwc         *client.Client
-	projectName string
-	owner       string
+// NewClient returns a Client for the sessions of the project owner/project.
+func NewClient(uwc *client.Client, owner, project string) *Client {
+	return &Client{uwc: uwc, owner: owner, project: project}
 }
 
-func newSessionFactory() sessionFactory {
-	if uwc == nil {
-		uwc = config.InitUnweaveClient()
+// FromConfig returns a Client for the project and credentials configured for the CLI.
+// It returns config.ErrNoProject if no project is set.
+func FromConfig() (*Client, error) {
+	owner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return nil, err
 	}
-
-	owner, projectName := config.GetProjectOwnerAndName()
-
-	return sessionFactory{uwc: uwc, projectName: projectName, owner: owner}
+	return NewClient(config.InitUnweaveClient(), owner, projectName), nil
 }
 
-func (s sessionFactory) createSession(ctx context.Context, params types.ExecCreateParams) (*types.Exec, error) {
-	var (
-		exec *types.Exec
-		err  error
-
-		hasGpuType = params.Spec.GPU.Type != ""
-		hasCpuType = params.Spec.CPU.Type != ""
-	)
+// Create creates a session with the spec provided. It fails with a *types.Error if
+// neither a CPU nor a GPU type is set, and with a 503 *types.Error if the provider is
+// out of capacity.
+func (c *Client) Create(ctx context.Context, params types.ExecCreateParams) (*types.Exec, error) {
+	hasGpuType := params.Spec.GPU.Type != ""
+	hasCpuType := params.Spec.CPU.Type != ""
 
 	if !hasGpuType && !hasCpuType {
 		return nil, &types.Error{
@@ -61,78 +48,35 @@ func (s sessionFactory) createSession(ctx context.Context, params types.ExecCrea
 			Provider:   params.Provider,
 			Err:        errors.New("missing both cpu and gpu type"),
 		}
-		//exec, err = s.createSessionFromConfigNodeTypes(ctx, params)
-	}
-
-	exec, err = s.createExecSession(ctx, params)
-	if err != nil {
-		return nil, err
 	}
 
-	return exec, nil
+	return c.uwc.Exec.Create(ctx, c.owner, c.project, params)
 }
 
-func (s sessionFactory) createExecSession(ctx context.Context, params types.ExecCreateParams) (*types.Exec, error) {
-	exec, err := s.uwc.Exec.Create(ctx, s.owner, s.projectName, params)
-	if err != nil {
-		return nil, err
-	}
-
-	return exec, nil
+// Get returns a session by ID.
+func (c *Client) Get(ctx context.Context, sessionID string) (*types.Exec, error) {
+	return c.uwc.Exec.Get(ctx, c.owner, c.project, sessionID)
 }
 
-func isOutOfCapacityError(err error) bool {
-	var e *types.Error
-	if errors.As(err, &e) && e.Code == 503 {
-		return true
-	}
-	return false
+// List returns the sessions of the project. Terminated sessions are only included if
+// listTerminated is set.
+func (c *Client) List(ctx context.Context, listTerminated bool) ([]types.Exec, error) {
+	return c.uwc.Exec.List(ctx, c.owner, c.project, listTerminated)
 }
 
-func renderSessionCreated(exec *types.Exec) {
-	if exec == nil {
-		return
-	}
-
-	nodeType := exec.Spec.GPU.Type
-	if nodeType == "" {
-		nodeType = exec.Spec.CPU.Type
-	}
-
-	results := []ui.ResultEntry{
-		{Key: "Name", Value: exec.Name},
-		{Key: "ID", Value: exec.ID},
-		{Key: "Provider", Value: exec.Provider.DisplayName()},
-		{Key: "Instance Type", Value: exec.ID},
-		{Key: "Region", Value: exec.Region},
-		{Key: "Status", Value: fmt.Sprintf("%s", exec.Status)},
-		{Key: "SSHKeys", Value: fmt.Sprintf("%s", getSSHKeyNames(exec.Keys))},
-		{Key: "CPUs", Value: fmt.Sprintf("%v", exec.Spec.CPU.Min)},
-		// Uncomment when issues setting RAM are resolved
-		// {Key: "RAM", Value: fmt.Sprintf("%vGB", exec.Specs.RAM.Min)},
-		{Key: "HDD", Value: fmt.Sprintf("%vGB", exec.Spec.HDD.Min)},
-		{Key: "Node type", Value: nodeType},
-		{Key: "NumGPUs", Value: fmt.Sprintf("%v", exec.Spec.GPU.Count.Min)},
-		{Key: "Volumes", Value: ui.FormatVolumes(exec.Volumes)},
-	}
-
-	if exec.Network.HTTPService != nil {
-		results = append(results,
-			ui.ResultEntry{Key: "InternalPort", Value: fmt.Sprintf("%d", exec.Network.HTTPService.InternalPort)},
-		)
-	}
-
-	ui.ResultTitle("Session Created:")
-	ui.Result(results, ui.IndentWidth)
-	return
+// Terminate terminates a session.
+func (c *Client) Terminate(ctx context.Context, sessionID string) error {
+	return c.uwc.Exec.Terminate(ctx, c.owner, c.project, sessionID)
 }
 
-func getSSHKeyNames(keys []types.SSHKey) string {
-	keyNames := make([]string, 0, len(keys))
-
-	for _, key := range keys {
-		keyNames = append(keyNames, key.Name)
-	}
+// Waiter returns a Waiter for the sessions of the project.
+func (c *Client) Waiter() *Waiter {
+	return &Waiter{Execer: c.uwc.Exec, Owner: c.owner, Project: c.project}
+}
 
-	return strings.Join(keyNames, ", ")
+// IsOutOfCapacity returns whether err is the API error for a provider that's out of
+// capacity.
+func IsOutOfCapacity(err error) bool {
+	var e *types.Error
+	return errors.As(err, &e) && e.Code == 503
 }
@@ -5,22 +5,20 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/unweave/cli/client"
 	"github.com/unweave/cli/client/clientfakes"
-	"github.com/unweave/cli/config"
 	"github.com/unweave/unweave/api/types"
 )
 
 func TestCreateSession(t *testing.T) {
-	config.Config.Project.URI = "test/testo"
-	setup := func() (context.Context, *clientfakes.FakeExecer) {
+	setup := func() (context.Context, *Client, *clientfakes.FakeExecer) {
 		execer := new(clientfakes.FakeExecer)
 		provider := new(clientfakes.FakeProvider)
 
-		uwc.Exec = execer
-		uwc.Provider = provider
+		uwc := &client.Client{Exec: execer, Provider: provider}
 
 		ctx := context.Background()
-		return ctx, execer
+		return ctx, NewClient(uwc, "test", "testo"), execer
 	}
 
 	t.Run(
@@ -35,10 +33,10 @@ func TestCreateSession(t *testing.T) {
 }
 
 func shouldCreateSessionFullParams(
-	setup func() (context.Context, *clientfakes.FakeExecer),
+	setup func() (context.Context, *Client, *clientfakes.FakeExecer),
 ) func(t *testing.T) {
 	return func(t *testing.T) {
-		ctx, execer := setup()
+		ctx, c, execer := setup()
 
 		createdExec := &types.Exec{ID: "created-exec-id"}
 		execer.CreateReturns(createdExec, nil)
@@ -61,20 +59,22 @@ func shouldCreateSessionFullParams(
 			},
 		}
 
-		id, err := Create(ctx, params)
+		exec, err := c.Create(ctx, params)
 		assert.NoError(t, err)
-		assert.Equal(t, id, createdExec.ID)
+		assert.Equal(t, exec.ID, createdExec.ID)
 
-		_, _, _, gotParams := execer.CreateArgsForCall(0)
+		_, owner, project, gotParams := execer.CreateArgsForCall(0)
+		assert.Equal(t, "test", owner)
+		assert.Equal(t, "testo", project)
 		assert.Equal(t, params, gotParams)
 	}
 }
 
 func shouldFailOnInvalidParams(
-	setup func() (context.Context, *clientfakes.FakeExecer),
+	setup func() (context.Context, *Client, *clientfakes.FakeExecer),
 ) func(t *testing.T) {
 	return func(t *testing.T) {
-		ctx, execer := setup()
+		ctx, c, execer := setup()
 
 		createdExec := &types.Exec{ID: "created-exec-id"}
 		execer.CreateReturns(createdExec, nil)
@@ -88,7 +88,7 @@ func shouldFailOnInvalidParams(
 			},
 		}
 
-		_, err := Create(ctx, params)
+		_, err := c.Create(ctx, params)
 
 		var wantErr *types.Error
 		assert.Error(t, err)
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/unweave/cli/tools"
+)
+
+// jsonFile keeps values by session ID in a JSON file.
+type jsonFile[T any] struct {
+	path string
+}
+
+func (f jsonFile[T]) load() (map[string]T, error) {
+	buf, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string]T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]T{}
+	if err = json.Unmarshal(buf, &all); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	return all, nil
+}
+
+// set replaces the value of a session, or removes the session if remove is set.
+func (f jsonFile[T]) set(sessionID string, v T, remove bool) error {
+	return f.update(func(all map[string]T) {
+		if remove {
+			delete(all, sessionID)
+		} else {
+			all[sessionID] = v
+		}
+	})
+}
+
+// update changes the values of any sessions with fn and saves them. The file is locked
+// while it's updated, so that CLIs running at the same time, e.g. for a sweep, don't
+// lose each other's changes.
+func (f jsonFile[T]) update(fn func(all map[string]T)) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	unlock, err := tools.LockFile(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	all, err := f.load()
+	if err != nil {
+		return err
+	}
+	fn(all)
+
+	buf, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return tools.WriteFileAtomic(f.path, buf, 0600)
+}
@@ -0,0 +1,90 @@
+package session
+
+import "time"
+
+// usageRetention is how long the usage of a session is kept after it ended.
+const usageRetention = 62 * 24 * time.Hour
+
+// Usage is when a session ran and what it costs per hour, as seen from this machine.
+// The API doesn't return when terminated sessions ended, so what they cost can only be
+// worked out from here.
+type Usage struct {
+	// Project is the URI of the project of the session.
+	Project   string    `json:"project"`
+	CreatedAt time.Time `json:"created_at"`
+	Hourly    float64   `json:"hourly"`
+	// LastSeen is when the session was last seen running.
+	LastSeen time.Time `json:"last_seen"`
+	// EndedAt is when the session was terminated, or when it was last seen running if it
+	// was terminated from elsewhere. It's zero while the session runs.
+	EndedAt time.Time `json:"ended_at"`
+}
+
+// Ended returns whether the session ended.
+func (u Usage) Ended() bool {
+	return !u.EndedAt.IsZero()
+}
+
+// UsageStore keeps the usage of sessions in a JSON file.
+type UsageStore struct {
+	file jsonFile[Usage]
+}
+
+// NewUsageStore returns a UsageStore backed by the file at path.
+func NewUsageStore(path string) *UsageStore {
+	return &UsageStore{file: jsonFile[Usage]{path: path}}
+}
+
+// All returns the usage of all sessions by session ID.
+func (s *UsageStore) All() (map[string]Usage, error) {
+	return s.file.load()
+}
+
+// Start records a session that started running.
+func (s *UsageStore) Start(sessionID string, u Usage) error {
+	return s.file.set(sessionID, u, false)
+}
+
+// Observe records that the sessions of project in running, by session ID, were running
+// at now. The sessions of the project that were running before but aren't anymore are
+// recorded as ended when they were last seen running. It returns the usage of all
+// sessions of the project.
+func (s *UsageStore) Observe(project string, running map[string]Usage, now time.Time) (map[string]Usage, error) {
+	usage := map[string]Usage{}
+	err := s.file.update(func(all map[string]Usage) {
+		for id, u := range all {
+			if u.Ended() && now.Sub(u.EndedAt) > usageRetention {
+				delete(all, id)
+				continue
+			}
+			if _, ok := running[id]; !ok && u.Project == project && !u.Ended() {
+				u.EndedAt = u.LastSeen
+				all[id] = u
+			}
+		}
+		for id, u := range running {
+			u.Project = project
+			u.LastSeen = now
+			u.EndedAt = time.Time{}
+			all[id] = u
+		}
+		for id, u := range all {
+			if u.Project == project {
+				usage[id] = u
+			}
+		}
+	})
+	return usage, err
+}
+
+// End records that sessions were terminated at now. Sessions without usage are left out.
+func (s *UsageStore) End(sessionIDs []string, now time.Time) error {
+	return s.file.update(func(all map[string]Usage) {
+		for _, id := range sessionIDs {
+			if u, ok := all[id]; ok && !u.Ended() {
+				u.EndedAt = now
+				all[id] = u
+			}
+		}
+	})
+}
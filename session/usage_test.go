@@ -0,0 +1,54 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageStore(t *testing.T) {
+	store := NewUsageStore(filepath.Join(t.TempDir(), "usage.json"))
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	seen := created.Add(time.Hour)
+
+	require.NoError(t, store.Start("sess-1", Usage{Project: "p", CreatedAt: created, Hourly: 1, LastSeen: created}))
+	require.NoError(t, store.Start("sess-2", Usage{Project: "p", CreatedAt: created, Hourly: 2, LastSeen: created}))
+	require.NoError(t, store.Start("other", Usage{Project: "q", CreatedAt: created, Hourly: 3, LastSeen: created}))
+
+	usage, err := store.Observe("p", map[string]Usage{
+		"sess-1": {CreatedAt: created, Hourly: 1},
+		"sess-2": {CreatedAt: created, Hourly: 2},
+	}, seen)
+	require.NoError(t, err)
+	assert.Len(t, usage, 2, "only the sessions of the project are returned")
+	assert.Equal(t, seen, usage["sess-1"].LastSeen)
+
+	t.Run("should end sessions that are no longer running when they were last seen", func(t *testing.T) {
+		usage, err := store.Observe("p", map[string]Usage{"sess-2": {CreatedAt: created, Hourly: 2}}, seen.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, seen, usage["sess-1"].EndedAt)
+		assert.False(t, usage["sess-2"].Ended())
+
+		all, err := store.All()
+		require.NoError(t, err)
+		assert.False(t, all["other"].Ended(), "sessions of other projects are left as they are")
+	})
+
+	t.Run("should end terminated sessions when they were terminated", func(t *testing.T) {
+		terminated := seen.Add(90 * time.Minute)
+		require.NoError(t, store.End([]string{"sess-2", "unknown"}, terminated))
+		all, err := store.All()
+		require.NoError(t, err)
+		assert.Equal(t, terminated, all["sess-2"].EndedAt)
+		assert.NotContains(t, all, "unknown")
+	})
+
+	t.Run("should forget sessions that ended long ago", func(t *testing.T) {
+		usage, err := store.Observe("p", nil, created.AddDate(0, 3, 0))
+		require.NoError(t, err)
+		assert.Empty(t, usage)
+	})
+}
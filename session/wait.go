@@ -4,76 +4,210 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
-	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/client"
 	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
-func Wait(ctx context.Context, execID string) (execch chan types.Exec, errch chan error, err error) {
-	uwc := config.InitUnweaveClient()
-	listTerminated := config.All
-	owner, projectName := config.GetProjectOwnerAndName()
-
-	errch = make(chan error)
-	execch = make(chan types.Exec)
-	currentStatus := types.StatusPending
-
-	go func() {
-		ticketCount := 0
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				sessions, err := uwc.Exec.List(ctx, owner, projectName, listTerminated)
-				if err != nil {
-					var e *types.Error
-					if errors.As(err, &e) {
-						uie := &ui.Error{Error: e}
-						fmt.Println(uie.Verbose())
-						os.Exit(1)
-					}
-					errch <- err
+const (
+	// DefaultMinPollInterval is the interval between polls right after a session
+	// changed status.
+	DefaultMinPollInterval = 500 * time.Millisecond
+	// DefaultMaxPollInterval caps the interval between polls of a session whose status
+	// isn't changing.
+	DefaultMaxPollInterval = 5 * time.Second
+)
+
+var (
+	// ErrSessionFailed is returned when a session moves to the error status while
+	// waiting for it.
+	ErrSessionFailed = errors.New("session failed to start")
+	// ErrSessionTerminated is returned when a session is terminated while waiting for it.
+	ErrSessionTerminated = errors.New("session is terminated")
+	// ErrWaitTimeout is returned when a session isn't running within the wait timeout.
+	ErrWaitTimeout = errors.New("timed out waiting for session")
+)
+
+// Event reports a status transition of a session.
+type Event struct {
+	Exec types.Exec
+	// Previous is the status the session moved from. It's empty on the first event.
+	Previous types.Status
+	// Err is set on the last event if waiting stopped before the session was running.
+	Err error
+}
+
+// execWatcher is implemented by clients that can stream session events.
+type execWatcher interface {
+	Watch(ctx context.Context, owner, project, sessionID string) (<-chan types.Exec, error)
+}
+
+// Waiter waits for sessions to be running. It subscribes to the session event stream
+// if the API offers one and otherwise polls the session with an adaptive interval.
+type Waiter struct {
+	Execer  client.Execer
+	Owner   string
+	Project string
+
+	// Timeout is the overall time to wait for. Zero waits until the context is done.
+	Timeout time.Duration
+	// MinPollInterval and MaxPollInterval bound the interval between polls. The
+	// interval doubles while the status doesn't change and resets on every change.
+	MinPollInterval time.Duration
+	MaxPollInterval time.Duration
+}
+
+// Watch reports every status transition of a session until it's running. The channel
+// is closed after the last event. If the session fails, is terminated, the timeout
+// expires or an API call fails, the last event has Err set. If ctx is cancelled, the
+// channel is closed without an error event.
+func (w *Waiter) Watch(ctx context.Context, execID string) <-chan Event {
+	events := make(chan Event)
+	go w.run(ctx, execID, events)
+	return events
+}
+
+// Until blocks until a session is running and returns it. onEvent, if not nil, is
+// called for every status transition.
+func (w *Waiter) Until(ctx context.Context, execID string, onEvent func(Event)) (*types.Exec, error) {
+	for event := range w.Watch(ctx, execID) {
+		if event.Err != nil {
+			return nil, event.Err
+		}
+		if onEvent != nil {
+			onEvent(event)
+		}
+		if event.Exec.Status == types.StatusRunning {
+			exec := event.Exec
+			return &exec, nil
+		}
+	}
+	return nil, ctx.Err()
+}
+
+func (w *Waiter) run(parent context.Context, execID string, events chan<- Event) {
+	defer close(events)
+
+	ctx := parent
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, w.Timeout)
+		defer cancel()
+	}
+
+	var current types.Status
+	var last types.Exec
+
+	// send delivers an event and returns whether the caller is still listening.
+	send := func(event Event) bool {
+		select {
+		case events <- event:
+			return true
+		case <-parent.Done():
+			return false
+		}
+	}
+
+	// report sends an event if the status changed and returns whether waiting is over.
+	report := func(exec types.Exec) bool {
+		last = exec
+		if exec.Status == current {
+			return false
+		}
+		event := Event{Exec: exec, Previous: current}
+		current = exec.Status
+
+		switch exec.Status {
+		case types.StatusRunning:
+			send(event)
+			return true
+		case types.StatusError:
+			event.Err = fmt.Errorf("%w: %s", ErrSessionFailed, execID)
+			send(event)
+			return true
+		case types.StatusTerminated:
+			event.Err = fmt.Errorf("%w: %s", ErrSessionTerminated, execID)
+			send(event)
+			return true
+		}
+		return !send(event)
+	}
+
+	// stop reports why the context ended. Cancellation by the caller isn't an error.
+	stop := func() {
+		if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			send(Event{Exec: last, Previous: current, Err: fmt.Errorf("%w %q after %s", ErrWaitTimeout, execID, w.Timeout)})
+		}
+	}
+
+	if watcher, ok := w.Execer.(execWatcher); ok {
+		stream, err := watcher.Watch(ctx, w.Owner, w.Project, execID)
+		switch {
+		case err == nil:
+			for exec := range stream {
+				if report(exec) {
 					return
 				}
+			}
+			if ctx.Err() != nil {
+				stop()
+				return
+			}
+			ui.Debugf("Session event stream closed, falling back to polling")
+		case errors.Is(err, client.ErrWatchUnsupported):
+		case ctx.Err() != nil:
+			stop()
+			return
+		default:
+			ui.Debugf("Failed to watch session %q, falling back to polling: %v", execID, err)
+		}
+	}
 
-				for _, s := range sessions {
-					s := s
-					if s.ID == execID {
-						if s.Status == types.StatusRunning {
-							currentStatus = s.Status
-							execch <- s
-							return
-						}
-						if s.Status != currentStatus {
-							currentStatus = s.Status
-							execch <- s
-						}
-						if s.Status == types.StatusError {
-							ui.Errorf("❌ Session %s failed to start", execID)
-							os.Exit(1)
-						}
-						if s.Status == types.StatusTerminated {
-							ui.Errorf("Session %q is terminated.", execID)
-							os.Exit(1)
-						}
-
-						if ticketCount%10 == 0 && s.Status != types.StatusRunning {
-							ui.Infof("Waiting for session %q to start...", execID)
-						}
-						ticketCount++
-					}
-				}
+	minInterval, maxInterval := w.MinPollInterval, w.MaxPollInterval
+	if minInterval <= 0 {
+		minInterval = DefaultMinPollInterval
+	}
+	if maxInterval < minInterval {
+		maxInterval = DefaultMaxPollInterval
+		if maxInterval < minInterval {
+			maxInterval = minInterval
+		}
+	}
 
-			case <-ctx.Done():
+	interval := minInterval
+	for {
+		exec, err := w.Execer.Get(ctx, w.Owner, w.Project, execID)
+		if err != nil {
+			if ctx.Err() != nil {
+				stop()
 				return
 			}
+			send(Event{Exec: last, Previous: current, Err: err})
+			return
 		}
-	}()
 
-	return execch, errch, nil
+		previous := current
+		if report(*exec) {
+			return
+		}
+		if exec.Status != previous {
+			interval = minInterval
+		} else {
+			interval *= 2
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			stop()
+			return
+		}
+	}
 }
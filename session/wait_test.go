@@ -0,0 +1,189 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unweave/cli/client"
+	"github.com/unweave/cli/client/clientfakes"
+	"github.com/unweave/unweave/api/types"
+)
+
+// streamingExecer is a FakeExecer that also implements the session event stream.
+type streamingExecer struct {
+	*clientfakes.FakeExecer
+	stream []types.Exec
+	err    error
+}
+
+func (s *streamingExecer) Watch(ctx context.Context, owner, project, sessionID string) (<-chan types.Exec, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ch := make(chan types.Exec, len(s.stream))
+	for _, e := range s.stream {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func newTestWaiter(execer client.Execer) *Waiter {
+	return &Waiter{
+		Execer:          execer,
+		Owner:           "owner",
+		Project:         "project",
+		MinPollInterval: time.Millisecond,
+		MaxPollInterval: 4 * time.Millisecond,
+	}
+}
+
+func execWithStatus(status types.Status) *types.Exec {
+	return &types.Exec{ID: "exec-id", Status: status}
+}
+
+func collect(events <-chan Event) []Event {
+	var all []Event
+	for e := range events {
+		all = append(all, e)
+	}
+	return all
+}
+
+func TestWaiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should report every transition until running", func(t *testing.T) {
+		execer := new(clientfakes.FakeExecer)
+		execer.GetReturnsOnCall(0, execWithStatus(types.StatusPending), nil)
+		execer.GetReturnsOnCall(1, execWithStatus(types.StatusPending), nil)
+		execer.GetReturnsOnCall(2, execWithStatus(types.StatusInitializing), nil)
+		execer.GetReturnsOnCall(3, execWithStatus(types.StatusRunning), nil)
+
+		events := collect(newTestWaiter(execer).Watch(ctx, "exec-id"))
+
+		require.Len(t, events, 3)
+		assert.Equal(t, types.Status(""), events[0].Previous)
+		assert.Equal(t, types.StatusPending, events[0].Exec.Status)
+		assert.Equal(t, types.StatusPending, events[1].Previous)
+		assert.Equal(t, types.StatusInitializing, events[1].Exec.Status)
+		assert.Equal(t, types.StatusRunning, events[2].Exec.Status)
+		for _, e := range events {
+			assert.NoError(t, e.Err)
+		}
+
+		assert.Equal(t, 4, execer.GetCallCount())
+		_, owner, project, id := execer.GetArgsForCall(0)
+		assert.Equal(t, []string{"owner", "project", "exec-id"}, []string{owner, project, id})
+	})
+
+	t.Run("should return typed errors when the session ends", func(t *testing.T) {
+		for status, want := range map[types.Status]error{
+			types.StatusError:      ErrSessionFailed,
+			types.StatusTerminated: ErrSessionTerminated,
+		} {
+			execer := new(clientfakes.FakeExecer)
+			execer.GetReturns(execWithStatus(status), nil)
+
+			_, err := newTestWaiter(execer).Until(ctx, "exec-id", nil)
+			assert.ErrorIs(t, err, want)
+		}
+	})
+
+	t.Run("should return API errors instead of exiting", func(t *testing.T) {
+		execer := new(clientfakes.FakeExecer)
+		execer.GetReturns(nil, &types.Error{Code: 401, Message: "unauthorized"})
+
+		_, err := newTestWaiter(execer).Until(ctx, "exec-id", nil)
+
+		var e *types.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, 401, e.Code)
+	})
+
+	t.Run("should time out", func(t *testing.T) {
+		execer := new(clientfakes.FakeExecer)
+		execer.GetReturns(execWithStatus(types.StatusPending), nil)
+
+		w := newTestWaiter(execer)
+		w.Timeout = 20 * time.Millisecond
+
+		_, err := w.Until(ctx, "exec-id", nil)
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+	})
+
+	t.Run("should close the channel without an error when cancelled", func(t *testing.T) {
+		execer := new(clientfakes.FakeExecer)
+		execer.GetReturns(execWithStatus(types.StatusPending), nil)
+
+		ctx, cancel := context.WithCancel(ctx)
+		events := newTestWaiter(execer).Watch(ctx, "exec-id")
+		<-events
+		cancel()
+
+		for e := range events {
+			assert.NoError(t, e.Err)
+		}
+	})
+
+	t.Run("should back off while the status doesn't change", func(t *testing.T) {
+		execer := new(clientfakes.FakeExecer)
+		execer.GetReturns(execWithStatus(types.StatusPending), nil)
+
+		w := newTestWaiter(execer)
+		w.MinPollInterval = 10 * time.Millisecond
+		w.MaxPollInterval = 40 * time.Millisecond
+		w.Timeout = 200 * time.Millisecond
+
+		_, err := w.Until(ctx, "exec-id", nil)
+		require.ErrorIs(t, err, ErrWaitTimeout)
+
+		// Polling every 10ms would take 20 polls, backing off to 40ms takes about 7.
+		assert.Less(t, execer.GetCallCount(), 12)
+	})
+
+	t.Run("should use the event stream if available", func(t *testing.T) {
+		execer := &streamingExecer{
+			FakeExecer: new(clientfakes.FakeExecer),
+			stream: []types.Exec{
+				*execWithStatus(types.StatusPending),
+				*execWithStatus(types.StatusRunning),
+			},
+		}
+
+		exec, err := newTestWaiter(execer).Until(ctx, "exec-id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, types.StatusRunning, exec.Status)
+		assert.Equal(t, 0, execer.GetCallCount())
+	})
+
+	t.Run("should fall back to polling", func(t *testing.T) {
+		for _, streamErr := range []error{client.ErrWatchUnsupported, errors.New("connection reset")} {
+			execer := &streamingExecer{FakeExecer: new(clientfakes.FakeExecer), err: streamErr}
+			execer.GetReturns(execWithStatus(types.StatusRunning), nil)
+
+			_, err := newTestWaiter(execer).Until(ctx, "exec-id", nil)
+			require.NoError(t, err)
+			assert.Equal(t, 1, execer.GetCallCount())
+		}
+	})
+
+	t.Run("should poll once the stream ends early", func(t *testing.T) {
+		execer := &streamingExecer{
+			FakeExecer: new(clientfakes.FakeExecer),
+			stream:     []types.Exec{*execWithStatus(types.StatusPending)},
+		}
+		execer.GetReturns(execWithStatus(types.StatusRunning), nil)
+
+		var seen []types.Status
+		_, err := newTestWaiter(execer).Until(ctx, "exec-id", func(e Event) {
+			seen = append(seen, e.Exec.Status)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Status{types.StatusPending, types.StatusRunning}, seen)
+	})
+}
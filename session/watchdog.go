@@ -0,0 +1,125 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/unweave/cli/ssh"
+)
+
+// WatchdogDir is the directory on the session the watchdog is installed in.
+const WatchdogDir = "/root/.unweave/watchdog"
+
+// DefaultWatchdogInterval is how often the watchdog checks the session.
+const DefaultWatchdogInterval = time.Minute
+
+// DefaultShutdownCommand is how the watchdog shuts a session down.
+const DefaultShutdownCommand = "poweroff 2> /dev/null || kill -TERM 1"
+
+// Watchdog powers the machine of a session off from the session itself once it reaches
+// its TTL or has been idle for its idle timeout. It holds no credentials, so it can't
+// terminate the session through the API: the API has no TTL of its own and no tokens
+// scoped to a session. Depending on the provider, the session may still be listed as
+// running, and billed, until it's terminated with unweave terminate.
+//
+// The CLI installs the watchdog once a session it created runs. Sessions it stops waiting
+// for before that, e.g. on Ctrl+C, have none.
+type Watchdog struct {
+	Lifetime Lifetime
+	// CreatedAt is when the session was created. The TTL counts from then.
+	CreatedAt time.Time
+	// Interval defaults to DefaultWatchdogInterval.
+	Interval time.Duration
+	// ShutdownCommand defaults to DefaultShutdownCommand.
+	ShutdownCommand string
+}
+
+const watchdogScript = `#!/bin/sh
+# Powers this session off once it reaches its TTL or has been idle for too long.
+. "$(dirname "$0")/env"
+
+activity() {
+%s}
+
+terminate() {
+  echo "$(date) powering the session off: $1"
+  eval "$SHUTDOWN"
+  exit 0
+}
+
+idle_since=
+while true; do
+  now=$(date +%%s)
+  if [ "$DEADLINE" -gt 0 ] && [ "$now" -ge "$DEADLINE" ]; then
+    terminate "TTL reached"
+  fi
+  if [ "$IDLE_TIMEOUT" -gt 0 ]; then
+    eval "$(activity)"
+    if [ "$ssh" -eq 0 ] && [ "$jobs" -eq 0 ] && [ "$gpu" -lt "$IDLE_GPU_UTILIZATION" ]; then
+      idle_since=${idle_since:-$now}
+      if [ $((now - idle_since)) -ge "$IDLE_TIMEOUT" ]; then
+        terminate "idle for $((now - idle_since))s"
+      fi
+    else
+      idle_since=
+    fi
+  fi
+  sleep "$INTERVAL"
+done
+`
+
+// Script returns the shell script the watchdog runs.
+func (w Watchdog) Script() string {
+	return fmt.Sprintf(watchdogScript, ActivityScript)
+}
+
+// Env returns the settings of the watchdog as shell variable assignments.
+func (w Watchdog) Env() string {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchdogInterval
+	}
+	var deadline int64
+	if w.Lifetime.TTL > 0 {
+		deadline = w.CreatedAt.Add(w.Lifetime.TTL).Unix()
+	}
+
+	shutdown := w.ShutdownCommand
+	if shutdown == "" {
+		shutdown = DefaultShutdownCommand
+	}
+
+	vars := []string{
+		fmt.Sprintf("DEADLINE=%d", deadline),
+		fmt.Sprintf("IDLE_TIMEOUT=%d", int64(w.Lifetime.IdleTimeout.Seconds())),
+		fmt.Sprintf("IDLE_GPU_UTILIZATION=%d", IdleGPUUtilization),
+		fmt.Sprintf("INTERVAL=%d", int64(interval.Seconds())),
+		"SHUTDOWN=" + ssh.Quote(shutdown),
+	}
+	return strings.Join(vars, "\n") + "\n"
+}
+
+// Install installs the watchdog on the session t is connected to and starts it. A
+// watchdog that is already running is replaced.
+func (w Watchdog) Install(ctx context.Context, t ssh.Transport) error {
+	script := path.Join(WatchdogDir, "watchdog.sh")
+	env := path.Join(WatchdogDir, "env")
+	pid := path.Join(WatchdogDir, "pid")
+
+	command := fmt.Sprintf("mkdir -p %s && chmod 700 %s && "+
+		"{ [ -f %s ] && kill $(cat %s) 2>/dev/null; true; } && "+
+		"(umask 077 && printf '%%s' %s > %s && printf '%%s' %s > %s) && "+
+		"{ setsid nohup sh %s >> %s 2>&1 < /dev/null & echo $! > %s; }",
+		WatchdogDir, WatchdogDir,
+		pid, pid,
+		ssh.Quote(w.Script()), script, ssh.Quote(w.Env()), env,
+		script, path.Join(WatchdogDir, "watchdog.log"), pid)
+
+	if _, err := t.Run(ctx, command); err != nil {
+		return fmt.Errorf("failed to install watchdog: %w", err)
+	}
+	return nil
+}
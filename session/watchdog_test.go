@@ -0,0 +1,88 @@
+package session
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWatchdog runs the watchdog script locally until it exits and returns what it shut
+// the session down for.
+func runWatchdog(t *testing.T, w Watchdog) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	reason := filepath.Join(dir, "shutdown")
+	w.ShutdownCommand = "echo \"$1\" > " + reason
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "watchdog.sh"), []byte(w.Script()), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "env"), []byte(w.Env()), 0600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "sh", filepath.Join(dir, "watchdog.sh")).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	buf, err := os.ReadFile(reason)
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(buf))
+}
+
+func TestWatchdog(t *testing.T) {
+	t.Run("should shut the session down once its TTL is reached", func(t *testing.T) {
+		reason := runWatchdog(t, Watchdog{
+			Lifetime:  Lifetime{TTL: time.Hour},
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+			Interval:  time.Second,
+		})
+		assert.Equal(t, "TTL reached", reason)
+	})
+
+	t.Run("should shut the session down once it's idle for too long", func(t *testing.T) {
+		if _, err := exec.LookPath("nvidia-smi"); err == nil {
+			t.Skip("GPUs may be busy")
+		}
+		start := time.Now()
+		reason := runWatchdog(t, Watchdog{
+			Lifetime:  Lifetime{IdleTimeout: time.Second},
+			CreatedAt: time.Now(),
+			Interval:  time.Second,
+		})
+		assert.True(t, strings.HasPrefix(reason, "idle for "), reason)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("should hold no credentials", func(t *testing.T) {
+		env := Watchdog{Lifetime: Lifetime{TTL: time.Hour}, CreatedAt: time.Now()}.Env()
+		assert.NotContains(t, env, "TOKEN")
+		assert.Contains(t, env, "SHUTDOWN='"+DefaultShutdownCommand+"'")
+	})
+}
+
+func TestLifetime(t *testing.T) {
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	_, ok := Lifetime{IdleTimeout: time.Hour}.Remaining(created, created)
+	assert.False(t, ok)
+
+	remaining, ok := Lifetime{TTL: 4 * time.Hour}.Remaining(created, created.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Hour, remaining)
+
+	remaining, _ = Lifetime{TTL: time.Hour}.Remaining(created, created.Add(2*time.Hour))
+	assert.Equal(t, time.Duration(0), remaining)
+
+	store := NewLifetimeStore(filepath.Join(t.TempDir(), "lifetimes.json"))
+	require.NoError(t, store.Set("sess-1", Lifetime{TTL: 4 * time.Hour, IdleTimeout: 30 * time.Minute}))
+	require.NoError(t, store.Set("sess-2", Lifetime{}))
+	all, err := store.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Lifetime{"sess-1": {TTL: 4 * time.Hour, IdleTimeout: 30 * time.Minute}}, all)
+}
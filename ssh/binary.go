@@ -0,0 +1,134 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/unweave/cli/ui"
+)
+
+// binaryTransport shells out to the system ssh and scp binaries. It's used as a
+// fallback when the native transport can't be used.
+type binaryTransport struct {
+	opts Options
+}
+
+func newBinaryTransport(opts Options) *binaryTransport {
+	return &binaryTransport{opts: opts}
+}
+
+// args returns the common ssh/scp arguments, respecting any host key options the
+// user has overridden. The user's arguments come last so that they're parsed as options
+// too, right before the destination. portFlag is how the command takes the port, -p for
+// ssh and -P for scp.
+func (t *binaryTransport) args(portFlag string) []string {
+	overrideUserKnownHostsFile := false
+	overrideStrictHostKeyChecking := false
+
+	for _, arg := range t.opts.Args {
+		if strings.Contains(arg, "UserKnownHostsFile") {
+			overrideUserKnownHostsFile = true
+		}
+		if strings.Contains(arg, "StrictHostKeyChecking") {
+			overrideStrictHostKeyChecking = true
+		}
+	}
+
+	var args []string
+	if t.opts.Network.Port != 0 {
+		args = append(args, portFlag, strconv.Itoa(t.opts.Network.Port))
+	}
+	if t.opts.PrivateKeyPath != "" {
+		args = append(args, "-i", t.opts.PrivateKeyPath)
+	}
+	if !overrideUserKnownHostsFile {
+		args = append(args, "-o", "UserKnownHostsFile=/dev/null")
+	}
+	if !overrideStrictHostKeyChecking {
+		args = append(args, "-o", "StrictHostKeyChecking=no")
+	}
+	return append(args, t.opts.Args...)
+}
+
+func (t *binaryTransport) Shell(ctx context.Context, command []string) error {
+	sshArgs := append(t.args("-p"), userAtHost(t.opts.Network))
+	sshArgs = append(sshArgs, command...)
+
+	sshCommand := exec.CommandContext(ctx, "ssh", sshArgs...)
+	ui.Debugf("Running SSH command: %s", strings.Join(sshCommand.Args, " "))
+
+	sshCommand.Stdin = os.Stdin
+	sshCommand.Stdout = os.Stdout
+	sshCommand.Stderr = os.Stderr
+
+	return binaryExitError(strings.Join(command, " "), sshCommand.Run(), nil)
+}
+
+func (t *binaryTransport) Run(ctx context.Context, command string) (*Result, error) {
+	sshArgs := append(t.args("-p"), userAtHost(t.opts.Network), command)
+
+	sshCommand := exec.CommandContext(ctx, "ssh", sshArgs...)
+	ui.Debugf("Running SSH command: %s", strings.Join(sshCommand.Args, " "))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	sshCommand.Stdout = stdout
+	sshCommand.Stderr = stderr
+
+	err := sshCommand.Run()
+	res := &Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitStatus = exitErr.ExitCode()
+	}
+	return res, binaryExitError(command, err, res.Stderr)
+}
+
+func (t *binaryTransport) Upload(ctx context.Context, src, dst string) error {
+	return t.scp(ctx, src, fmt.Sprintf("%s:%s", userAtHost(t.opts.Network), dst))
+}
+
+func (t *binaryTransport) Download(ctx context.Context, src, dst string) error {
+	return t.scp(ctx, fmt.Sprintf("%s:%s", userAtHost(t.opts.Network), src), dst)
+}
+
+func (t *binaryTransport) scp(ctx context.Context, from, to string) error {
+	scpArgs := append([]string{"-r"}, t.args("-P")...)
+	scpArgs = append(scpArgs, from, to)
+
+	scpCommand := exec.CommandContext(ctx, "scp", scpArgs...)
+	ui.Debugf("Running SCP command: %s", strings.Join(scpCommand.Args, " "))
+
+	stderr := &bytes.Buffer{}
+	scpCommand.Stderr = stderr
+
+	return binaryExitError("scp", scpCommand.Run(), stderr.Bytes())
+}
+
+func (t *binaryTransport) Close() error {
+	return nil
+}
+
+// binaryExitError maps errors returned by the ssh and scp binaries to the transport
+// errors. Both exit with status 255 when the connection fails.
+func binaryExitError(command string, err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == 255 {
+			return ErrConnectionClosed
+		}
+		return &ExitError{Command: command, ExitStatus: exitErr.ExitCode(), Stderr: string(stderr)}
+	}
+	return fmt.Errorf("%s failed: %w", command, err)
+}
@@ -12,20 +12,21 @@ import (
 	"github.com/unweave/cli/ui"
 )
 
-func getUnweaveSSHConfigPath() string {
-	return filepath.Join(config.GetGlobalConfigPath(), "ssh_config")
+func getUnweaveSSHConfigPath() (string, error) {
+	dir, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ssh_config"), nil
 }
 
-var sshDirPath = func() string {
+func getSSHDirPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		ui.Errorf("Failed to get user home directory: %v", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	return filepath.Join(homeDir, ".ssh")
-}()
-
-var sshConfigPath = filepath.Join(sshDirPath, "config")
+	return filepath.Join(homeDir, ".ssh"), nil
+}
 
 func AddHost(alias, host, user string, port int, identityFile string) error {
 	configEntry := fmt.Sprintf(`Host %s
@@ -42,7 +43,17 @@ func AddHost(alias, host, user string, port int, identityFile string) error {
 		return fmt.Errorf("expected identity file, got an empty string")
 	}
 
-	file, err := os.OpenFile(getUnweaveSSHConfigPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	unweaveSSHConfigPath, err := getUnweaveSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	sshDirPath, err := getSSHDirPath()
+	if err != nil {
+		return err
+	}
+	sshConfigPath := filepath.Join(sshDirPath, "config")
+
+	file, err := os.OpenFile(unweaveSSHConfigPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
@@ -58,9 +69,8 @@ func AddHost(alias, host, user string, port int, identityFile string) error {
 	}
 
 	// Add an Include directive to the user's ssh config to unweave_global SSH configs - used for vscode-remote:
-	err = os.MkdirAll(sshDirPath, 0700)
-	if err != nil {
-		fmt.Println("Failed to create .ssh folder:", err)
+	if err = os.MkdirAll(sshDirPath, 0700); err != nil {
+		return fmt.Errorf("failed to create .ssh folder: %w", err)
 	}
 	if _, err := os.Stat(sshConfigPath); os.IsNotExist(err) {
 		if _, err = os.Create(sshConfigPath); err != nil {
@@ -73,7 +83,7 @@ func AddHost(alias, host, user string, port int, identityFile string) error {
 	}
 
 	// Add to the top of the file if it doesn't already exist
-	includeEntry := "Include " + getUnweaveSSHConfigPath()
+	includeEntry := "Include " + unweaveSSHConfigPath
 	for _, line := range lines {
 		if strings.HasPrefix(line, includeEntry) {
 			return nil
@@ -85,7 +95,11 @@ func AddHost(alias, host, user string, port int, identityFile string) error {
 }
 
 func RemoveHost(alias string) error {
-	lines, err := readLines(getUnweaveSSHConfigPath())
+	unweaveSSHConfigPath, err := getUnweaveSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(unweaveSSHConfigPath)
 	if err != nil {
 		return err
 	}
@@ -119,7 +133,7 @@ func RemoveHost(alias string) error {
 		lines = lines[:startIndex]
 	}
 
-	return writeLines(getUnweaveSSHConfigPath(), lines)
+	return writeLines(unweaveSSHConfigPath, lines)
 }
 
 func readLines(path string) ([]string, error) {
@@ -2,69 +2,19 @@ package ssh
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"os/exec"
-	"strings"
-	"syscall"
 
-	"github.com/unweave/cli/ui"
 	"github.com/unweave/unweave/api/types"
 )
 
+// Connect opens an interactive terminal on the session host. If command is not empty,
+// it's run instead of the login shell. ErrConnectionClosed is returned if the remote
+// host drops the connection.
 func Connect(ctx context.Context, connectionInfo types.ExecNetwork, prvKeyPath string, args []string, command []string) error {
-	overrideUserKnownHostsFile := false
-	overrideStrictHostKeyChecking := false
-
-	for _, arg := range args {
-		if strings.Contains(arg, "UserKnownHostsFile") {
-			overrideUserKnownHostsFile = true
-		}
-		if strings.Contains(arg, "StrictHostKeyChecking") {
-			overrideStrictHostKeyChecking = true
-		}
-	}
-
-	if prvKeyPath != "" {
-		args = append(args, "-i", prvKeyPath)
+	t, err := Dial(ctx, Options{Network: connectionInfo, PrivateKeyPath: prvKeyPath, Args: args})
+	if err != nil {
+		return err
 	}
+	defer t.Close()
 
-	if !overrideUserKnownHostsFile {
-		args = append(args, "-o", "UserKnownHostsFile=/dev/null")
-	}
-	if !overrideStrictHostKeyChecking {
-		args = append(args, "-o", "StrictHostKeyChecking=no")
-	}
-
-	sshArgs := append(args, fmt.Sprintf("%s@%s", connectionInfo.User, connectionInfo.Host))
-
-	if len(command) != 0 {
-		sshArgs = append(sshArgs, command...)
-	}
-
-	sshCommand := exec.Command(
-		"ssh",
-		sshArgs...,
-	)
-
-	ui.Debugf("Running SSH command: %s", strings.Join(sshCommand.Args, " "))
-
-	sshCommand.Stdin = os.Stdin
-	sshCommand.Stdout = os.Stdout
-	sshCommand.Stderr = os.Stderr
-
-	if err := sshCommand.Run(); err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Exited with non-zero exit code
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 255 {
-					ui.Infof("The remote host closed the connection.")
-					return nil
-				}
-			}
-			return err
-		}
-		return fmt.Errorf("SSH command failed: %v", err)
-	}
-	return nil
+	return t.Shell(ctx, command)
 }
@@ -3,7 +3,6 @@ package ssh
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -47,12 +46,6 @@ func Add(ctx context.Context, publicKeyPath, owner string, name *string) (string
 
 	keyname, err := uwc.SSHKey.Add(ctx, owner, params)
 	if err != nil {
-		var e *types.Error
-		if errors.As(err, &e) {
-			uie := &ui.Error{Error: e}
-			fmt.Println(uie.Verbose())
-			os.Exit(1)
-		}
 		return "", err
 	}
 	return keyname, nil
@@ -64,26 +57,25 @@ func Generate(ctx context.Context, owner string, name *string) (keyname, keypath
 
 	res, err := uwc.SSHKey.Generate(ctx, owner, params)
 	if err != nil {
-		return "", "", nil, ui.HandleError(err)
+		return "", "", nil, err
 	}
 
 	prv := []byte(res.PrivateKey)
 	pub = []byte(res.PublicKey)
 
-	sshDir := config.GetUnweaveSSHKeysFolder()
+	sshDir, err := config.GetUnweaveSSHKeysFolder()
+	if err != nil {
+		return "", "", nil, err
+	}
 	pubPath := filepath.Join(sshDir, res.Name+".pub")
 	prvPath := filepath.Join(sshDir, res.Name)
 
 	if err = os.WriteFile(prvPath, prv, 0600); err != nil {
-		ui.Errorf("Failed to write private key to %s: %v", prvPath, err)
-		os.Exit(1)
-		return "", "", nil, nil
+		return "", "", nil, fmt.Errorf("failed to write private key to %s: %w", prvPath, err)
 	}
 
 	if err = os.WriteFile(pubPath, pub, 0600); err != nil {
-		ui.Errorf("Failed to write public key to %s: %v", pubPath, err)
-		os.Exit(1)
-		return "", "", nil, nil
+		return "", "", nil, fmt.Errorf("failed to write public key to %s: %w", pubPath, err)
 	}
 
 	return res.Name, pubPath, pub, nil
@@ -91,7 +83,6 @@ func Generate(ctx context.Context, owner string, name *string) (keyname, keypath
 
 func GenerateFromPrivateKey(ctx context.Context, path string, name *string) (keyName string, pub []byte, err error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		ui.Errorf("Private key not found: %s", path)
 		return "", nil, fmt.Errorf("private key not found: %s", path)
 	}
 
@@ -129,12 +120,10 @@ func GenerateFromPrivateKey(ctx context.Context, path string, name *string) (key
 	pub = []byte(output)
 
 	if err = os.WriteFile(path+".pub", pub, 0600); err != nil {
-		ui.Attentionf("Could not write public key to file.")
+		ui.Debugf("Could not write public key to file: %v", err)
 	}
 
-	ui.Infof("Generated public key from private key at path: %s", path)
-
-	keyname, err := Add(ctx, path+".pub", config.Config.Unweave.User.ID, name)
+	keyname, err := Add(ctx, path+".pub", config.AccountID(), name)
 	if err != nil {
 		return "", nil, err
 	}
@@ -0,0 +1,358 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+const dialTimeout = 30 * time.Second
+
+type nativeTransport struct {
+	client *ssh.Client
+	sftp   *sftp.Client
+	// agent is the connection to the ssh-agent, if it's used to authenticate.
+	agent net.Conn
+}
+
+func dialNative(ctx context.Context, opts Options) (*nativeTransport, error) {
+	auth, agentConn, err := authMethods(opts.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	closeAgent := func() {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+	}
+
+	port := opts.Network.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(opts.Network.Host, strconv.Itoa(port))
+
+	cfg := &ssh.ClientConfig{
+		User: opts.Network.User,
+		Auth: auth,
+		// Session hosts are ephemeral and get new host keys every time they're
+		// provisioned. This matches the StrictHostKeyChecking=no default of the binary
+		// transport.
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         dialTimeout,
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		closeAgent()
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		conn.Close()
+		closeAgent()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+
+	return &nativeTransport{client: ssh.NewClient(c, chans, reqs), agent: agentConn}, nil
+}
+
+// authMethods returns the private key at keyPath and any keys held by the running
+// ssh-agent as auth methods, along with the connection to the agent if there is one.
+// The agent is asked for signatures until the connection is closed.
+func authMethods(keyPath string) ([]ssh.AuthMethod, net.Conn, error) {
+	var methods []ssh.AuthMethod
+
+	if keyPath != "" {
+		buf, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(buf)
+		if err == nil {
+			methods = append(methods, ssh.PublicKeys(signer))
+		} else {
+			var passErr *ssh.PassphraseMissingError
+			if !errors.As(err, &passErr) {
+				return nil, nil, fmt.Errorf("failed to parse private key %s: %w", keyPath, err)
+			}
+			// Encrypted keys can still be used if they're loaded into the agent.
+		}
+	}
+
+	var agentConn net.Conn
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no usable SSH credentials found")
+	}
+	return methods, agentConn, nil
+}
+
+func (t *nativeTransport) Shell(ctx context.Context, command []string) error {
+	session, err := t.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = "xterm-256color"
+		}
+		modes := ssh.TerminalModes{ssh.ECHO: 1, ssh.TTY_OP_ISPEED: 14400, ssh.TTY_OP_OSPEED: 14400}
+		if err = session.RequestPty(termType, height, width, modes); err != nil {
+			return fmt.Errorf("failed to request pty: %w", err)
+		}
+
+		stop := watchWindowSize(fd, session)
+		defer stop()
+	}
+
+	if len(command) == 0 {
+		err = session.Shell()
+	} else {
+		err = session.Start(strings.Join(command, " "))
+	}
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	select {
+	case err = <-done:
+		return exitError(strings.Join(command, " "), err, nil)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *nativeTransport) Run(ctx context.Context, command string) (*Result, error) {
+	session, err := t.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	res := &Result{Stdout: []byte(stdout.String()), Stderr: []byte(stderr.String())}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitStatus = exitErr.ExitStatus()
+	}
+	return res, exitError(command, err, res.Stderr)
+}
+
+// exitError maps errors returned by an ssh.Session to the transport errors.
+func exitError(command string, err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Command: command, ExitStatus: exitErr.ExitStatus(), Stderr: string(stderr)}
+	}
+	var missingErr *ssh.ExitMissingError
+	if errors.As(err, &missingErr) || errors.Is(err, io.EOF) {
+		return ErrConnectionClosed
+	}
+	return err
+}
+
+func (t *nativeTransport) sftpClient() (*sftp.Client, error) {
+	if t.sftp != nil {
+		return t.sftp, nil
+	}
+	c, err := sftp.NewClient(t.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sftp session: %w", err)
+	}
+	t.sftp = c
+	return c, nil
+}
+
+func (t *nativeTransport) Upload(ctx context.Context, src, dst string) error {
+	c, err := t.sftpClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// Like scp, copying into an existing directory keeps the source name.
+	if fi, err := c.Stat(dst); err == nil && fi.IsDir() {
+		dst = path.Join(dst, filepath.Base(src))
+	}
+
+	if !info.IsDir() {
+		return uploadFile(c, src, dst, info.Mode())
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, filepath.ToSlash(rel))
+		if d.IsDir() {
+			return c.MkdirAll(target)
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return uploadFile(c, p, target, fi.Mode())
+	})
+}
+
+func uploadFile(c *sftp.Client, src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := c.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", dst, err)
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", src, err)
+	}
+	return out.Chmod(mode.Perm())
+}
+
+func (t *nativeTransport) Download(ctx context.Context, src, dst string) error {
+	c, err := t.sftpClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path %s: %w", src, err)
+	}
+
+	if fi, err := os.Stat(dst); err == nil && fi.IsDir() {
+		dst = filepath.Join(dst, path.Base(src))
+	}
+
+	if !info.IsDir() {
+		return downloadFile(c, src, dst, info.Mode())
+	}
+
+	walker := c.Walk(src)
+	for walker.Step() {
+		if err = walker.Err(); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), src), "/")
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		if walker.Stat().IsDir() {
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = downloadFile(c, walker.Path(), target, walker.Stat().Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downloadFile(c *sftp.Client, src, dst string, mode fs.FileMode) error {
+	in, err := c.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to download %s: %w", src, err)
+	}
+	return nil
+}
+
+func (t *nativeTransport) Close() error {
+	if t.sftp != nil {
+		t.sftp.Close()
+	}
+	if t.agent != nil {
+		t.agent.Close()
+	}
+	return t.client.Close()
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// watchWindowSize forwards local terminal resizes to the remote PTY until the returned
+// func is called.
+func watchWindowSize(fd int, session *ssh.Session) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGWINCH)
+
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				if width, height, err := term.GetSize(fd); err == nil {
+					_ = session.WindowChange(height, width)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
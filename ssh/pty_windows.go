@@ -0,0 +1,10 @@
+//go:build windows
+
+package ssh
+
+import "golang.org/x/crypto/ssh"
+
+// watchWindowSize is a no-op on Windows, which has no SIGWINCH.
+func watchWindowSize(fd int, session *ssh.Session) (stop func()) {
+	return func() {}
+}
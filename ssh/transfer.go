@@ -0,0 +1,449 @@
+package ssh
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"github.com/unweave/cli/ui"
+)
+
+// DefaultChunkSize is the number of bytes copied between progress reports.
+const DefaultChunkSize = 4 << 20
+
+// partialSuffix is appended to the name of a file while it's being copied. A partial
+// file left behind by an interrupted copy is picked up by the next one.
+const partialSuffix = ".uwpart"
+
+// ErrChecksumMismatch is returned when the SHA-256 of a copied file doesn't match the
+// SHA-256 of its source.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Progress reports how much of a file has been copied.
+type Progress struct {
+	// Path is the source path of the file.
+	Path string
+	// Done is the number of bytes copied so far, including the bytes resumed from an
+	// earlier copy.
+	Done int64
+	// Resumed is the number of bytes picked up from an earlier copy.
+	Resumed int64
+	Total   int64
+	// Skipped is set if the destination already was a copy of the file, so nothing was
+	// copied.
+	Skipped bool
+}
+
+// TransferOptions configures CopyToRemote and CopyFromRemote.
+type TransferOptions struct {
+	// ChunkSize is the number of bytes copied between progress reports. It defaults to
+	// DefaultChunkSize.
+	ChunkSize int64
+	// OnProgress, if not nil, is called after every chunk.
+	OnProgress func(Progress)
+	// Ignore, if not nil, skips the slash separated paths it matches when uploading a
+	// directory. Paths are relative to the directory.
+	Ignore interface {
+		MatchesPath(path string) bool
+	}
+}
+
+func (o TransferOptions) chunkSize() int64 {
+	if o.ChunkSize <= 0 {
+		return DefaultChunkSize
+	}
+	return o.ChunkSize
+}
+
+// CopyToRemote copies the local file or directory at src to dst on the remote host.
+// Files are copied in chunks and verified with SHA-256, and a copy that was interrupted
+// is resumed where it stopped: files that were already copied, with the same size and
+// SHA-256, are skipped, and the partial copy of a file is resumed. Copying a file into an
+// existing directory keeps the file name, while the contents of a directory are copied
+// into dst.
+//
+// Resuming and verification need the native transport. Other transports fall back to
+// Upload, which only leaves out the entries of a directory that opts.Ignore matches, not
+// the paths further down.
+func CopyToRemote(ctx context.Context, t Transport, src, dst string, opts TransferOptions) error {
+	nt, ok := t.(*nativeTransport)
+	if !ok {
+		ui.Debugf("Transport doesn't support resumable copies, falling back to a plain upload")
+		return uploadPlain(ctx, t, src, dst, opts)
+	}
+	c, err := nt.sftpClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if fi, err := c.Stat(dst); err == nil && fi.IsDir() {
+			dst = path.Join(dst, filepath.Base(src))
+		}
+		return nt.uploadResumable(ctx, c, src, dst, info, opts)
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && opts.Ignore != nil && opts.Ignore.MatchesPath(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := path.Join(dst, rel)
+		if d.IsDir() {
+			return c.MkdirAll(target)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return nt.uploadResumable(ctx, c, p, target, fi, opts)
+	})
+}
+
+// CopyFromRemote copies the remote file or directory at src to dst on the local
+// machine. It's the counterpart of CopyToRemote and works the same way.
+func CopyFromRemote(ctx context.Context, t Transport, src, dst string, opts TransferOptions) error {
+	nt, ok := t.(*nativeTransport)
+	if !ok {
+		ui.Debugf("Transport doesn't support resumable copies, falling back to a plain download")
+		return downloadPlain(ctx, t, src, dst)
+	}
+	c, err := nt.sftpClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path %s: %w", src, err)
+	}
+
+	if !info.IsDir() {
+		if fi, err := os.Stat(dst); err == nil && fi.IsDir() {
+			dst = filepath.Join(dst, path.Base(src))
+		}
+		return nt.downloadResumable(ctx, c, src, dst, info, opts)
+	}
+
+	walker := c.Walk(src)
+	for walker.Step() {
+		if err = walker.Err(); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), src), "/")
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		fi := walker.Stat()
+		if fi.IsDir() {
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		if err = nt.downloadResumable(ctx, c, walker.Path(), target, fi, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadPlain copies src to dst with t.Upload the way CopyToRemote does. Upload copies a
+// directory into dst if dst exists, so the entries of a directory are uploaded into dst
+// one by one instead.
+func uploadPlain(ctx context.Context, t Transport, src, dst string, opts TransferOptions) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return t.Upload(ctx, src, dst)
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if _, err = t.Run(ctx, "mkdir -p -- "+Quote(dst)); err != nil {
+		return fmt.Errorf("failed to create remote directory %s: %w", dst, err)
+	}
+	for _, e := range entries {
+		if opts.Ignore != nil && opts.Ignore.MatchesPath(e.Name()) {
+			continue
+		}
+		if err = t.Upload(ctx, filepath.Join(src, e.Name()), dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadPlain copies src to dst with t.Download the way CopyFromRemote does. Download
+// copies a directory into dst if dst exists, so the entries of a directory are
+// downloaded into dst one by one instead.
+func downloadPlain(ctx context.Context, t Transport, src, dst string) error {
+	q := Quote(src)
+	res, err := t.Run(ctx, fmt.Sprintf("test -d %s && find %s -mindepth 1 -maxdepth 1 -print0", q, q))
+	if err != nil {
+		// src isn't a directory, or doesn't exist and Download says so
+		return t.Download(ctx, src, dst)
+	}
+
+	if err = os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	for _, entry := range strings.Split(string(res.Stdout), "\x00") {
+		if entry == "" {
+			continue
+		}
+		if err = t.Download(ctx, entry, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *nativeTransport) uploadResumable(ctx context.Context, c *sftp.Client, src, dst string, info fs.FileInfo, opts TransferOptions) error {
+	if fi, err := c.Stat(dst); err == nil && fi.Mode().IsRegular() && fi.Size() == info.Size() {
+		same, err := t.sameContents(ctx, src, dst)
+		if err != nil {
+			return err
+		}
+		if same {
+			reportSkipped(src, info.Size(), opts)
+			return nil
+		}
+	}
+
+	part := dst + partialSuffix
+
+	var offset int64
+	if fi, err := c.Stat(part); err == nil && fi.Size() <= info.Size() {
+		offset = fi.Size()
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	out, err := c.OpenFile(part, flags)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", part, err)
+	}
+
+	err = copyChunks(ctx, out, in, src, offset, info.Size(), opts)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", src, err)
+	}
+
+	want, err := localSHA256(src)
+	if err != nil {
+		return err
+	}
+	got, err := t.remoteSHA256(ctx, part)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		c.Remove(part)
+		if offset > 0 {
+			// The partial file didn't belong to the same source, start over.
+			return t.uploadResumable(ctx, c, src, dst, info, opts)
+		}
+		return fmt.Errorf("%w for %s", ErrChecksumMismatch, dst)
+	}
+
+	if err = c.PosixRename(part, dst); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", dst, err)
+	}
+	return c.Chmod(dst, info.Mode().Perm())
+}
+
+func (t *nativeTransport) downloadResumable(ctx context.Context, c *sftp.Client, src, dst string, info fs.FileInfo, opts TransferOptions) error {
+	if fi, err := os.Stat(dst); err == nil && fi.Mode().IsRegular() && fi.Size() == info.Size() {
+		same, err := t.sameContents(ctx, dst, src)
+		if err != nil {
+			return err
+		}
+		if same {
+			reportSkipped(src, info.Size(), opts)
+			return nil
+		}
+	}
+
+	part := dst + partialSuffix
+
+	var offset int64
+	if fi, err := os.Stat(part); err == nil && fi.Size() <= info.Size() {
+		offset = fi.Size()
+	}
+
+	in, err := c.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	out, err := os.OpenFile(part, flags, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	err = copyChunks(ctx, out, in, src, offset, info.Size(), opts)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", src, err)
+	}
+
+	want, err := t.remoteSHA256(ctx, src)
+	if err != nil {
+		return err
+	}
+	got, err := localSHA256(part)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		os.Remove(part)
+		if offset > 0 {
+			// The partial file didn't belong to the same source, start over.
+			return t.downloadResumable(ctx, c, src, dst, info, opts)
+		}
+		return fmt.Errorf("%w for %s", ErrChecksumMismatch, dst)
+	}
+
+	return os.Rename(part, dst)
+}
+
+// sameContents returns whether the local file and the remote file have the same SHA-256.
+func (t *nativeTransport) sameContents(ctx context.Context, local, remote string) (bool, error) {
+	want, err := localSHA256(local)
+	if err != nil {
+		return false, err
+	}
+	got, err := t.remoteSHA256(ctx, remote)
+	if err != nil {
+		return false, err
+	}
+	return got == want, nil
+}
+
+func reportSkipped(name string, size int64, opts TransferOptions) {
+	if opts.OnProgress != nil {
+		opts.OnProgress(Progress{Path: name, Done: size, Resumed: size, Total: size, Skipped: true})
+	}
+}
+
+// copyChunks copies src from offset to total into dst, which must both be positioned
+// at offset, and reports the progress after every chunk.
+func copyChunks(ctx context.Context, dst io.WriteSeeker, src io.ReadSeeker, name string, offset, total int64, opts TransferOptions) error {
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	progress := Progress{Path: name, Done: offset, Resumed: offset, Total: total}
+	if opts.OnProgress != nil {
+		opts.OnProgress(progress)
+	}
+
+	chunk := opts.chunkSize()
+	for progress.Done < total {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if rest := total - progress.Done; rest < chunk {
+			chunk = rest
+		}
+		n, err := io.CopyN(dst, src, chunk)
+		progress.Done += n
+		if opts.OnProgress != nil && n > 0 {
+			opts.OnProgress(progress)
+		}
+		if err == io.EOF {
+			return fmt.Errorf("%s shrank while copying", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func localSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (t *nativeTransport) remoteSHA256(ctx context.Context, name string) (string, error) {
+	res, err := t.Run(ctx, "sha256sum -- "+Quote(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum remote file %s: %w", name, err)
+	}
+	fields := strings.Fields(string(res.Stdout))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("failed to checksum remote file %s: no output", name)
+	}
+	return fields[0], nil
+}
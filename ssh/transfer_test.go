@@ -0,0 +1,199 @@
+package ssh
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumableTransfers(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ctx := context.Background()
+	network, keyPath := startTestServer(t)
+
+	tr, err := Dial(ctx, Options{Network: network, PrivateKeyPath: keyPath})
+	require.NoError(t, err)
+	defer tr.Close()
+
+	data := strings.Repeat("0123456789", 1000)
+
+	t.Run("should upload a file in chunks and report progress", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(src, []byte(data), 0640))
+		remote := t.TempDir()
+
+		var reports []Progress
+		opts := TransferOptions{ChunkSize: 4096, OnProgress: func(p Progress) { reports = append(reports, p) }}
+		require.NoError(t, CopyToRemote(ctx, tr, src, remote, opts))
+
+		buf, err := os.ReadFile(filepath.Join(remote, "data.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, data, string(buf))
+		assert.NoFileExists(t, filepath.Join(remote, "data.bin"+partialSuffix))
+
+		require.Len(t, reports, 4)
+		assert.Equal(t, Progress{Path: src, Done: 0, Total: 10000}, reports[0])
+		assert.Equal(t, int64(4096), reports[1].Done)
+		assert.Equal(t, int64(10000), reports[3].Done)
+	})
+
+	t.Run("should resume a partial upload", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(src, []byte(data), 0644))
+		dst := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(dst+partialSuffix, []byte(data[:6000]), 0644))
+
+		var last Progress
+		require.NoError(t, CopyToRemote(ctx, tr, src, dst, TransferOptions{OnProgress: func(p Progress) { last = p }}))
+
+		buf, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(buf))
+		assert.Equal(t, int64(6000), last.Resumed)
+		assert.Equal(t, int64(10000), last.Done)
+	})
+
+	t.Run("should start over if the partial file doesn't match", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(src, []byte(data), 0644))
+		dst := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(dst+partialSuffix, []byte("garbage"), 0644))
+
+		require.NoError(t, CopyToRemote(ctx, tr, src, dst, TransferOptions{}))
+
+		buf, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(buf))
+	})
+
+	t.Run("should resume a partial download", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(src, []byte(data), 0644))
+		dst := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(dst+partialSuffix, []byte(data[:2500]), 0644))
+
+		var last Progress
+		require.NoError(t, CopyFromRemote(ctx, tr, src, dst, TransferOptions{OnProgress: func(p Progress) { last = p }}))
+
+		buf, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(buf))
+		assert.NoFileExists(t, dst+partialSuffix)
+		assert.Equal(t, int64(2500), last.Resumed)
+	})
+
+	t.Run("should copy the contents of a directory both ways", func(t *testing.T) {
+		local := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(local, "nested"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(local, ".git"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(local, "nested", "train.py"), []byte("print(1)"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(local, ".git", "HEAD"), []byte("ref"), 0644))
+
+		remote := t.TempDir()
+		opts := TransferOptions{Ignore: ignore.CompileIgnoreLines(".git")}
+		require.NoError(t, CopyToRemote(ctx, tr, local, remote, opts))
+
+		buf, err := os.ReadFile(filepath.Join(remote, "nested", "train.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print(1)", string(buf))
+		assert.NoDirExists(t, filepath.Join(remote, ".git"))
+
+		back := filepath.Join(t.TempDir(), "back")
+		require.NoError(t, CopyFromRemote(ctx, tr, remote, back, TransferOptions{}))
+
+		buf, err = os.ReadFile(filepath.Join(back, "nested", "train.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print(1)", string(buf))
+	})
+
+	t.Run("should skip files that were already copied when resuming a directory copy", func(t *testing.T) {
+		local := t.TempDir()
+		for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+			require.NoError(t, os.WriteFile(filepath.Join(local, name), []byte(data), 0644))
+		}
+		remote := t.TempDir()
+		// a.bin was copied before the copy was interrupted, b.bin has different contents
+		// of the same size.
+		require.NoError(t, os.WriteFile(filepath.Join(remote, "a.bin"), []byte(data), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(remote, "b.bin"), []byte(strings.Repeat("x", len(data))), 0644))
+
+		skipped := map[string]bool{}
+		opts := TransferOptions{OnProgress: func(p Progress) {
+			if p.Skipped {
+				skipped[filepath.Base(p.Path)] = true
+			}
+		}}
+		require.NoError(t, CopyToRemote(ctx, tr, local, remote, opts))
+		assert.Equal(t, map[string]bool{"a.bin": true}, skipped)
+		for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+			buf, err := os.ReadFile(filepath.Join(remote, name))
+			require.NoError(t, err)
+			assert.Equal(t, data, string(buf), name)
+		}
+
+		skipped = map[string]bool{}
+		back := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(back, "c.bin"), []byte(data), 0644))
+		require.NoError(t, CopyFromRemote(ctx, tr, remote, back, opts))
+		assert.Equal(t, map[string]bool{"c.bin": true}, skipped)
+	})
+}
+
+func TestPlainTransfers(t *testing.T) {
+	if _, err := exec.LookPath("scp"); err != nil {
+		t.Skip("scp isn't installed")
+	}
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ctx := context.Background()
+	network, keyPath := startTestServer(t)
+	tr := newBinaryTransport(Options{Network: network, PrivateKeyPath: keyPath, Args: []string{"-o", "BatchMode=yes"}})
+
+	t.Run("should copy the contents of a directory into an existing one both ways", func(t *testing.T) {
+		local := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(local, "nested"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(local, ".git"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(local, "nested", "train.py"), []byte("print(1)"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(local, ".git", "HEAD"), []byte("ref"), 0644))
+
+		remote := t.TempDir()
+		opts := TransferOptions{Ignore: ignore.CompileIgnoreLines(".git")}
+		// Copying twice must not nest the directory in itself
+		require.NoError(t, CopyToRemote(ctx, tr, local, remote, opts))
+		require.NoError(t, CopyToRemote(ctx, tr, local, remote, opts))
+
+		buf, err := os.ReadFile(filepath.Join(remote, "nested", "train.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print(1)", string(buf))
+		assert.NoDirExists(t, filepath.Join(remote, "nested", "nested"))
+		assert.NoDirExists(t, filepath.Join(remote, ".git"))
+
+		back := t.TempDir()
+		require.NoError(t, CopyFromRemote(ctx, tr, remote, back, TransferOptions{}))
+		require.NoError(t, CopyFromRemote(ctx, tr, remote, back, TransferOptions{}))
+
+		buf, err = os.ReadFile(filepath.Join(back, "nested", "train.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print(1)", string(buf))
+		assert.NoDirExists(t, filepath.Join(back, "nested", "nested"))
+	})
+
+	t.Run("should keep the name of a file copied into a directory", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "data.bin")
+		require.NoError(t, os.WriteFile(src, []byte("data"), 0644))
+
+		remote := t.TempDir()
+		require.NoError(t, CopyToRemote(ctx, tr, src, remote, TransferOptions{}))
+		assert.FileExists(t, filepath.Join(remote, "data.bin"))
+
+		back := t.TempDir()
+		require.NoError(t, CopyFromRemote(ctx, tr, filepath.Join(remote, "data.bin"), back, TransferOptions{}))
+		assert.FileExists(t, filepath.Join(back, "data.bin"))
+	})
+}
@@ -0,0 +1,110 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/unweave/cli/config"
+	"github.com/unweave/cli/ui"
+	"github.com/unweave/unweave/api/types"
+)
+
+const (
+	// TransportNative uses the Go SSH implementation. It's the default.
+	TransportNative = "native"
+	// TransportBinary shells out to the system ssh and scp binaries.
+	TransportBinary = "binary"
+)
+
+// ErrConnectionClosed is returned when the remote host drops the connection before a
+// command has finished running.
+var ErrConnectionClosed = errors.New("the remote host closed the connection")
+
+// Transport is a connection to a remote session host. It can be used to open
+// interactive terminals, run commands and copy files.
+type Transport interface {
+	// Shell attaches the local terminal to a PTY on the remote host. If command is
+	// empty, the user's login shell is started.
+	Shell(ctx context.Context, command []string) error
+
+	// Run executes command on the remote host and captures its output. A non-zero exit
+	// status is returned as an *ExitError alongside the result.
+	Run(ctx context.Context, command string) (*Result, error)
+
+	// Upload copies the local file or directory at src to dst on the remote host.
+	Upload(ctx context.Context, src, dst string) error
+
+	// Download copies the remote file or directory at src to dst on the local machine.
+	Download(ctx context.Context, src, dst string) error
+
+	Close() error
+}
+
+// Result holds the captured output of a remote command.
+type Result struct {
+	Stdout     []byte
+	Stderr     []byte
+	ExitStatus int
+}
+
+// ExitError is returned when a remote command exits with a non-zero status.
+type ExitError struct {
+	Command    string
+	ExitStatus int
+	Stderr     string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("command %q exited with status %d", e.Command, e.ExitStatus)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Quote quotes s for a POSIX shell, so that it can be used as a single word in the
+// commands passed to Transport.Run.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Options configures how a Transport connects to a session.
+type Options struct {
+	Network        types.ExecNetwork
+	PrivateKeyPath string
+
+	// Args are extra arguments for the ssh binary, e.g. `-L 8080:localhost:8080`.
+	// Setting them forces the binary transport since they only make sense there.
+	Args []string
+}
+
+// Dial opens a Transport to the session described by opts. It uses the native Go
+// implementation unless the binary transport is requested explicitly or the native
+// connection fails and an ssh binary is available to fall back to.
+func Dial(ctx context.Context, opts Options) (Transport, error) {
+	if opts.Network.Host == "" {
+		return nil, fmt.Errorf("no connection info for host")
+	}
+
+	if len(opts.Args) > 0 || strings.EqualFold(config.Config.Unweave.SSHTransport, TransportBinary) {
+		return newBinaryTransport(opts), nil
+	}
+
+	t, err := dialNative(ctx, opts)
+	if err == nil {
+		return t, nil
+	}
+	if _, lerr := exec.LookPath("ssh"); lerr != nil {
+		return nil, err
+	}
+
+	ui.Debugf("Native SSH transport failed, falling back to the ssh binary: %v", err)
+	return newBinaryTransport(opts), nil
+}
+
+func userAtHost(network types.ExecNetwork) string {
+	return fmt.Sprintf("%s@%s", network.User, network.Host)
+}
@@ -0,0 +1,247 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/unweave/unweave/api/types"
+)
+
+// startTestServer starts an in-process SSH server that runs exec requests with the
+// local shell and serves the sftp subsystem from the local filesystem.
+func startTestServer(t *testing.T) (types.ExecNetwork, string) {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := gossh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	// The client key is written in a format that the ssh binary can read too
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(clientKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "id_ecdsa")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	authorized, err := gossh.NewPublicKey(&clientKey.PublicKey)
+	require.NoError(t, err)
+
+	cfg := &gossh.ServerConfig{
+		PublicKeyCallback: func(_ gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown public key")
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, cfg)
+		}
+	}()
+
+	network := types.ExecNetwork{
+		Host: "127.0.0.1",
+		Port: ln.Addr().(*net.TCPAddr).Port,
+		User: "unweave",
+	}
+	return network, keyPath
+}
+
+func serveTestConn(conn net.Conn, cfg *gossh.ServerConfig) {
+	_, chans, reqs, err := gossh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go gossh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(gossh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				switch req.Type {
+				case "exec":
+					var payload struct{ Command string }
+					gossh.Unmarshal(req.Payload, &payload)
+					req.Reply(true, nil)
+
+					cmd := exec.Command("sh", "-c", payload.Command)
+					cmd.Stdout = ch
+					cmd.Stderr = ch.Stderr()
+
+					status := 0
+					if err := cmd.Run(); err != nil {
+						status = 127
+						var exitErr *exec.ExitError
+						if errors.As(err, &exitErr) {
+							status = exitErr.ExitCode()
+						}
+					}
+					ch.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{uint32(status)}))
+					return
+				case "subsystem":
+					var payload struct{ Name string }
+					gossh.Unmarshal(req.Payload, &payload)
+					if payload.Name != "sftp" {
+						req.Reply(false, nil)
+						continue
+					}
+					req.Reply(true, nil)
+
+					srv, err := sftp.NewServer(ch)
+					if err != nil {
+						return
+					}
+					srv.Serve()
+					// scp fails without an exit status, which has to be sent before Close
+					// closes the channel
+					ch.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{0}))
+					srv.Close()
+					return
+				default:
+					req.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+func TestNativeTransport(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ctx := context.Background()
+	network, keyPath := startTestServer(t)
+
+	tr, err := Dial(ctx, Options{Network: network, PrivateKeyPath: keyPath})
+	require.NoError(t, err)
+	defer tr.Close()
+
+	_, ok := tr.(*nativeTransport)
+	assert.True(t, ok, "expected the native transport")
+
+	t.Run("should capture output of a command", func(t *testing.T) {
+		res, err := tr.Run(ctx, "echo hello && echo oops >&2")
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello\n", string(res.Stdout))
+		assert.Equal(t, "oops\n", string(res.Stderr))
+		assert.Equal(t, 0, res.ExitStatus)
+	})
+
+	t.Run("should return the exit status of a failed command", func(t *testing.T) {
+		res, err := tr.Run(ctx, "echo failed >&2; exit 3")
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitStatus)
+		assert.Equal(t, "failed\n", exitErr.Stderr)
+		assert.Equal(t, 3, res.ExitStatus)
+	})
+
+	t.Run("should upload and download a file", func(t *testing.T) {
+		local := t.TempDir()
+		remote := t.TempDir()
+
+		src := filepath.Join(local, "data.txt")
+		require.NoError(t, os.WriteFile(src, []byte("some data"), 0644))
+
+		require.NoError(t, tr.Upload(ctx, src, filepath.Join(remote, "uploaded.txt")))
+		buf, err := os.ReadFile(filepath.Join(remote, "uploaded.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "some data", string(buf))
+
+		dst := filepath.Join(local, "downloaded.txt")
+		require.NoError(t, tr.Download(ctx, filepath.Join(remote, "uploaded.txt"), dst))
+		buf, err = os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "some data", string(buf))
+	})
+
+	t.Run("should upload a directory into an existing remote directory", func(t *testing.T) {
+		local := filepath.Join(t.TempDir(), "project")
+		remote := t.TempDir()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(local, "nested"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(local, "nested", "train.py"), []byte("print(1)"), 0644))
+
+		require.NoError(t, tr.Upload(ctx, local, remote))
+		buf, err := os.ReadFile(filepath.Join(remote, "project", "nested", "train.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print(1)", string(buf))
+	})
+}
+
+func TestDialWithSSHArgsUsesBinaryTransport(t *testing.T) {
+	network := types.ExecNetwork{Host: "127.0.0.1", Port: 22, User: "unweave"}
+
+	tr, err := Dial(context.Background(), Options{Network: network, Args: []string{"-L", "8080:localhost:8080"}})
+	require.NoError(t, err)
+
+	_, ok := tr.(*binaryTransport)
+	assert.True(t, ok, "expected the binary transport")
+}
+
+func TestBinaryTransportArgs(t *testing.T) {
+	network := types.ExecNetwork{Host: "127.0.0.1", Port: 2222, User: "unweave"}
+	tr := newBinaryTransport(Options{Network: network, PrivateKeyPath: "/keys/id"})
+
+	assert.Equal(t, []string{
+		"-p", "2222", "-i", "/keys/id",
+		"-o", "UserKnownHostsFile=/dev/null", "-o", "StrictHostKeyChecking=no",
+	}, tr.args("-p"))
+	assert.Equal(t, []string{"-P", "2222"}, tr.args("-P")[:2], "scp takes the port as -P")
+
+	tr = newBinaryTransport(Options{Network: types.ExecNetwork{Host: "127.0.0.1", User: "unweave"}})
+	assert.NotContains(t, tr.args("-p"), "-p", "the default port is left to ssh")
+
+	tr = newBinaryTransport(Options{Network: network, Args: []string{"-L", "8080:localhost:8080"}})
+	assert.Equal(t, []string{
+		"-p", "2222",
+		"-o", "UserKnownHostsFile=/dev/null", "-o", "StrictHostKeyChecking=no",
+		"-L", "8080:localhost:8080",
+	}, tr.args("-p"), "the user's arguments come after the generated options")
+}
+
+func TestQuote(t *testing.T) {
+	out, err := exec.Command("sh", "-c", "printf %s "+Quote(`it's "$HOME" \n`)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, `it's "$HOME" \n`, string(out))
+}
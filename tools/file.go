@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockTimeout is how long LockFile waits for another CLI to release a lock.
+	lockTimeout = 5 * time.Second
+	// staleLockAge is how old a lock has to be to be considered abandoned.
+	staleLockAge = 30 * time.Second
+)
+
+// LockFile takes the lock of the file at path and returns the function that releases
+// it, so that CLIs running at the same time don't lose each other's changes to the file.
+// The lock is a file created exclusively next to it, which works on every OS. A lock
+// older than staleLockAge was left behind by a CLI that died and is taken over.
+func LockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock %s: %s exists", path, lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// WriteFileAtomic replaces the file at path with data at once, so that it can be read
+// while it's written.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -4,10 +4,12 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	ignore "github.com/sabhiram/go-gitignore"
 )
@@ -61,6 +63,95 @@ func Tar(rootDir string, w io.Writer, ignore *ignore.GitIgnore) error {
 	})
 }
 
+// TarFiles writes a gzipped tar archive of the files at paths to w. Paths are slash
+// separated and relative to rootDir, and are stored in the archive as they are.
+//
+// The files may change while they're archived: files that no longer exist or aren't
+// regular files anymore are left out. Every file is copied aside before it's archived,
+// and files whose size or modification time changed while they were copied are left out
+// too. Their paths are returned, so that callers can archive them again later.
+func TarFiles(rootDir string, paths []string, w io.Writer) ([]string, error) {
+	gzw := gzip.NewWriter(w)
+	defer gzw.Close()
+
+	tw := tar.NewWriter(gzw)
+	defer tw.Close()
+
+	spool, err := os.CreateTemp("", "uw-tar-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var changed []string
+	for _, p := range paths {
+		ok, err := tarFile(tw, spool, filepath.Join(rootDir, filepath.FromSlash(p)), p)
+		if err != nil {
+			return changed, err
+		}
+		if !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed, nil
+}
+
+// tarFile archives the file at path as name. The file is copied to spool first, since
+// the header with its size has to be written before its contents. It returns false if
+// the file changed while it was copied, in which case nothing is written.
+func tarFile(tw *tar.Writer, spool *os.File, path, name string) (bool, error) {
+	data, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer data.Close()
+
+	fi, err := data.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !fi.Mode().IsRegular() {
+		return true, nil
+	}
+
+	if err = spool.Truncate(0); err != nil {
+		return false, err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	n, err := io.Copy(spool, data)
+	if err != nil {
+		return false, err
+	}
+	after, err := data.Stat()
+	if err != nil {
+		return false, err
+	}
+	if n != fi.Size() || after.Size() != fi.Size() || !after.ModTime().Equal(fi.ModTime()) {
+		return false, nil
+	}
+
+	header, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return false, err
+	}
+	header.Name = name
+
+	if err = tw.WriteHeader(header); err != nil {
+		return false, err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	_, err = io.CopyN(tw, spool, n)
+	return err == nil, err
+}
+
 func Zip(rootDir string, w io.Writer, ignore *ignore.GitIgnore) error {
 	zw := zip.NewWriter(w)
 	defer zw.Close()
@@ -99,3 +190,96 @@ func Zip(rootDir string, w io.Writer, ignore *ignore.GitIgnore) error {
 		return nil
 	})
 }
+
+// FileSize is a file of a directory and its size in bytes.
+type FileSize struct {
+	Path string
+	Size int64
+}
+
+// ArchiveFiles returns the files Tar and Zip archive from rootDir, with paths relative to
+// rootDir. Symlinks to files are followed like Zip does, and have the size of the file
+// they point to.
+func ArchiveFiles(rootDir string, ignore *ignore.GitIgnore) ([]FileSize, error) {
+	var files []FileSize
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+		if ignore.MatchesPath(rPath) || d.IsDir() {
+			return nil
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		files = append(files, FileSize{Path: rPath, Size: fi.Size()})
+		return nil
+	})
+	return files, err
+}
+
+// Untar extracts the gzipped tar archive in r to dstDir. The first stripComponents
+// path elements of every entry are removed, like tar's --strip-components flag.
+func Untar(r io.Reader, dstDir string, stripComponents int) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	root, err := filepath.Abs(dstDir)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Split(strings.Trim(filepath.ToSlash(header.Name), "/"), "/")
+		if len(parts) <= stripComponents {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(strings.Join(parts[stripComponents:], "/")))
+		if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes the destination directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			if err = os.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+}
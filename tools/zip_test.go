@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readTar returns the files in a gzipped tar archive by name.
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	gzr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		buf, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(buf)
+	}
+}
+
+func TestTarFiles(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "train.py"), []byte("print('hi')"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "model.py"), []byte("model"), 0644))
+
+	t.Run("should archive the files at paths", func(t *testing.T) {
+		var buf bytes.Buffer
+		changed, err := TarFiles(root, []string{"train.py", "src/model.py"}, &buf)
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+		assert.Equal(t, map[string]string{"train.py": "print('hi')", "src/model.py": "model"}, readTar(t, &buf))
+	})
+
+	t.Run("should leave out files that were removed or aren't regular files", func(t *testing.T) {
+		var buf bytes.Buffer
+		changed, err := TarFiles(root, []string{"deleted.py", "src", "train.py"}, &buf)
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+		assert.Equal(t, map[string]string{"train.py": "print('hi')"}, readTar(t, &buf))
+	})
+
+	t.Run("should leave out and return files that change while they're archived", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("needs /proc")
+		}
+		// Files in /proc are listed with a size of 0 but have contents, like a file that
+		// grows after it's opened.
+		var buf bytes.Buffer
+		changed, err := TarFiles("/proc/self", []string{"status"}, &buf)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"status"}, changed)
+		assert.Empty(t, readTar(t, &buf))
+	})
+}
+
+func TestArchiveFiles(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "train.py"), []byte("print('hi')"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "model.py"), []byte("model"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "weights.bin"), []byte("ignored"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(root, "train.py"), filepath.Join(root, "link.py")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "src"), filepath.Join(root, "link")))
+
+	files, err := ArchiveFiles(root, ignore.CompileIgnoreLines("*.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, []FileSize{
+		{Path: "link.py", Size: 11},
+		{Path: filepath.Join("src", "model.py"), Size: 5},
+		{Path: "train.py", Size: 11},
+	}, files, "symlinked files are counted at the size of the file Zip reads")
+}
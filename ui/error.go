@@ -3,7 +3,6 @@ package ui
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/muesli/reflow/indent"
 	"github.com/muesli/reflow/wordwrap"
@@ -44,17 +43,19 @@ func (e *Error) Verbose() string {
 	return str
 }
 
+// HandleError prints err for the user and returns it. API errors are rendered in full
+// and unauthorized requests ask the user to login. It never exits, that's left to the
+// command.
 func HandleError(err error) error {
 	var e *types.Error
-	if errors.As(err, &e) {
-		if e.Code == 401 {
-			fmt.Println("Unauthorized. Please login with `unweave login`")
-			os.Exit(1)
-			return nil
-		}
+	switch {
+	case errors.As(err, &e) && e.Code == 401:
+		fmt.Println("Unauthorized. Please login with `unweave login`")
+	case errors.As(err, &e):
 		uie := &Error{Error: e}
 		fmt.Println(uie.Verbose())
-		os.Exit(1)
+	case err != nil:
+		Errorf("%s", err)
 	}
 	return err
 }
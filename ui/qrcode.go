@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// qrQuietZone is the number of light modules around the code. The spec asks for four,
+// but two are enough for phone cameras and keep the code small in a terminal.
+const qrQuietZone = 2
+
+// QRCode renders text as a QR code for the terminal, using half blocks so that every
+// character covers two rows of modules. Light modules are drawn, which suits the dark
+// backgrounds most terminals use.
+func QRCode(text string) (string, error) {
+	q, err := qrcode.New(text, qrcode.Low)
+	if err != nil {
+		return "", err
+	}
+	q.DisableBorder = true
+	modules := q.Bitmap()
+	size := len(modules)
+
+	light := func(row, col int) bool {
+		if row < 0 || col < 0 || row >= size || col >= size {
+			return true
+		}
+		return !modules[row][col]
+	}
+
+	var sb strings.Builder
+	for row := -qrQuietZone; row < size+qrQuietZone; row += 2 {
+		for col := -qrQuietZone; col < size+qrQuietZone; col++ {
+			top, bottom := light(row, col), light(row+1, col)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteRune('\n')
+	}
+	return sb.String(), nil
+}
@@ -17,6 +17,11 @@ func center(s string, w int) string {
 }
 
 func Table(title string, cols []Column, rows []Row) {
+	fmt.Print(FormatTable(title, cols, rows))
+}
+
+// FormatTable returns the table Table prints.
+func FormatTable(title string, cols []Column, rows []Row) string {
 	totalWidth := 0
 	header := ""
 	body := ""
@@ -54,7 +59,7 @@ func Table(title string, cols []Column, rows []Row) {
 		body += "\n"
 	}
 
-	fmt.Printf("%s\n%s%s%s%s", title, separator, header, separator, body)
+	return fmt.Sprintf("%s\n%s%s%s%s", title, separator, header, separator, body)
 }
 
 // MaxFieldLength can be used to compute the maximum length of any given column based on the length of the greatest row
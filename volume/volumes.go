@@ -14,8 +14,11 @@ func Create(ctx context.Context, name string, size int) (types.Volume, error) {
 		size = config.DefaultVolumeSize
 	}
 
+	projectOwner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return types.Volume{}, err
+	}
 	client := config.InitUnweaveClient()
-	projectOwner, projectName := config.GetProjectOwnerAndName()
 	projectProvider := config.Config.Project.DefaultProvider
 
 	if config.Provider != "" {
@@ -36,10 +39,13 @@ func Create(ctx context.Context, name string, size int) (types.Volume, error) {
 
 // Delete deletes a volume
 func Delete(ctx context.Context, name string) error {
-	client := config.InitUnweaveClient()
-	projectOwner, projectName := config.GetProjectOwnerAndName()
+	projectOwner, projectName, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
-	err := client.Volume.Delete(ctx, projectOwner, projectName, name)
+	client := config.InitUnweaveClient()
+	err = client.Volume.Delete(ctx, projectOwner, projectName, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete volume: %w", err)
 	}
@@ -48,7 +54,10 @@ func Delete(ctx context.Context, name string) error {
 
 // List lists all volumes for a given project or default project if none is specified
 func List(ctx context.Context) ([]types.Volume, error) {
-	ownerID, projectID := config.GetProjectOwnerAndName()
+	ownerID, projectID, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return nil, err
+	}
 
 	var project string
 	if projectID != "" {
@@ -65,10 +74,13 @@ func List(ctx context.Context) ([]types.Volume, error) {
 
 // Update updates an existing volume
 func Update(ctx context.Context, name string, newSize int) error {
-	userID, projectID := config.GetProjectOwnerAndName()
+	userID, projectID, err := config.GetProjectOwnerAndName()
+	if err != nil {
+		return err
+	}
 
 	client := config.InitUnweaveClient()
-	err := client.Volume.Update(ctx, userID, projectID, name, types.VolumeResizeRequest{
+	err = client.Volume.Update(ctx, userID, projectID, name, types.VolumeResizeRequest{
 		IDOrName: name,
 		Size:     newSize,
 	})